@@ -0,0 +1,977 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/internal/bundle"
+	"github.com/Uri2001/codex-sessions/internal/config"
+	"github.com/Uri2001/codex-sessions/internal/daemon"
+	"github.com/Uri2001/codex-sessions/internal/history"
+	"github.com/Uri2001/codex-sessions/internal/project"
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/internal/ui"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// command is a subcommand of the CLI. Each command parses its own flags from args.
+type command struct {
+	name string
+	// usage is the argument synopsis shown after the command name.
+	usage   string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "ui", usage: "[codex args...]", summary: "Browse sessions interactively (default).", run: runUI},
+		{name: "list", usage: "[--format text|jsonl]", summary: "Print all sessions, newest first.", run: runList},
+		{name: "resume", usage: "[--last | --last-for-cwd | --again | <id-prefix>] [codex args...]", summary: "Resume a session without the UI.", run: runResume},
+		{name: "delete", usage: "[--purge] <id-prefix>...", summary: "Move sessions to the trash.", run: runDelete},
+		{name: "prune", usage: "[--empty-trash]", summary: "Expire old trash entries and remove empty directories.", run: runPrune},
+		{name: "grep", usage: "[--context n] [-i] <regex>", summary: "Print the transcript lines of every session matching a pattern.", run: runGrep},
+		{name: "export", usage: "[-o file] <id-prefix>", summary: "Write a session transcript as Markdown.", run: runExport},
+		{name: "show", usage: "[--no-pager] <id-prefix>", summary: "Read a session transcript as colored text in $PAGER.", run: runShow},
+		{name: "export-all", usage: "--out <dir> [--cwd path] [--format md|html]", summary: "Write every transcript of a project, with an index page.", run: runExportAll},
+		{name: "scrub", usage: "--pattern <regex> [--id <id-prefix>]", summary: "Permanently mask matching secrets in session files.", run: runScrub},
+		{name: "bundle", usage: "<id-prefix>... <out.tar.zst>", summary: "Package sessions and their annotations for another machine.", run: runBundle},
+		{name: "import", usage: "<bundle.tar.zst>", summary: "Add the sessions from a bundle to the first sessions root.", run: runImport},
+		{name: "backup", usage: "[--dest dir] [--keep n]", summary: "Snapshot the sessions tree and rotate old snapshots.", run: runBackup},
+		{name: "index", usage: "rebuild | status", summary: "Build or inspect the content index that speeds up transcript searches.", run: runIndex},
+		{name: "stats", usage: "", summary: "Summarise the sessions directory.", run: runStats},
+		{name: "watch", usage: "[--json] [--interval d]", summary: "Print session changes as they happen.", run: runWatch},
+		{name: "mcp", usage: "", summary: "Run a Model Context Protocol server on stdin/stdout.", run: runMCP},
+		{name: "daemon", usage: "[--interval d]", summary: "Keep a warm session index for instant UI startup.", run: runDaemon},
+		{name: "serve", usage: "[--listen addr] [--web]", summary: "Serve sessions and transcripts over HTTP, optionally with a web viewer.", run: runServe},
+		{name: "help", usage: "", summary: "Show this help.", run: runHelp},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet returns a flag set for the named command whose usage message shows its synopsis.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		cmd, _ := findCommand(name)
+		fmt.Fprintf(fs.Output(), "Usage: codex-sessions [flags] %s %s\n\n%s\n", name, cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runHelp(e *env, args []string) error {
+	if len(args) > 0 {
+		if cmd, ok := findCommand(args[0]); ok && cmd.name != "help" {
+			return cmd.run(e, []string{"-h"})
+		}
+	}
+	flag.CommandLine.SetOutput(os.Stdout)
+	usage()
+	return nil
+}
+
+// loadSessions loads the sessions root, treating partial load errors as warnings.
+func loadSessions(e *env) []sessions.Session {
+	list, err := sessions.LoadRootsContext(context.Background(), e.roots, e.load)
+	if err != nil {
+		warnf("%v", err)
+	}
+	return withWorkdirs(e, list)
+}
+
+// withWorkdirs replaces the working directories of list by those moved with W in the UI, so
+// resuming, filtering and reporting from the command line use them too.
+func withWorkdirs(e *env, list []sessions.Session) []sessions.Session {
+	if meta, err := sidecar.Open(e.root); err == nil {
+		for i := range list {
+			list[i] = meta.WithWorkdir(list[i])
+		}
+	}
+	return list
+}
+
+// resolveSession returns the single session matching prefix.
+func resolveSession(list []sessions.Session, prefix string) (sessions.Session, error) {
+	matches := sessions.MatchPrefix(list, prefix)
+	switch len(matches) {
+	case 0:
+		return sessions.Session{}, fmt.Errorf("%w matching %q", errNoSessions, prefix)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, sess := range matches {
+		ids[i] = sess.ID
+	}
+	return sessions.Session{}, fmt.Errorf("%q is ambiguous: %s", prefix, strings.Join(ids, ", "))
+}
+
+// loadFromDaemon returns the sessions from a running daemon, loading them directly when none is
+// available, with the working directories moved with W.
+func loadFromDaemon(e *env) ([]sessions.Session, error) {
+	if list, ok := daemonSessions(e, e.load); ok {
+		return withWorkdirs(e, list), nil
+	}
+	list, err := sessions.LoadRootsContext(context.Background(), e.roots, e.load)
+	return withWorkdirs(e, list), err
+}
+
+// daemonSessions asks a running daemon for the sessions of e.roots, read with opts. The working
+// directories are as recorded; the UI applies those moved with W itself, remembering the
+// recorded ones.
+func daemonSessions(e *env, opts sessions.Options) ([]sessions.Session, bool) {
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return nil, false
+	}
+	list, err := daemon.Fetch(socket, e.roots, opts)
+	return list, err == nil
+}
+
+func runUI(e *env, args []string) error {
+	fs := newFlagSet("ui")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	proceed, status, err := firstRun(e)
+	if err != nil || !proceed {
+		return err
+	}
+	return openUI(e, fs.Args(), "", status)
+}
+
+// openUI runs the TUI, optionally pre-filtered with query, and resumes the selected session.
+func openUI(e *env, extraArgs []string, query, status string) error {
+	cfg, cfgErr := e.config()
+	if cfgErr != nil && status == "" {
+		status = cfgErr.Error()
+	}
+	load := e.load
+	load.FileLimit = cfg.Limit
+	if flagPassed("limit") {
+		load.FileLimit = *flagLimit
+	}
+	// Without a daemon the UI starts empty and sessions stream in while the directory is parsed.
+	// A daemon has every file indexed, so it does not answer when a file limit is asked for.
+	list, _ := daemonSessions(e, load)
+	stream := func(ctx context.Context, opts sessions.Options, emit func(sessions.Session)) error {
+		return sessions.StreamRootsContext(ctx, e.roots, opts, emit)
+	}
+
+	enricher, err := newEnricher(cfg)
+	if err != nil {
+		warnf("%v", err)
+		if status == "" {
+			status = err.Error()
+		}
+	}
+
+	proj, err := project.Find(".")
+	if err != nil && status == "" {
+		status = err.Error()
+	}
+
+	historyPath, _ := history.DefaultPath()
+	var queries []string
+	if historyPath != "" {
+		if queries, err = history.Load(historyPath); err != nil && status == "" {
+			status = err.Error()
+		}
+	}
+
+	// Writing to stderr while the TUI owns the terminal would garble the screen, so file
+	// operations are collected and printed once it exits.
+	var opLog bytes.Buffer
+	run := ui.Run
+	if *flagPlainUI {
+		run = func(list []sessions.Session, opts ui.Options) (ui.Selection, error) {
+			return ui.RunPlain(list, opts, os.Stdin, os.Stderr)
+		}
+	}
+	chosen, err := run(list, ui.Options{
+		SessionsRoot: e.root,
+		Files:        fileOptions(&opLog),
+		Status:       status,
+		Query:        query,
+		Keymap:       cfg.Keymap,
+		Keys:         cfg.Keys,
+		Mouse:        !cfg.DisableMouse,
+		Theme:        cfg.Theme,
+		ThemeColors:  cfg.Colors,
+		Columns:      cfg.Columns,
+		Sort:         cfg.Sort,
+		DimAfter:     time.Duration(cfg.DimAfter()) * 24 * time.Hour,
+		EnterMenu:    cfg.EnterMenu,
+		ActionWidth:  cfg.LastActionWidth,
+		TwoLineRows:  cfg.TwoLineRows,
+		ShowHidden:   *flagAll,
+		Ranking:      ranking(cfg.Ranking),
+		TimeRange:    e.timeRange,
+		Prices:       cfg.Prices,
+		Redactor:     e.redactor(),
+		Templates:    cfg.Templates,
+		Project:      projectSessions(proj),
+		Compact:      *flagChoose,
+		Height:       *flagHeight,
+		MultiSelect:  *flagNoResume,
+		History:      queries,
+		SaveHistory: func(list []string) error {
+			if historyPath == "" {
+				return nil
+			}
+			return history.Save(historyPath, list)
+		},
+		SavedSearches: cfg.SavedSearches,
+		SaveSearch: func(name, query string) error {
+			return saveSearch(e, name, query)
+		},
+		Enricher: enricher,
+		Stream:   stream,
+		Load:     load,
+		ContentCandidates: func(list []sessions.Session, pattern string) []sessions.Session {
+			list, _ = contentCandidates(e, list, pattern)
+			return list
+		},
+	})
+	os.Stderr.Write(opLog.Bytes())
+	if err != nil {
+		return fmt.Errorf("run ui: %w", err)
+	}
+	if chosen.New {
+		return startNew(chosen.Dir, chosen.Prompt, extraArgs)
+	}
+	if chosen.Session.ID == "" {
+		return errCancelled
+	}
+	if len(chosen.Sessions) > 0 {
+		return printSessions(e, chosen.Sessions)
+	}
+	return finish(e, chosen.Session, chosen.Dir, append(extraArgs, chosen.Args...))
+}
+
+// printSessions prints the sessions chosen together in the UI: one ID per line, or a JSON array
+// of session records with --output json.
+func printSessions(e *env, list []sessions.Session) error {
+	if *flagOutput == "json" {
+		records := make([]sessionRecord, len(list))
+		for i, sess := range list {
+			records[i] = newSessionRecord(e, sess)
+		}
+		return json.NewEncoder(os.Stdout).Encode(records)
+	}
+	for _, sess := range list {
+		fmt.Println(sess.ID)
+	}
+	return nil
+}
+
+// projectSessions returns the sessions listed in the project file, if there is one.
+func projectSessions(proj *project.File) []string {
+	if proj == nil {
+		return nil
+	}
+	return proj.Sessions
+}
+
+// saveSearch adds a named query to the saved searches in the configuration file.
+func saveSearch(e *env, name, query string) error {
+	path := *flagConfig
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return err
+		}
+	}
+	cfg, _ := e.config()
+	searches := maps.Clone(cfg.SavedSearches)
+	if searches == nil {
+		searches = make(map[string]string)
+	}
+	searches[name] = query
+	if err := config.Set(path, "saved_searches", searches); err != nil {
+		return err
+	}
+	e.cfg.SavedSearches = searches
+	return nil
+}
+
+// ranking applies the configured weights on top of the UI's defaults.
+func ranking(spec config.RankingSpec) ui.Ranking {
+	r := ui.DefaultRanking()
+	if spec.Relevance != nil {
+		r.Relevance = *spec.Relevance
+	}
+	if spec.Recency != nil {
+		r.Recency = *spec.Recency
+	}
+	if d, err := time.ParseDuration(spec.HalfLife); err == nil && d > 0 {
+		r.HalfLife = d
+	}
+	if spec.Pinned != nil {
+		r.Pinned = *spec.Pinned
+	}
+	return r
+}
+
+func runResume(e *env, args []string) error {
+	fs := newFlagSet("resume")
+	last := fs.Bool("last", false, "Resume the most recently updated session.")
+	lastForCwd := fs.Bool("last-for-cwd", false, "Resume the most recently updated session started in the current directory.")
+	projectDefault := fs.Bool("project-default", false, "Resume the first session listed in the .codex-sessions project file.")
+	again := fs.Bool("again", false, "Resume the session selected or resumed last time.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list := loadSessions(e)
+	if *again {
+		meta, err := sidecar.Open(e.root)
+		if err != nil {
+			return err
+		}
+		id := meta.LastSelected()
+		if id == "" {
+			return fmt.Errorf("%w: none was selected before", errNoSessions)
+		}
+		sess, err := resolveSession(list, id)
+		if err != nil {
+			return err
+		}
+		return finish(e, sess, "", fs.Args())
+	}
+	if *projectDefault {
+		proj, err := project.Find(".")
+		if err != nil {
+			return err
+		}
+		if proj == nil {
+			return fmt.Errorf("no %s file in the current directory or its parents", project.FileName)
+		}
+		if proj.Default() == "" {
+			return fmt.Errorf("%s lists no sessions", proj.Path)
+		}
+		sess, err := resolveSession(list, proj.Default())
+		if err != nil {
+			return fmt.Errorf("%s: %w", proj.Path, err)
+		}
+		return finish(e, sess, "", fs.Args())
+	}
+	if *last || *lastForCwd {
+		sess, err := mostRecent(list, *lastForCwd)
+		if err != nil {
+			return err
+		}
+		return finish(e, sess, "", fs.Args())
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	prefix := fs.Arg(0)
+	extraArgs := fs.Args()[1:]
+	matches := sessions.MatchPrefix(list, prefix)
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%w matching %q", errNoSessions, prefix)
+	case 1:
+		return finish(e, matches[0], "", extraArgs)
+	}
+	// Ambiguous: let the user pick among the candidates.
+	return openUI(e, extraArgs, prefix, fmt.Sprintf("%d sessions match %q", len(matches), prefix))
+}
+
+func runList(e *env, args []string) error {
+	fs := newFlagSet("list")
+	format := fs.String("format", "text", "Output format: text, or jsonl to stream one JSON object per session as it is parsed.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	meta, err := sidecar.Open(e.root)
+	if err != nil {
+		warnf("%v", err)
+	}
+	visible := func(sess sessions.Session) bool {
+		return (*flagAll || !meta.Get(sess.ID).Hidden) && e.timeRange.Contains(sess.UpdatedAt)
+	}
+
+	switch *format {
+	case "text":
+	case "jsonl":
+		enc := json.NewEncoder(os.Stdout)
+		err := sessions.StreamRootsContext(context.Background(), e.roots, e.load, func(sess sessions.Session) {
+			sess = meta.WithWorkdir(sess)
+			if visible(sess) {
+				_ = enc.Encode(newSessionRecord(e, sess))
+			}
+		})
+		if err != nil {
+			warnf("%v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q (want text or jsonl)", *format)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, sess := range loadSessions(e) {
+		if !visible(sess) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", formatTime(sess.UpdatedAt), sess.ID, sess.WorkingDir, e.redactor().Redact(sess.LastAction))
+	}
+	return w.Flush()
+}
+
+func runDelete(e *env, args []string) error {
+	fs := newFlagSet("delete")
+	purge := fs.Bool("purge", false, "Remove the files permanently instead of moving them to the trash.")
+	force := fs.Bool("force", false, "Delete sessions even when a running Codex process is still writing to them.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	list := loadSessions(e)
+	var targets []sessions.Session
+	for _, prefix := range fs.Args() {
+		sess, err := resolveSession(list, prefix)
+		if err != nil {
+			return err
+		}
+		if !*force && sessions.IsActive(sess) {
+			return fmt.Errorf("session %s is in use by a running Codex process; use --force to delete it anyway", sess.ID)
+		}
+		if err := sessions.CheckWithinRoot(sess, sess.RootOr(e.root)); err != nil {
+			return fmt.Errorf("session %s: %w", sess.ID, err)
+		}
+		targets = append(targets, sess)
+	}
+
+	var combined error
+	for _, sess := range targets {
+		var err error
+		if *purge {
+			err = sessions.PurgeFiles(sess, sess.RootOr(e.root), e.files)
+		} else {
+			err = sessions.DeleteFiles(sess, sess.RootOr(e.root), e.files)
+		}
+		if err != nil {
+			combined = errors.Join(combined, fmt.Errorf("%s: %w", sess.ID, err))
+			continue
+		}
+		if !*flagDryRun {
+			fmt.Println(sess.ID)
+		}
+	}
+	return combined
+}
+
+func runPrune(e *env, args []string) error {
+	fs := newFlagSet("prune")
+	emptyTrash := fs.Bool("empty-trash", false, "Permanently remove everything in the trash instead.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*emptyTrash {
+		var combined error
+		for _, root := range e.roots {
+			combined = errors.Join(combined, sessions.Maintain(root, e.files))
+		}
+		return combined
+	}
+	n := 0
+	var combined error
+	for _, root := range e.roots {
+		removed, err := sessions.EmptyTrash(root, 0, e.files)
+		n += removed
+		combined = errors.Join(combined, err)
+	}
+	if combined != nil {
+		return combined
+	}
+	if *flagDryRun {
+		fmt.Printf("would remove %d trashed session(s)\n", n)
+	} else {
+		fmt.Printf("removed %d trashed session(s)\n", n)
+	}
+	return nil
+}
+
+func runExport(e *env, args []string) error {
+	fs := newFlagSet("export")
+	output := fs.String("o", "", "Write to this file instead of stdout.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	sess, err := resolveSession(loadSessions(e), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := e.readTranscript(sess)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return sessions.WriteMarkdown(os.Stdout, sess, entries)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := sessions.WriteMarkdown(file, sess, entries); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runScrub rewrites the rollout files of every session, or only of --id, with the matches of
+// --pattern replaced by sessions.Redacted. The original of each changed file is kept in
+// --backup-dir, by default a directory of the user cache only its owner can read.
+func runScrub(e *env, args []string) error {
+	fs := newFlagSet("scrub")
+	pattern := fs.String("pattern", "", "Regular expression matching the secret; a capture group limits masking to the group.")
+	id := fs.String("id", "", "Only scrub the session with this ID prefix.")
+	force := fs.Bool("force", false, "Scrub sessions even when a running Codex process is still writing to them.")
+	backupDir := fs.String("backup-dir", "", "Copy the original of each changed file into this directory, outside the sessions root, before scrubbing it. Defaults to codex-sessions/scrub-backups in the user cache directory.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pattern == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if *backupDir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("no directory for the scrubbed originals, pass --backup-dir: %w", err)
+		}
+		*backupDir = filepath.Join(cache, "codex-sessions", "scrub-backups")
+	}
+	redactor, err := sessions.NewRedactor([]string{*pattern}, false)
+	if err != nil {
+		return err
+	}
+
+	targets := loadSessions(e)
+	if *id != "" {
+		sess, err := resolveSession(targets, *id)
+		if err != nil {
+			return err
+		}
+		targets = []sessions.Session{sess}
+	}
+
+	var combined error
+	changed := 0
+	for _, sess := range targets {
+		if !*force && sessions.IsActive(sess) {
+			combined = errors.Join(combined, fmt.Errorf("session %s is in use by a running Codex process; use --force to scrub it anyway", sess.ID))
+			continue
+		}
+		paths, err := sessions.ScrubFiles(sess, redactor, sess.RootOr(e.root), *backupDir, e.files)
+		if err != nil {
+			combined = errors.Join(combined, fmt.Errorf("%s: %w", sess.ID, err))
+		}
+		for _, path := range paths {
+			if !*flagDryRun {
+				fmt.Printf("%s (original kept as %s)\n", path, sessions.ScrubBackupPath(*backupDir, path))
+			}
+		}
+		changed += len(paths)
+	}
+	if changed == 0 && combined == nil {
+		fmt.Fprintln(os.Stderr, "no matches")
+	}
+	if changed > 0 && !*flagDryRun {
+		fmt.Fprintf(os.Stderr, "the originals in %s still contain the secret; delete them once the sessions look right\n", *backupDir)
+	}
+	return combined
+}
+
+func runBundle(e *env, args []string) error {
+	fs := newFlagSet("bundle")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	prefixes, output := fs.Args()[:fs.NArg()-1], fs.Arg(fs.NArg()-1)
+
+	list := loadSessions(e)
+	var targets []sessions.Session
+	for _, prefix := range prefixes {
+		sess, err := resolveSession(list, prefix)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, sess)
+	}
+	meta, err := sidecar.Open(e.root)
+	if err != nil {
+		warnf("%v", err)
+	}
+
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Write(file, targets, meta); err != nil {
+		file.Close()
+		os.Remove(output)
+		return err
+	}
+	return file.Close()
+}
+
+func runImport(e *env, args []string) error {
+	fs := newFlagSet("import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	meta, err := sidecar.Open(e.root)
+	if err != nil {
+		warnf("%v", err)
+	}
+	meta.SetReadOnly(e.files.ReadOnly)
+
+	result, err := bundle.Import(file, e.root, loadSessions(e), meta, e.files)
+	for _, id := range result.Imported {
+		fmt.Println(id)
+	}
+	skipped := make([]string, 0, len(result.Skipped))
+	for id := range result.Skipped {
+		skipped = append(skipped, id)
+	}
+	sort.Strings(skipped)
+	for _, id := range skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: %s\n", id, result.Skipped[id])
+	}
+	return err
+}
+
+func runBackup(e *env, args []string) error {
+	cfg, _ := e.config()
+	fs := newFlagSet("backup")
+	dest := fs.String("dest", cfg.BackupDir, "Directory to write the snapshot to. Defaults to backup_dir from the configuration.")
+	keep := fs.Int("keep", cfg.BackupKeep, "Number of snapshots to retain; older ones are removed. 0 keeps all.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dest == "" {
+		return errors.New("no destination: pass --dest or set backup_dir in the configuration")
+	}
+
+	backup, removed, err := sessions.CreateBackup(*dest, e.roots, *keep, e.files)
+	if backup.Path != "" && !*flagDryRun {
+		fmt.Println(backup.Path)
+	}
+	if len(removed) > 0 && !*flagDryRun {
+		fmt.Fprintf(os.Stderr, "removed %d old backup(s)\n", len(removed))
+	}
+	return err
+}
+
+func runStats(e *env, args []string) error {
+	fs := newFlagSet("stats")
+	format := fs.String("format", "text", "Output format: text, or csv or json for per-day and per-project aggregates.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "text", "csv", "json":
+	default:
+		return fmt.Errorf("unknown format %q (want text, csv or json)", *format)
+	}
+
+	list, metrics, err := sessions.LoadRootsMetrics(context.Background(), e.roots, e.load)
+	if err != nil {
+		warnf("%v", err)
+	}
+	list = e.timeRange.Filter(withWorkdirs(e, list))
+	cfg, _ := e.config()
+	switch *format {
+	case "csv":
+		return writeReportCSV(os.Stdout, buildReport(list, cfg.Prices))
+	case "json":
+		return writeReportJSON(os.Stdout, buildReport(list, cfg.Prices))
+	}
+	var files int
+	var first, last time.Time
+	var tokens sessions.TokenUsage
+	var cost float64
+	var unpriced int
+	perDir := make(map[string]int)
+	for _, sess := range list {
+		files += len(sess.FilePaths)
+		tokens.InputTokens += sess.Tokens.InputTokens
+		tokens.OutputTokens += sess.Tokens.OutputTokens
+		tokens.TotalTokens += sess.Tokens.TotalTokens
+		if c, ok := cfg.Prices.Cost(sess); ok {
+			cost += c
+		} else if sess.Tokens.TotalTokens > 0 {
+			unpriced++
+		}
+		if !sess.CreatedAt.IsZero() && (first.IsZero() || sess.CreatedAt.Before(first)) {
+			first = sess.CreatedAt
+		}
+		if sess.UpdatedAt.After(last) {
+			last = sess.UpdatedAt
+		}
+		perDir[sess.WorkingDir]++
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Sessions\t%d\n", len(list))
+	fmt.Fprintf(w, "Rollout files\t%d\n", files)
+	fmt.Fprintf(w, "Tokens\t%d (%d input, %d output)\n", tokens.TotalTokens, tokens.InputTokens, tokens.OutputTokens)
+	if len(cfg.Prices) > 0 {
+		fmt.Fprintf(w, "Estimated cost\t$%.2f", cost)
+		if unpriced > 0 {
+			fmt.Fprintf(w, " (%d sessions without a price for their model)", unpriced)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "First session\t%s\n", formatTime(first))
+	fmt.Fprintf(w, "Last activity\t%s\n", formatTime(last))
+	if cfg.BackupDir != "" {
+		fmt.Fprintf(w, "Last backup\t%s\n", lastBackup(cfg.BackupDir))
+	}
+	fmt.Fprintf(w, "Load time\t%s (%s parsing %s)\n", metrics.Wall.Round(time.Millisecond), metrics.Parse.Round(time.Millisecond), formatBytes(metrics.Bytes))
+	fmt.Fprintf(w, "Throughput\t%.0f files/s, %s/s\n", metrics.FilesPerSecond(), formatBytes(int64(metrics.BytesPerSecond())))
+	if len(perDir) > 0 {
+		dirs := make([]string, 0, len(perDir))
+		for dir := range perDir {
+			dirs = append(dirs, dir)
+		}
+		sort.Slice(dirs, func(i, j int) bool {
+			if perDir[dirs[i]] != perDir[dirs[j]] {
+				return perDir[dirs[i]] > perDir[dirs[j]]
+			}
+			return dirs[i] < dirs[j]
+		})
+		if len(dirs) > 10 {
+			dirs = dirs[:10]
+		}
+		fmt.Fprintf(w, "\nTop directories\t\n")
+		for _, dir := range dirs {
+			label := dir
+			if label == "" {
+				label = "(unknown)"
+			}
+			fmt.Fprintf(w, "%s\t%d\n", label, perDir[dir])
+		}
+	}
+	if len(metrics.Slowest) > 0 {
+		fmt.Fprintf(w, "\nSlowest files\t\n")
+		for _, file := range metrics.Slowest {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", file.Path, file.Duration.Round(time.Microsecond), formatBytes(file.Bytes))
+		}
+	}
+	return w.Flush()
+}
+
+// lastBackup describes the newest snapshot in dir and its age.
+func lastBackup(dir string) string {
+	backups, err := sessions.ListBackups(dir)
+	if err != nil {
+		return err.Error()
+	}
+	if len(backups) == 0 {
+		return "never"
+	}
+	age := time.Since(backups[0].Time)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%s (%d min ago)", formatTime(backups[0].Time), int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%s (%d h ago)", formatTime(backups[0].Time), int(age.Hours()))
+	default:
+		return fmt.Sprintf("%s (%d days ago)", formatTime(backups[0].Time), int(age.Hours()/24))
+	}
+}
+
+// formatBytes renders n with a binary unit, e.g. "3.2 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// sessionRecord is the JSON form of a session used by `list --format jsonl`, `watch --json` and
+// `--output json`.
+// Field names are part of the public contract for integrations and must stay stable.
+type sessionRecord struct {
+	ID         string    `json:"id"`
+	WorkingDir string    `json:"cwd"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	LastAction string    `json:"last_action"`
+	Files      []string  `json:"files"`
+	Messages   int       `json:"messages"`
+	Model      string    `json:"model,omitempty"`
+	Root       string    `json:"root"`
+	Host       string    `json:"host,omitempty"`
+	// EndState is how the session ended when that was a failure: "error", "aborted",
+	// "tool-failure" or "rate-limit".
+	EndState string `json:"end_state,omitempty"`
+	// FilesChanged, LinesAdded and LinesRemoved sum the changes sent to apply_patch.
+	FilesChanged int `json:"files_changed,omitempty"`
+	LinesAdded   int `json:"lines_added,omitempty"`
+	LinesRemoved int `json:"lines_removed,omitempty"`
+	// Reasoning, ToolCalls and Errors count the reasoning items, tool calls and failures.
+	Reasoning int `json:"reasoning,omitempty"`
+	ToolCalls int `json:"tool_calls,omitempty"`
+	Errors    int `json:"errors,omitempty"`
+}
+
+// newSessionRecord returns the record of sess with secrets masked in its last action, as the
+// transcripts it comes from are.
+func newSessionRecord(e *env, sess sessions.Session) sessionRecord {
+	return sessionRecord{
+		ID:           sess.ID,
+		WorkingDir:   sess.WorkingDir,
+		CreatedAt:    sess.CreatedAt,
+		UpdatedAt:    sess.UpdatedAt,
+		LastAction:   e.redactor().Redact(sess.LastAction),
+		Files:        sess.FilePaths,
+		Messages:     sess.Messages(),
+		Model:        sess.Model,
+		Root:         sess.Root,
+		Host:         sess.Host,
+		EndState:     string(sess.EndState),
+		FilesChanged: len(sess.Patches.Files),
+		LinesAdded:   sess.Patches.Added,
+		LinesRemoved: sess.Patches.Removed,
+		Reasoning:    sess.Counts.Reasoning,
+		ToolCalls:    sess.Counts.FunctionCalls,
+		Errors:       sess.Counts.Errors,
+	}
+}
+
+// watchEvent is the JSON Lines record emitted by `watch --json`.
+type watchEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	sessionRecord
+}
+
+func runDaemon(e *env, args []string) error {
+	fs := newFlagSet("daemon")
+	interval := fs.Duration("interval", 2*time.Second, "Minimum time between rescans after a change, and the polling interval where file change notifications are unavailable.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	socket, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "listening on %s\n", socket)
+	err = daemon.Serve(ctx, socket, e.roots, e.load, *interval, func(err error) {
+		warnf("%v", err)
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func runWatch(e *env, args []string) error {
+	fs := newFlagSet("watch")
+	asJSON := fs.Bool("json", false, "Emit one JSON object per line for each session change.")
+	interval := fs.Duration("interval", 2*time.Second, "Minimum time between rescans after a change, and the polling interval where file change notifications are unavailable.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	err := sessions.WatchRoots(ctx, e.roots, *interval, e.load, func(ev sessions.Event) {
+		if !*asJSON {
+			fmt.Printf("%s %s %s\n", ev.Kind, ev.Session.ID, e.redactor().Redact(ev.Session.LastAction))
+			return
+		}
+		_ = enc.Encode(watchEvent{
+			Event:         ev.Kind,
+			Time:          time.Now().UTC(),
+			sessionRecord: newSessionRecord(e, ev.Session),
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/internal/index"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// runIndex manages the content index: `index rebuild` indexes every transcript from scratch and
+// `index status` reports how current the index is.
+func runIndex(e *env, args []string) error {
+	fs := newFlagSet("index")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || (fs.Arg(0) != "rebuild" && fs.Arg(0) != "status") {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	path, err := index.PathFor(e.roots)
+	if err != nil {
+		return err
+	}
+	list := loadSessions(e)
+
+	if fs.Arg(0) == "rebuild" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		start := time.Now()
+		ix := index.New(path, e.roots, e.redactor() != nil)
+		n, err := ix.Update(ctx, list, e.readTranscript)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			warnf("%v", err)
+		}
+		if err := ix.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("indexed %d sessions (%d words) in %s: %s\n", n, ix.WordCount(), time.Since(start).Round(time.Millisecond), path)
+		return nil
+	}
+
+	ix, err := index.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No content index for these sessions roots; searches read every transcript.\nRun `codex-sessions index rebuild` to create %s.\n", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Index\t%s\n", path)
+	if info, err := os.Stat(path); err == nil {
+		fmt.Fprintf(w, "Size\t%s\n", formatBytes(info.Size()))
+	}
+	fmt.Fprintf(w, "Updated\t%s\n", formatTime(ix.Updated))
+	fmt.Fprintf(w, "Sessions\t%d indexed, %d of %d stale (indexed again by the next search)\n", len(ix.Sessions), len(ix.Stale(list)), len(list))
+	fmt.Fprintf(w, "Words\t%d\n", ix.WordCount())
+	switch masked := e.redactor() != nil; {
+	case ix.Redacted == masked:
+	case ix.Redacted:
+		fmt.Fprintf(w, "Note\tbuilt with secrets masked, so it is not used with --no-redact\n")
+	default:
+		fmt.Fprintf(w, "Note\tbuilt with --no-redact, so it is only used with --no-redact\n")
+	}
+	return w.Flush()
+}
+
+// contentCandidates narrows list to the sessions whose transcript may match the regular
+// expression pattern, using the content index when `index rebuild` created one for these roots
+// with the same secret masking. Stale sessions of list are indexed first. Without a usable index
+// list is returned as is, and the caller reads every transcript.
+func contentCandidates(e *env, list []sessions.Session, pattern string) ([]sessions.Session, error) {
+	path, err := index.PathFor(e.roots)
+	if err != nil {
+		return list, err
+	}
+	ix, err := index.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return list, err
+	}
+	if ix.Redacted != (e.redactor() != nil) {
+		return list, nil
+	}
+	n, err := ix.Update(context.Background(), list, e.readTranscript)
+	if n > 0 {
+		err = errors.Join(err, ix.Save())
+	}
+	return ix.Candidates(list, pattern), err
+}
+
+// substringPattern returns a regular expression matching query case-insensitively, for
+// narrowing the substring searches of the HTTP API and the MCP server with contentCandidates.
+func substringPattern(query string) string {
+	return "(?i)" + regexp.QuoteMeta(query)
+}
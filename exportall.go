@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/mattn/go-runewidth"
+)
+
+// exportedSession is one transcript written by export-all, listed on the index page.
+type exportedSession struct {
+	sessions.Session
+	File   string
+	Prompt string
+}
+
+// runExportAll writes the transcript of every session started in --cwd or below to its own file
+// in --out, with an index page linking them.
+func runExportAll(e *env, args []string) error {
+	fs := newFlagSet("export-all")
+	cwd := fs.String("cwd", ".", "Export the sessions started in this directory or below it.")
+	out := fs.String("out", "", "Directory to write the transcripts and index to (created when missing).")
+	format := fs.String("format", "md", "Output format: md or html.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var write func(io.Writer, sessions.Session, []sessions.TranscriptEntry) error
+	switch *format {
+	case "md":
+		write = sessions.WriteMarkdown
+	case "html":
+		write = sessions.WriteHTML
+	default:
+		return fmt.Errorf("unknown format %q (want md or html)", *format)
+	}
+	dir, err := filepath.Abs(*cwd)
+	if err != nil {
+		return err
+	}
+
+	var list []sessions.Session
+	for _, sess := range e.timeRange.Filter(loadSessions(e)) {
+		if withinPath(sess.WorkingDir, dir) {
+			list = append(list, sess)
+		}
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("%w for %s", errNoSessions, dir)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	var exported []exportedSession
+	var errs []error
+	for _, sess := range list {
+		entries, err := e.readTranscript(sess)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		name := exportFileName(sess, *format)
+		if err := writeFileWith(filepath.Join(*out, name), func(w io.Writer) error { return write(w, sess, entries) }); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		exported = append(exported, exportedSession{Session: sess, File: name, Prompt: firstPrompt(entries)})
+	}
+	index := filepath.Join(*out, "index."+*format)
+	err = writeFileWith(index, func(w io.Writer) error {
+		if *format == "html" {
+			return writeHTMLIndex(w, dir, exported)
+		}
+		return writeMarkdownIndex(w, dir, exported)
+	})
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		fmt.Printf("%d sessions exported to %s\n", len(exported), index)
+	}
+	return errors.Join(errs...)
+}
+
+// withinPath reports whether path is dir or lies below it.
+func withinPath(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	if samePath(path, dir) {
+		return true
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(strings.TrimSpace(path)))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// exportFileName names the transcript file of sess, e.g. "2025-01-02-<id>.md", so the files of a
+// directory listing sort by start date.
+func exportFileName(sess sessions.Session, format string) string {
+	id := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, sess.ID)
+	if sess.CreatedAt.IsZero() {
+		return id + "." + format
+	}
+	return sess.CreatedAt.Local().Format("2006-01-02") + "-" + id + "." + format
+}
+
+// firstPrompt returns the first user message of a transcript on one line, shortened for the index.
+func firstPrompt(entries []sessions.TranscriptEntry) string {
+	for _, entry := range entries {
+		if entry.Role != "user" {
+			continue
+		}
+		if text := sessions.PromptText(entry.Text); text != "" {
+			return runewidth.Truncate(text, 100, "…")
+		}
+	}
+	return ""
+}
+
+func writeFileWith(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+func writeMarkdownIndex(w io.Writer, dir string, list []exportedSession) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Codex sessions in `%s`\n\n", dir)
+	b.WriteString("| Started | Session | Directory | Messages | First prompt |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, sess := range list {
+		fmt.Fprintf(&b, "| %s | [%s](%s) | `%s` | %d | %s |\n", formatTime(sess.CreatedAt), sess.ID, sess.File,
+			sess.WorkingDir, sess.Messages(), cell.Replace(sess.Prompt))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlIndex = template.Must(template.New("index").Funcs(template.FuncMap{
+	"time": formatTime,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Codex sessions in {{.Dir}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: .25rem .75rem; border-bottom: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>Codex sessions in <code>{{.Dir}}</code></h1>
+<table>
+<tr><th>Started</th><th>Session</th><th>Directory</th><th>Messages</th><th>First prompt</th></tr>
+{{- range .Sessions}}
+<tr><td>{{time .CreatedAt}}</td><td><a href="{{.File}}">{{.ID}}</a></td><td><code>{{.WorkingDir}}</code></td><td>{{.Messages}}</td><td>{{.Prompt}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+func writeHTMLIndex(w io.Writer, dir string, list []exportedSession) error {
+	return htmlIndex.Execute(w, struct {
+		Dir      string
+		Sessions []exportedSession
+	}{dir, list})
+}
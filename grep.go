@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// runGrep prints the transcript lines of every session matching a regular expression as
+// "file:session:line:text", where line is the line of the rollout file holding the entry.
+func runGrep(e *env, args []string) error {
+	fs := newFlagSet("grep")
+	contextLines := fs.Int("context", 0, "Also print this many lines of the entry text before and after each match.")
+	ignoreCase := fs.Bool("i", false, "Match case-insensitively.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *contextLines < 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	pattern := fs.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	list, err := contentCandidates(e, visibleSessions(e), re.String())
+	if err != nil {
+		warnf("content index: %v", err)
+	}
+	matches, err := sessions.Grep(ctx, list, re, *contextLines, e.readTranscript)
+	w := bufio.NewWriter(os.Stdout)
+	printGrep(w, matches, *contextLines)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == nil && len(matches) == 0 {
+		return errNoMatches
+	}
+	return err
+}
+
+// printGrep writes matches like grep: "file:session:line:text" for a matching line and
+// "file-session-line-text" for a context line, with "--" between groups of lines that are not
+// adjacent. Overlapping context of matches in the same entry is printed once.
+func printGrep(w *bufio.Writer, matches []sessions.GrepMatch, contextLines int) {
+	printed := false
+	for start := 0; start < len(matches); {
+		end := start + 1
+		for end < len(matches) && sameEntry(matches[start], matches[end]) {
+			end++
+		}
+		group := matches[start:end]
+		start = end
+
+		lines := strings.Split(group[0].Entry.Text, "\n")
+		matched := make(map[int]bool, len(group))
+		for _, match := range group {
+			matched[match.LineNumber-1] = true
+		}
+		last := -1
+		for _, match := range group {
+			lo, hi := max(0, match.LineNumber-1-contextLines, last+1), min(len(lines)-1, match.LineNumber-1+contextLines)
+			if lo > hi {
+				continue
+			}
+			if contextLines > 0 && printed && (last < 0 || lo > last+1) {
+				fmt.Fprintln(w, "--")
+			}
+			for k := lo; k <= hi; k++ {
+				sep := '-'
+				if matched[k] {
+					sep = ':'
+				}
+				fmt.Fprintf(w, "%s%c%s%c%d%c%s\n", match.Entry.Path, sep, match.Session.ID, sep, match.Entry.Line, sep, lines[k])
+			}
+			last, printed = hi, true
+		}
+	}
+}
+
+func sameEntry(a, b sessions.GrepMatch) bool {
+	return a.Session.ID == b.Session.ID && a.Index == b.Index
+}
@@ -0,0 +1,316 @@
+// Package bundle packages sessions (rollout files plus sidecar annotations) into a portable
+// zstd-compressed tar archive and imports such archives into a sessions root. Bundles compressed
+// with gzip, as written by earlier versions, are imported as well.
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	manifestName = "manifest.json"
+	filesPrefix  = "sessions/"
+	version      = 1
+)
+
+// Manifest is the first member of every bundle and describes its sessions.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Sessions  []Entry   `json:"sessions"`
+}
+
+// Entry lists the archive members of one session. Files are slash-separated paths relative to
+// the sessions root, stored below "sessions/" in the archive.
+type Entry struct {
+	ID    string       `json:"id"`
+	Files []string     `json:"files"`
+	Meta  sidecar.Meta `json:"meta"`
+}
+
+// Write archives the given sessions and their annotations from meta (which may be nil) to w.
+func Write(w io.Writer, list []sessions.Session, meta *sidecar.Store) error {
+	manifest := Manifest{Version: version, CreatedAt: time.Now().UTC()}
+	type member struct{ src, name string }
+	var members []member
+	for _, sess := range list {
+		entry := Entry{ID: sess.ID}
+		if meta != nil {
+			entry.Meta = meta.Get(sess.ID)
+		}
+		for _, file := range sess.FilePaths {
+			rel := relativePath(sess.Root, file)
+			entry.Files = append(entry.Files, rel)
+			members = append(members, member{src: file, name: filesPrefix + rel})
+		}
+		manifest.Sessions = append(manifest.Sessions, entry)
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	defer zw.Close()
+	tw := tar.NewWriter(zw)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := writeMember(tw, manifestName, data); err != nil {
+		return err
+	}
+	for _, m := range members {
+		data, err := os.ReadFile(m.src)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", m.src, err)
+		}
+		if err := writeMember(tw, m.name, data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// zstdMagic starts every zstd frame; gzip streams start with 0x1f 0x8b instead.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// decompress returns the tar stream of the bundle read from r, compressed with zstd or gzip.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	if bytes.Equal(magic, zstdMagic) {
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return gzip.NewReader(br)
+}
+
+func writeMember(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// relativePath returns file relative to root using forward slashes, falling back to the base
+// name for files outside root.
+func relativePath(root, file string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(file)
+}
+
+// Result reports the outcome of Import per session ID.
+type Result struct {
+	Imported []string
+	// Skipped maps session IDs that were not imported to the reason.
+	Skipped map[string]string
+}
+
+// Import extracts a bundle into sessionsRoot. Sessions whose ID already exists in existing, or
+// whose files would overwrite an existing file, are skipped. Every extracted rollout file must
+// belong to the session the manifest claims; otherwise the session is rolled back. With
+// opts.DryRun the bundle is only validated.
+func Import(r io.Reader, sessionsRoot string, existing []sessions.Session, meta *sidecar.Store, opts sessions.FileOptions) (Result, error) {
+	result := Result{Skipped: make(map[string]string)}
+	if opts.ReadOnly {
+		return result, sessions.ErrReadOnly
+	}
+
+	archive, err := decompress(r)
+	if err != nil {
+		return result, fmt.Errorf("open bundle: %w", err)
+	}
+	defer archive.Close()
+	tr := tar.NewReader(archive)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return result, errors.New("not a session bundle: missing manifest")
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return result, fmt.Errorf("decode manifest: %w", err)
+	}
+	if manifest.Version != version {
+		return result, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, sess := range existing {
+		known[sess.ID] = true
+	}
+	owner := make(map[string]string)
+	accepted := make(map[string]Entry)
+	for _, entry := range manifest.Sessions {
+		if reason := checkEntry(entry, sessionsRoot, known); reason != "" {
+			result.Skipped[entry.ID] = reason
+			continue
+		}
+		accepted[entry.ID] = entry
+		known[entry.ID] = true
+		for _, file := range entry.Files {
+			owner[filesPrefix+file] = entry.ID
+		}
+	}
+
+	written := make(map[string][]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("read bundle: %w", err)
+		}
+		id, ok := owner[hdr.Name]
+		if !ok || result.Skipped[id] != "" {
+			continue
+		}
+		dest := filepath.Join(sessionsRoot, filepath.FromSlash(strings.TrimPrefix(hdr.Name, filesPrefix)))
+		if opts.DryRun {
+			written[id] = append(written[id], "")
+			continue
+		}
+		// Only files this import created may be removed again; a failed create can be a file
+		// that appeared in the meantime.
+		created, err := extract(tr, dest, id)
+		if created {
+			written[id] = append(written[id], dest)
+		}
+		if err != nil {
+			result.Skipped[id] = err.Error()
+		}
+	}
+
+	var combined error
+	for _, entry := range manifest.Sessions {
+		id := entry.ID
+		if _, ok := accepted[id]; !ok {
+			continue
+		}
+		if result.Skipped[id] == "" && len(written[id]) != len(entry.Files) {
+			result.Skipped[id] = "bundle is missing rollout files"
+		}
+		if result.Skipped[id] != "" {
+			for _, file := range written[id] {
+				if file != "" {
+					_ = os.Remove(file)
+				}
+			}
+			continue
+		}
+		if meta != nil && !entry.Meta.Empty() && !opts.DryRun {
+			if err := meta.Set(id, entry.Meta); err != nil {
+				combined = errors.Join(combined, fmt.Errorf("%s: %w", id, err))
+			}
+		}
+		result.Imported = append(result.Imported, id)
+	}
+	return result, combined
+}
+
+// checkEntry validates a manifest entry and returns why it cannot be imported, if so.
+func checkEntry(entry Entry, root string, known map[string]bool) string {
+	if !validID(entry.ID) {
+		return "invalid session ID"
+	}
+	if known[entry.ID] {
+		return "session already exists"
+	}
+	if len(entry.Files) == 0 {
+		return "no rollout files"
+	}
+	for _, file := range entry.Files {
+		if !safeName(file) {
+			return fmt.Sprintf("unsafe file name %q", file)
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(file))); err == nil {
+			return fmt.Sprintf("%s already exists", file)
+		}
+	}
+	return ""
+}
+
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// safeName reports whether the slash-separated name of a bundle member stays below the
+// directory it is extracted to on every system. Backslashes and colons are refused outright, as
+// Windows reads them as separators and drive or stream prefixes.
+func safeName(name string) bool {
+	clean := path.Clean(name)
+	if clean != name || path.IsAbs(clean) || strings.HasPrefix(clean, "../") || clean == ".." || path.Ext(clean) != ".jsonl" {
+		return false
+	}
+	if strings.ContainsAny(name, `\:`) {
+		return false
+	}
+	return filepath.IsLocal(filepath.FromSlash(clean))
+}
+
+// extract writes one rollout file without overwriting anything and checks that it belongs to id.
+// created reports whether the file was created at dest, also when an error followed.
+func extract(r io.Reader, dest, id string) (created bool, err error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return false, err
+	}
+	file, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return true, err
+	}
+	if err := file.Close(); err != nil {
+		return true, err
+	}
+	sess, err := sessions.Parse(dest, sessions.Options{})
+	if err != nil {
+		return true, err
+	}
+	if sess.ID != id {
+		return true, fmt.Errorf("%s belongs to session %s", filepath.Base(dest), sess.ID)
+	}
+	return true, nil
+}
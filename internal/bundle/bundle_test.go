@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+func TestCheckEntry(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "2025", "01", "02"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "2025", "01", "02", "taken.jsonl"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	known := map[string]bool{"existing": true}
+
+	tests := []struct {
+		name  string
+		entry Entry
+		// want is a substring of the reason, or "" when the entry is accepted.
+		want string
+	}{
+		{"dated file", Entry{ID: "abc-1", Files: []string{"2025/01/02/rollout-abc.jsonl"}}, ""},
+		{"file at the root", Entry{ID: "abc-1", Files: []string{"rollout-abc.jsonl"}}, ""},
+		{"invalid id", Entry{ID: "../abc", Files: []string{"rollout.jsonl"}}, "invalid session ID"},
+		{"empty id", Entry{ID: "", Files: []string{"rollout.jsonl"}}, "invalid session ID"},
+		{"known id", Entry{ID: "existing", Files: []string{"rollout.jsonl"}}, "already exists"},
+		{"no files", Entry{ID: "abc-1"}, "no rollout files"},
+		{"existing file", Entry{ID: "abc-1", Files: []string{"2025/01/02/taken.jsonl"}}, "already exists"},
+		{"parent", Entry{ID: "abc-1", Files: []string{"../rollout.jsonl"}}, "unsafe"},
+		{"parent after clean", Entry{ID: "abc-1", Files: []string{"2025/../../rollout.jsonl"}}, "unsafe"},
+		{"not clean", Entry{ID: "abc-1", Files: []string{"2025//rollout.jsonl"}}, "unsafe"},
+		{"dot segment", Entry{ID: "abc-1", Files: []string{"./rollout.jsonl"}}, "unsafe"},
+		{"absolute", Entry{ID: "abc-1", Files: []string{"/etc/rollout.jsonl"}}, "unsafe"},
+		{"not a rollout", Entry{ID: "abc-1", Files: []string{"2025/01/02/notes.txt"}}, "unsafe"},
+		{"windows separator", Entry{ID: "abc-1", Files: []string{`..\rollout.jsonl`}}, "unsafe"},
+		{"windows drive", Entry{ID: "abc-1", Files: []string{"C:/rollout.jsonl"}}, "unsafe"},
+		{"windows drive relative", Entry{ID: "abc-1", Files: []string{"C:rollout.jsonl"}}, "unsafe"},
+		{"windows unc", Entry{ID: "abc-1", Files: []string{`\\host\share\rollout.jsonl`}}, "unsafe"},
+		{"windows stream", Entry{ID: "abc-1", Files: []string{"rollout.jsonl:hidden.jsonl"}}, "unsafe"},
+		{"one bad file among good", Entry{ID: "abc-1", Files: []string{"a.jsonl", "../b.jsonl"}}, "unsafe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkEntry(tt.entry, root, known)
+			if tt.want == "" && got != "" || tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("checkEntry(%+v) = %q, want %q", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteImportRoundTrip(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	rel := filepath.Join("2025", "01", "02", "rollout-abc.jsonl")
+	path := filepath.Join(src, rel)
+	rollout := `{"timestamp":"2025-01-02T10:00:00Z","type":"session_meta","payload":{"id":"abc-1","cwd":"/tmp"}}` + "\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(rollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	sess := sessions.Session{ID: "abc-1", Root: src, FilePaths: []string{path}}
+	if err := Write(&buf, []sessions.Session{sess}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), zstdMagic) {
+		t.Fatalf("bundle does not start with the zstd magic: % x", buf.Bytes()[:4])
+	}
+	result, err := Import(&buf, dest, nil, nil, sessions.FileOptions{})
+	if err != nil || len(result.Imported) != 1 || len(result.Skipped) != 0 {
+		t.Fatalf("Import = %+v, %v; want abc-1 imported", result, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, rel)); err != nil || string(data) != rollout {
+		t.Errorf("imported rollout = %q, %v; want %q", data, err, rollout)
+	}
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const fileName = "config.json"
+
+// Keymap names accepted by the "keymap" setting.
+const (
+	KeymapDefault = "default"
+	KeymapVim     = "vim"
+)
+
+// DefaultDimAfterDays is the age in days after which list rows are dimmed unless the
+// "dim_after_days" setting says otherwise.
+const DefaultDimAfterDays = 30
+
+// Config holds user preferences loaded from the codex-sessions configuration file.
+type Config struct {
+	// SessionsDirs lists the sessions roots to scan when --sessions-dir is not given.
+	SessionsDirs []string `json:"sessions_dirs,omitempty"`
+	// Exclude lists globs of files and directories below the sessions roots to skip, like
+	// --exclude.
+	Exclude []string `json:"exclude,omitempty"`
+	// Hosts names the machine whose sessions a root holds, keyed by root as given in
+	// sessions_dirs or --sessions-dir, e.g. {"/mnt/sync/laptop/sessions": "laptop"}.
+	Hosts map[string]string `json:"hosts,omitempty"`
+	// Keymap selects the navigation bindings: "default" or "vim".
+	Keymap string `json:"keymap,omitempty"`
+	// Keys rebinds UI actions on top of the keymap, e.g. {"pin": ["*"], "quit": ["Ctrl+Q"]}.
+	Keys map[string][]string `json:"keys,omitempty"`
+	// LogFile receives structured diagnostics when --log-file is not given.
+	LogFile string `json:"log_file,omitempty"`
+	// MaintainAfterResume starts a background maintenance pass once `codex resume` exits.
+	MaintainAfterResume bool `json:"maintain_after_resume,omitempty"`
+	// ResumeCmd is a command template run instead of `codex resume`, like --resume-cmd.
+	ResumeCmd string `json:"resume_cmd,omitempty"`
+	// RestoreCwd resumes sessions in their working directory with their recorded sandbox mode
+	// and approval policy, like --restore-cwd.
+	RestoreCwd bool `json:"restore_cwd,omitempty"`
+	// Templates maps names to prompts offered when starting a new session with `n`.
+	Templates map[string]string `json:"templates,omitempty"`
+	// SavedSearches maps names to search queries recalled with F4.
+	SavedSearches map[string]string `json:"saved_searches,omitempty"`
+	// DisableMouse turns off mouse handling, e.g. to keep the terminal's native text selection.
+	DisableMouse bool `json:"disable_mouse,omitempty"`
+	// Theme names a built-in color theme: "dark", "light" or "solarized".
+	Theme string `json:"theme,omitempty"`
+	// Colors overrides individual theme colors by slot name, e.g. {"header": "#b58900"}.
+	Colors map[string]string `json:"colors,omitempty"`
+	// Columns enables optional built-in columns, e.g. ["messages", "duration"].
+	Columns []string `json:"columns,omitempty"`
+	// Sort is the order the list starts in, e.g. "frecency"; the default is "updated".
+	Sort string `json:"sort,omitempty"`
+	// EnterMenu makes Enter open a menu of quick actions (resume, resume read-only, export, ...)
+	// instead of resuming the session right away.
+	EnterMenu bool `json:"enter_menu,omitempty"`
+	// DimAfterDays dims the list rows of sessions not updated for that many days; 0 turns
+	// dimming off. Unset means DefaultDimAfterDays.
+	DimAfterDays *int `json:"dim_after_days,omitempty"`
+	// SnippetLength is the number of display columns snippets such as the last action are cut
+	// to while sessions are parsed; 0 means sessions.DefaultSnippetLimit.
+	SnippetLength int `json:"snippet_length,omitempty"`
+	// LastAction chooses what the Last Action column describes: "entry" (the default) for the
+	// latest entry of a session, or "prompt" for its latest user prompt.
+	LastAction sessions.LastActionMode `json:"last_action,omitempty"`
+	// IgnoreEntries lists entry types, e.g. "token_count", that neither change the last action
+	// nor the update time of a session.
+	IgnoreEntries []string `json:"ignore_entries,omitempty"`
+	// Limit is the number of most recently modified rollout files the UI reads at startup, like
+	// --limit; 0 reads them all.
+	Limit int `json:"limit,omitempty"`
+	// LastActionWidth caps the width of the Last Action column; 0 lets it take the free space.
+	LastActionWidth int `json:"last_action_width,omitempty"`
+	// TwoLineRows shows the last user and assistant messages on two stacked lines per session
+	// instead of the last action.
+	TwoLineRows bool `json:"two_line_rows,omitempty"`
+	// Prices maps model names (or prefixes) to US dollars per million tokens, for cost estimates.
+	Prices sessions.PriceTable `json:"prices,omitempty"`
+	// BackupDir is the default destination of the backup command and is shown by stats.
+	BackupDir string `json:"backup_dir,omitempty"`
+	// BackupKeep is the default number of backups the backup command retains.
+	BackupKeep int `json:"backup_keep,omitempty"`
+	// Redaction masks secrets in previews and exported transcripts.
+	Redaction RedactionSpec `json:"redaction"`
+	// Ranking tunes the order of search results.
+	Ranking RankingSpec `json:"ranking"`
+	// MetadataProviders lists enrichers whose fields are shown as extra columns.
+	MetadataProviders []ProviderSpec `json:"metadata_providers,omitempty"`
+}
+
+// ProviderSpec configures a metadata provider. Name selects a built-in provider ("git",
+// "tokens") unless Command is set, in which case the command is run per session.
+type ProviderSpec struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command,omitempty"`
+	Fields  []string `json:"fields,omitempty"`
+}
+
+// RedactionSpec configures secret masking. Unset fields keep their defaults: enabled, with the
+// built-in patterns (sessions.DefaultSecretPatterns) followed by Patterns.
+type RedactionSpec struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// Defaults turns the built-in patterns off when false.
+	Defaults *bool `json:"defaults,omitempty"`
+	// Patterns are extra regular expressions; a capture group limits masking to the group.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Redactor builds the redactor described by the spec, or nil when redaction is disabled.
+func (s RedactionSpec) Redactor() (*sessions.Redactor, error) {
+	if s.Enabled != nil && !*s.Enabled {
+		return nil, nil
+	}
+	return sessions.NewRedactor(s.Patterns, s.Defaults == nil || *s.Defaults)
+}
+
+// RankingSpec weighs fuzzy relevance, recency and pins when ordering search results. Unset
+// fields keep their defaults: relevance 1, recency 0, a half-life of one week and pinned 10.
+type RankingSpec struct {
+	Relevance *float64 `json:"relevance,omitempty"`
+	Recency   *float64 `json:"recency,omitempty"`
+	// HalfLife is a Go duration such as "72h" after which the recency component halves.
+	HalfLife string   `json:"half_life,omitempty"`
+	Pinned   *float64 `json:"pinned,omitempty"`
+}
+
+// Default returns the configuration used when no file is present.
+func Default() Config {
+	return Config{}.withDefaults()
+}
+
+// DefaultPath returns the location of the configuration file inside the user config directory,
+// e.g. "~/.config/codex-sessions/config.json" on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user config dir: %w", err)
+	}
+	return filepath.Join(dir, "codex-sessions", fileName), nil
+}
+
+// Create writes cfg to a new configuration file at path, creating its directory. It fails when
+// the file already exists rather than overwriting the user's settings.
+func Create(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("create config: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
+	return file.Close()
+}
+
+// Set stores value under key in the configuration file at path, keeping every other setting as
+// written. The file is created when missing and replaced atomically.
+func Set(path, key string, value any) error {
+	settings := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("decode config %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("read config: %w", err)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	settings[key] = raw
+	if data, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// Load reads the configuration file at path. A missing file yields the default configuration.
+func Load(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Default(), nil
+		}
+		return Default(), fmt.Errorf("read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("decode config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Default(), fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg.withDefaults(), nil
+}
+
+// DimAfter returns the number of days after which list rows are dimmed, 0 for never.
+func (c Config) DimAfter() int {
+	if c.DimAfterDays == nil {
+		return DefaultDimAfterDays
+	}
+	return *c.DimAfterDays
+}
+
+func (c Config) withDefaults() Config {
+	if c.Keymap == "" {
+		c.Keymap = KeymapDefault
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	switch c.withDefaults().Keymap {
+	case KeymapDefault, KeymapVim:
+	default:
+		return fmt.Errorf("unknown keymap %q", c.Keymap)
+	}
+	if c.DimAfterDays != nil && *c.DimAfterDays < 0 {
+		return fmt.Errorf("invalid dim_after_days %d", *c.DimAfterDays)
+	}
+	if c.SnippetLength < 0 {
+		return fmt.Errorf("invalid snippet_length %d", c.SnippetLength)
+	}
+	switch c.LastAction {
+	case "", sessions.LastActionEntry, sessions.LastActionPrompt:
+	default:
+		return fmt.Errorf("unknown last_action %q", c.LastAction)
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q", pattern)
+		}
+	}
+	if c.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", c.Limit)
+	}
+	if c.LastActionWidth < 0 {
+		return fmt.Errorf("invalid last_action_width %d", c.LastActionWidth)
+	}
+	if c.Ranking.HalfLife != "" {
+		if d, err := time.ParseDuration(c.Ranking.HalfLife); err != nil || d <= 0 {
+			return fmt.Errorf("invalid ranking half_life %q", c.Ranking.HalfLife)
+		}
+	}
+	if _, err := sessions.NewRedactor(c.Redaction.Patterns, false); err != nil {
+		return err
+	}
+	for _, spec := range c.MetadataProviders {
+		if spec.Name == "" {
+			return errors.New("metadata provider without name")
+		}
+	}
+	return nil
+}
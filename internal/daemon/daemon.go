@@ -0,0 +1,191 @@
+// Package daemon keeps a parsed session index warm in a long-running process and hands it to
+// clients over a unix socket, so the UI starts instantly even on very large histories.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// dialTimeout bounds how long a client waits for the daemon before loading sessions itself.
+const dialTimeout = 200 * time.Millisecond
+
+type request struct {
+	Roots   []string         `json:"roots"`
+	Options sessions.Options `json:"options"`
+}
+
+type response struct {
+	Error    string             `json:"error,omitempty"`
+	Sessions []sessions.Session `json:"sessions"`
+}
+
+// SocketPath returns the default socket location inside the user cache directory.
+func SocketPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "codex-sessions", "daemon.sock"), nil
+}
+
+// index is the daemon's view of the sessions roots.
+type index struct {
+	mu   sync.RWMutex
+	byID map[string]sessions.Session
+}
+
+func (ix *index) apply(ev sessions.Event) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ev.Kind == sessions.EventDeleted {
+		delete(ix.byID, ev.Session.ID)
+		return
+	}
+	ix.byID[ev.Session.ID] = ev.Session
+}
+
+// list returns the indexed sessions newest first, like sessions.LoadRoots.
+func (ix *index) list() []sessions.Session {
+	ix.mu.RLock()
+	list := make([]sessions.Session, 0, len(ix.byID))
+	for _, sess := range ix.byID {
+		list = append(list, sess)
+	}
+	ix.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list
+}
+
+// Serve loads roots with opts, keeps the index current with sessions.WatchRoots, rescanning at
+// most every interval, and answers clients on socket until ctx is cancelled. It refuses to start
+// if another daemon is already listening. Rollout files that cannot be read are passed to warn and left out of the index.
+// Every rollout file is indexed, whatever opts.ScanRange and opts.FileLimit say, so clients
+// asking for any time range can be answered.
+func Serve(ctx context.Context, socket string, roots []string, opts sessions.Options, interval time.Duration, warn func(error)) error {
+	if conn, err := net.DialTimeout("unix", socket, dialTimeout); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	// A socket file nobody answers on is left over from a daemon that did not shut down cleanly.
+	_ = os.Remove(socket)
+	if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+		return fmt.Errorf("create socket dir: %w", err)
+	}
+
+	opts.ScanRange, opts.FileLimit = sessions.TimeRange{}, 0
+	list, err := sessions.LoadRootsContext(ctx, roots, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		warn(err)
+	}
+	ix := &index{byID: make(map[string]sessions.Session, len(list))}
+	for _, sess := range list {
+		ix.byID[sess.ID] = sess
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	defer listener.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- sessions.WatchRoots(ctx, roots, interval, opts, ix.apply)
+	}()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			select {
+			case err := <-watchErr:
+				return err
+			default:
+			}
+			return fmt.Errorf("accept: %w", err)
+		}
+		go handle(conn, roots, opts, ix)
+	}
+}
+
+func handle(conn net.Conn, roots []string, opts sessions.Options, ix *index) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	var resp response
+	switch {
+	case !slices.Equal(req.Roots, roots):
+		resp.Error = "daemon serves different sessions roots"
+	case !serves(opts, req.Options):
+		resp.Error = "daemon reads the sessions with different options"
+	default:
+		resp.Sessions = req.Options.ScanRange.Filter(ix.list())
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// serves reports whether an index read with own holds the sessions a load with req would
+// return. The index has every rollout file, so it serves any scan range but no file limit.
+func serves(own, req sessions.Options) bool {
+	own, req = own.Normalized(), req.Normalized()
+	return req.FileLimit == 0 &&
+		slices.Equal(own.Excludes, req.Excludes) &&
+		slices.Equal(own.IgnoredEntries, req.IgnoredEntries) &&
+		own.SnippetLimit == req.SnippetLimit &&
+		own.LastAction == req.LastAction &&
+		maps.Equal(own.RootHosts, req.RootHosts)
+}
+
+// Fetch asks the daemon on socket for the sessions of roots, read with opts. It fails quickly
+// when no daemon is running, the daemon indexes other roots or cannot answer for opts, so
+// callers can fall back to sessions.LoadRootsContext.
+func Fetch(socket string, roots []string, opts sessions.Options) ([]sessions.Session, error) {
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(request{Roots: roots, Options: opts}); err != nil {
+		return nil, err
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("read daemon response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Sessions, nil
+}
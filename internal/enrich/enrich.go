@@ -0,0 +1,208 @@
+// Package enrich computes optional per-session metadata (git info, token counts, output of
+// user-defined commands) outside the core load path and caches the results per session.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const workers = 4
+
+// Provider computes extra metadata fields for a session. Implementations may be slow (spawning
+// processes, re-reading rollouts); the Runner calls them off the UI thread and caches results.
+type Provider interface {
+	// Name identifies the provider in configuration and in the cache.
+	Name() string
+	// Fields lists the field names Enrich may return, in display order.
+	Fields() []string
+	// Enrich returns field values for sess. Missing fields are shown as empty.
+	Enrich(ctx context.Context, sess sessions.Session) (map[string]string, error)
+}
+
+// Runner applies a set of providers to sessions, consulting and updating a cache keyed by
+// session ID and last update time.
+type Runner struct {
+	providers []Provider
+	cachePath string
+
+	mu    sync.Mutex
+	cache map[string]map[string]cacheEntry // provider -> session ID -> entry
+}
+
+type cacheEntry struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Fields    map[string]string `json:"fields"`
+}
+
+// DefaultCachePath returns the cache file location inside the user cache directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "codex-sessions", "metadata-cache.json"), nil
+}
+
+// NewRunner creates a Runner. When cachePath is empty results are not persisted.
+func NewRunner(providers []Provider, cachePath string) *Runner {
+	r := &Runner{
+		providers: providers,
+		cachePath: cachePath,
+		cache:     make(map[string]map[string]cacheEntry),
+	}
+	r.loadCache()
+	return r
+}
+
+// Columns returns the union of all provider fields in configuration order.
+func (r *Runner) Columns() []string {
+	var cols []string
+	seen := make(map[string]bool)
+	for _, p := range r.providers {
+		for _, f := range p.Fields() {
+			if !seen[f] {
+				seen[f] = true
+				cols = append(cols, f)
+			}
+		}
+	}
+	return cols
+}
+
+// Run enriches items concurrently and calls emit with the merged fields of every session. emit
+// may be called from multiple goroutines. Provider errors are collected and returned once all
+// sessions are processed; the cache is saved before returning.
+func (r *Runner) Run(ctx context.Context, items []sessions.Session, emit func(id string, fields map[string]string)) error {
+	if len(r.providers) == 0 {
+		return nil
+	}
+
+	jobs := make(chan sessions.Session)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		combined error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sess := range jobs {
+				fields, err := r.enrich(ctx, sess)
+				if err != nil {
+					errMu.Lock()
+					combined = errors.Join(combined, err)
+					errMu.Unlock()
+				}
+				if len(fields) > 0 {
+					emit(sess.ID, fields)
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, sess := range items {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- sess:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := r.saveCache(); err != nil {
+		combined = errors.Join(combined, err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return combined
+}
+
+func (r *Runner) enrich(ctx context.Context, sess sessions.Session) (map[string]string, error) {
+	merged := make(map[string]string)
+	var combined error
+	for _, p := range r.providers {
+		if fields, ok := r.cached(p.Name(), sess); ok {
+			for k, v := range fields {
+				merged[k] = v
+			}
+			continue
+		}
+		fields, err := p.Enrich(ctx, sess)
+		if err != nil {
+			combined = errors.Join(combined, fmt.Errorf("%s %s: %w", p.Name(), sess.ID, err))
+			continue
+		}
+		r.store(p.Name(), sess, fields)
+		for k, v := range fields {
+			merged[k] = v
+		}
+	}
+	return merged, combined
+}
+
+func (r *Runner) cached(provider string, sess sessions.Session) (map[string]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.cache[provider][sess.ID]
+	if !ok || !entry.UpdatedAt.Equal(sess.UpdatedAt) {
+		return nil, false
+	}
+	return entry.Fields, true
+}
+
+func (r *Runner) store(provider string, sess sessions.Session, fields map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache[provider] == nil {
+		r.cache[provider] = make(map[string]cacheEntry)
+	}
+	r.cache[provider][sess.ID] = cacheEntry{UpdatedAt: sess.UpdatedAt, Fields: fields}
+}
+
+func (r *Runner) loadCache() {
+	if r.cachePath == "" {
+		return
+	}
+	data, err := os.ReadFile(r.cachePath)
+	if err != nil {
+		return
+	}
+	// A corrupt cache is simply rebuilt.
+	_ = json.Unmarshal(data, &r.cache)
+	if r.cache == nil {
+		r.cache = make(map[string]map[string]cacheEntry)
+	}
+}
+
+func (r *Runner) saveCache() error {
+	if r.cachePath == "" {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.Marshal(r.cache)
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode metadata cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cachePath), 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	tmp := r.cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write metadata cache: %w", err)
+	}
+	return os.Rename(tmp, r.cachePath)
+}
@@ -0,0 +1,132 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/internal/config"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const defaultCommandTimeout = 5 * time.Second
+
+// FromConfig builds providers from configuration. Specs with a command become external command
+// providers; otherwise the name selects a built-in provider ("git" or "tokens").
+func FromConfig(specs []config.ProviderSpec) ([]Provider, error) {
+	providers := make([]Provider, 0, len(specs))
+	for _, spec := range specs {
+		if len(spec.Command) > 0 {
+			providers = append(providers, newCommandProvider(spec))
+			continue
+		}
+		switch spec.Name {
+		case "git":
+			providers = append(providers, gitProvider{})
+		case "tokens":
+			providers = append(providers, tokensProvider{})
+		default:
+			return nil, fmt.Errorf("unknown metadata provider %q", spec.Name)
+		}
+	}
+	return providers, nil
+}
+
+// gitProvider reports the current branch of the session's working directory.
+type gitProvider struct{}
+
+func (gitProvider) Name() string     { return "git" }
+func (gitProvider) Fields() []string { return []string{"Branch"} }
+
+func (gitProvider) Enrich(ctx context.Context, sess sessions.Session) (map[string]string, error) {
+	if sess.WorkingDir == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultCommandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", sess.WorkingDir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		// Not a repository (or gone); nothing to report.
+		return map[string]string{"Branch": ""}, nil
+	}
+	return map[string]string{"Branch": strings.TrimSpace(string(out))}, nil
+}
+
+// tokensProvider reports the total token usage recorded in the rollouts.
+type tokensProvider struct{}
+
+func (tokensProvider) Name() string     { return "tokens" }
+func (tokensProvider) Fields() []string { return []string{"Tokens"} }
+
+func (tokensProvider) Enrich(_ context.Context, sess sessions.Session) (map[string]string, error) {
+	usage, err := sessions.ReadTokenUsage(sess)
+	if err != nil {
+		return nil, err
+	}
+	if usage.TotalTokens == 0 {
+		return map[string]string{"Tokens": ""}, nil
+	}
+	return map[string]string{"Tokens": strconv.FormatInt(usage.TotalTokens, 10)}, nil
+}
+
+// commandProvider runs a user-defined command with the session as JSON on stdin. The command
+// prints either a JSON object mapping field names to values, or plain text used as the value of
+// its single field.
+type commandProvider struct {
+	name    string
+	command []string
+	fields  []string
+	timeout time.Duration
+}
+
+func newCommandProvider(spec config.ProviderSpec) *commandProvider {
+	fields := spec.Fields
+	if len(fields) == 0 {
+		fields = []string{spec.Name}
+	}
+	return &commandProvider{
+		name:    spec.Name,
+		command: spec.Command,
+		fields:  fields,
+		timeout: defaultCommandTimeout,
+	}
+}
+
+func (p *commandProvider) Name() string     { return p.name }
+func (p *commandProvider) Fields() []string { return p.fields }
+
+func (p *commandProvider) Enrich(ctx context.Context, sess sessions.Session) (map[string]string, error) {
+	input, err := json.Marshal(map[string]any{
+		"id":         sess.ID,
+		"cwd":        sess.WorkingDir,
+		"created_at": sess.CreatedAt,
+		"updated_at": sess.UpdatedAt,
+		"files":      sess.FilePaths,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	start := time.Now()
+	out, err := cmd.Output()
+	slog.Debug("exec", "argv", cmd.Args, "session", sess.ID, "duration", time.Since(start), "err", err)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(out, &fields); err == nil {
+		return fields, nil
+	}
+	return map[string]string{p.fields[0]: strings.TrimSpace(string(out))}, nil
+}
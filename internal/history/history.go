@@ -0,0 +1,83 @@
+// Package history remembers recent search queries across runs. They are kept in a plain text
+// file, one query per line, oldest first.
+package history
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Max is the number of queries kept.
+const Max = 100
+
+// DefaultPath returns the location of the history file inside the user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "codex-sessions", "history"), nil
+}
+
+// Load reads the history file at path. A missing file yields an empty history.
+func Load(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	defer file.Close()
+	var list []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			list = append(list, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return trim(list), nil
+}
+
+// Add returns list with query appended as the most recent entry. An earlier copy of the same
+// query is dropped and the oldest entries are discarded beyond Max.
+func Add(list []string, query string) []string {
+	query = strings.TrimSpace(strings.ReplaceAll(query, "\n", " "))
+	if query == "" {
+		return list
+	}
+	list = slices.DeleteFunc(slices.Clone(list), func(q string) bool { return q == query })
+	return trim(append(list, query))
+}
+
+// Save replaces the history file at path with list.
+func Save(path string, list []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+	data := strings.Join(list, "\n") + "\n"
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
+
+func trim(list []string) []string {
+	if len(list) > Max {
+		return list[len(list)-Max:]
+	}
+	return list
+}
@@ -0,0 +1,373 @@
+// Package index maintains an optional full-text index of session transcripts in the user cache
+// directory. It records the words of every transcript so content searches only read the
+// transcripts that can match; the index is a filter, never the source of results. It is updated
+// incrementally: only sessions whose rollout files changed since the last update are read again.
+//
+// The index is a gob file rather than a SQLite or bleve database, so the tool keeps building
+// without cgo or further dependencies. Lookups go through trigrams of the indexed words, built in
+// memory when the index is first searched, so a search only compares the words sharing the
+// rarest trigram of each required fragment.
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// version is bumped whenever the file format or the tokenisation changes; older files are
+// treated as missing.
+const version = 1
+
+const workers = 8
+
+// Index is the content index of one set of sessions roots.
+type Index struct {
+	Version int
+	Roots   []string
+	// Redacted records whether the transcripts were indexed with secrets masked. An index is only
+	// used by searches that mask secrets the same way.
+	Redacted bool
+	Updated  time.Time
+	Sessions map[string]Entry
+
+	path string
+	// words maps every indexed word to the IDs of the sessions containing it; nil until needed.
+	words map[string][]string
+	// grams maps every trigram of the indexed words to the words containing it; built with words.
+	grams map[string][]string
+}
+
+// Entry is the indexed state of one session.
+type Entry struct {
+	Files []FileStamp
+	// Words lists the distinct lower-cased words of the transcript, sorted.
+	Words []string
+}
+
+// FileStamp identifies the version of a rollout file that was indexed by its size and
+// modification time in Unix nanoseconds.
+type FileStamp struct {
+	Path    string
+	Size    int64
+	ModTime int64
+}
+
+// PathFor returns the index file of roots inside the user cache directory, e.g.
+// "~/.cache/codex-sessions/index-<hash>.gob" on Linux.
+func PathFor(roots []string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user cache dir: %w", err)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(roots, "\n")))
+	return filepath.Join(dir, "codex-sessions", "index-"+hex.EncodeToString(sum[:6])+".gob"), nil
+}
+
+// New returns an empty index to be saved at path.
+func New(path string, roots []string, redacted bool) *Index {
+	return &Index{Version: version, Roots: roots, Redacted: redacted, Sessions: map[string]Entry{}, path: path}
+}
+
+// Open reads the index at path. It returns an error wrapping os.ErrNotExist when there is no
+// index, or one written by an incompatible version.
+func Open(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	ix := &Index{}
+	if err := gob.NewDecoder(file).Decode(ix); err != nil {
+		return nil, fmt.Errorf("read index %s: %w", path, err)
+	}
+	if ix.Version != version {
+		return nil, fmt.Errorf("index %s has format %d: %w", path, ix.Version, os.ErrNotExist)
+	}
+	if ix.Sessions == nil {
+		ix.Sessions = map[string]Entry{}
+	}
+	ix.path = path
+	return ix, nil
+}
+
+// Path returns the file the index is saved to.
+func (ix *Index) Path() string {
+	return ix.path
+}
+
+// Save writes the index to its file atomically, creating the cache directory.
+func (ix *Index) Save() error {
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	// A temporary file of its own keeps concurrent searches from writing into each other's.
+	file, err := os.CreateTemp(filepath.Dir(ix.path), filepath.Base(ix.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	tmp := file.Name()
+	if err := gob.NewEncoder(file).Encode(ix); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// Stale returns the sessions of list whose rollout files changed since they were indexed, or
+// that were never indexed.
+func (ix *Index) Stale(list []sessions.Session) []sessions.Session {
+	var stale []sessions.Session
+	for _, sess := range list {
+		entry, ok := ix.Sessions[sess.ID]
+		if !ok || !slices.Equal(entry.Files, stamps(sess)) {
+			stale = append(stale, sess)
+		}
+	}
+	return stale
+}
+
+// Update indexes the stale sessions of list, reading several transcripts in parallel, and
+// returns the number of sessions (re)indexed. Sessions whose transcript cannot be read are left
+// out of the index and reported in the error. Indexed sessions missing from list are kept.
+func (ix *Index) Update(ctx context.Context, list []sessions.Session, read func(sessions.Session) ([]sessions.TranscriptEntry, error)) (int, error) {
+	stale := ix.Stale(list)
+	jobs := make(chan sessions.Session)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		combined error
+		indexed  int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sess := range jobs {
+				// Stamp the files before reading them so a write in between leaves the session stale.
+				files := stamps(sess)
+				entries, err := read(sess)
+				mu.Lock()
+				if err != nil {
+					combined = errors.Join(combined, err)
+					delete(ix.Sessions, sess.ID)
+				} else {
+					ix.Sessions[sess.ID] = Entry{Files: files, Words: transcriptWords(entries)}
+					indexed++
+				}
+				ix.words, ix.grams = nil, nil
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, sess := range stale {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- sess:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	ix.Updated = time.Now()
+	if ctx.Err() != nil {
+		return indexed, ctx.Err()
+	}
+	return indexed, combined
+}
+
+// WordCount returns the number of distinct words in the index.
+func (ix *Index) WordCount() int {
+	return len(ix.postings())
+}
+
+// Candidates returns the sessions of list whose transcript may contain a match of the regular
+// expression pattern: the sessions that are not indexed, and the indexed ones containing every
+// literal the pattern requires. Fragments of the literals shorter than three runes are not
+// looked up; when nothing can be derived from the pattern, list is returned.
+func (ix *Index) Candidates(list []sessions.Session, pattern string) []sessions.Session {
+	fragments := requiredFragments(pattern)
+	if len(fragments) == 0 {
+		return list
+	}
+	var matching map[string]bool
+	for _, fragment := range fragments {
+		words, ok := ix.wordsContaining(fragment)
+		if !ok {
+			continue
+		}
+		found := make(map[string]bool)
+		for _, word := range words {
+			for _, id := range ix.postings()[word] {
+				if matching == nil || matching[id] {
+					found[id] = true
+				}
+			}
+		}
+		matching = found
+	}
+	if matching == nil {
+		return list
+	}
+	var out []sessions.Session
+	for _, sess := range list {
+		if _, indexed := ix.Sessions[sess.ID]; !indexed || matching[sess.ID] {
+			out = append(out, sess)
+		}
+	}
+	return out
+}
+
+// wordsContaining returns the indexed words containing fragment. ok is false for fragments
+// shorter than a trigram, which the index cannot look up; they do not narrow a search.
+func (ix *Index) wordsContaining(fragment string) (words []string, ok bool) {
+	grams := trigrams(fragment)
+	if len(grams) == 0 {
+		return nil, false
+	}
+	ix.postings()
+	// Every word containing fragment contains each of its trigrams, so the words of the rarest
+	// one are the only candidates.
+	var rarest []string
+	for i, gram := range grams {
+		list := ix.grams[gram]
+		if i == 0 || len(list) < len(rarest) {
+			rarest = list
+		}
+	}
+	for _, word := range rarest {
+		if strings.Contains(word, fragment) {
+			words = append(words, word)
+		}
+	}
+	return words, true
+}
+
+func (ix *Index) postings() map[string][]string {
+	if ix.words == nil {
+		ix.words = make(map[string][]string)
+		for id, entry := range ix.Sessions {
+			for _, word := range entry.Words {
+				ix.words[word] = append(ix.words[word], id)
+			}
+		}
+		ix.grams = make(map[string][]string)
+		for word := range ix.words {
+			for _, gram := range trigrams(word) {
+				ix.grams[gram] = append(ix.grams[gram], word)
+			}
+		}
+	}
+	return ix.words
+}
+
+// trigrams returns the distinct runs of three runes in word, none for shorter words.
+func trigrams(word string) []string {
+	runes := []rune(word)
+	var grams []string
+	seen := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		if gram := string(runes[i : i+3]); !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
+
+func stamps(sess sessions.Session) []FileStamp {
+	out := make([]FileStamp, 0, len(sess.FilePaths))
+	for _, path := range sess.FilePaths {
+		stamp := FileStamp{Path: path}
+		if info, err := os.Stat(path); err == nil {
+			stamp.Size, stamp.ModTime = info.Size(), info.ModTime().UnixNano()
+		}
+		out = append(out, stamp)
+	}
+	return out
+}
+
+// isWordRune reports whether r belongs to a word. Text is split into words at every other rune.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func transcriptWords(entries []sessions.TranscriptEntry) []string {
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, word := range strings.FieldsFunc(strings.ToLower(entry.Text), func(r rune) bool { return !isWordRune(r) }) {
+			seen[word] = true
+		}
+	}
+	words := make([]string, 0, len(seen))
+	for word := range seen {
+		words = append(words, word)
+	}
+	slices.Sort(words)
+	return words
+}
+
+// requiredFragments returns lower-cased runs of word runes that every match of pattern contains.
+// Each one lies within a single word of the matched text, so a transcript can only match when
+// every fragment is part of one of its words.
+func requiredFragments(pattern string) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	var fragments []string
+	for _, literal := range requiredLiterals(re.Simplify()) {
+		for _, fragment := range strings.FieldsFunc(strings.ToLower(literal), func(r rune) bool { return !isWordRune(r) }) {
+			if !slices.Contains(fragments, fragment) {
+				fragments = append(fragments, fragment)
+			}
+		}
+	}
+	return fragments
+}
+
+// requiredLiterals returns the literal strings every match of re contains.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpConcat:
+		var out []string
+		for _, sub := range re.Sub {
+			out = append(out, requiredLiterals(sub)...)
+		}
+		return out
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	}
+	return nil
+}
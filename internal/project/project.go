@@ -0,0 +1,77 @@
+// Package project reads the project-local ".codex-sessions" file, which lists the sessions that
+// belong to a repository. It is plain text with a session ID (or unambiguous prefix) at the start
+// of each line; the rest of the line, blank lines and text after '#' are ignored. The first
+// session listed is the project default.
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of the project file, looked up in the current directory and its parents.
+const FileName = ".codex-sessions"
+
+// File is a parsed project file.
+type File struct {
+	Path string
+	// Sessions lists the session IDs or prefixes in file order.
+	Sessions []string
+}
+
+// Default returns the first session listed, or "" when the file lists none.
+func (f *File) Default() string {
+	if f == nil || len(f.Sessions) == 0 {
+		return ""
+	}
+	return f.Sessions[0]
+}
+
+// Find returns the project file in dir or the nearest parent directory that has one, or nil when
+// there is none.
+func Find(dir string) (*File, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return Load(path)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("read project file: %w", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the project file at path.
+func Load(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read project file: %w", err)
+	}
+	defer file.Close()
+	f := &File{Path: path}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if fields := strings.Fields(line); len(fields) > 0 {
+			f.Sessions = append(f.Sessions, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read project file %s: %w", path, err)
+	}
+	return f, nil
+}
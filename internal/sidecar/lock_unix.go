@@ -0,0 +1,23 @@
+//go:build !windows
+
+package sidecar
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive flock on the file at path, creating it, and waits while another
+// process holds it. The returned function releases the lock.
+func lock(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock.
+	return func() { file.Close() }, nil
+}
@@ -0,0 +1,244 @@
+// Package sidecar stores user annotations for sessions (bookmarks, pins, ...) that are not part
+// of the Codex logs. They live in a single JSON file inside the sessions root, keyed by session ID.
+//
+// Several pickers may run at once on the same root. Every update takes an exclusive lock on a
+// file next to the metadata, merges into what is on disk at that moment and replaces the file
+// by an atomic rename, so readers never see a partial file and no instance drops the
+// annotations another one saved meanwhile.
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const (
+	fileName = ".codex-sessions-meta.json"
+	// lockSuffix names the file locked while the metadata is updated. The metadata file itself
+	// cannot be locked, as every update replaces it.
+	lockSuffix = ".lock"
+)
+
+const (
+	// maxResumes is the number of resume times kept per session.
+	maxResumes = 20
+	// frecencyHalfLife is the age at which a resume counts half as much towards frecency.
+	frecencyHalfLife = 7 * 24 * time.Hour
+)
+
+// Meta holds the annotations of a single session.
+type Meta struct {
+	Bookmarks []Bookmark `json:"bookmarks,omitempty"`
+	// Pinned sessions are listed before all others.
+	Pinned bool `json:"pinned,omitempty"`
+	// Hidden sessions are left out of the default view without deleting their files.
+	Hidden bool `json:"hidden,omitempty"`
+	// Note is free-form text the user attached to the session.
+	Note string `json:"note,omitempty"`
+	// Workdir replaces the working directory recorded in the rollout, e.g. after the project
+	// was moved or its repository renamed. The rollout itself is left as it is.
+	Workdir string `json:"workdir,omitempty"`
+	// Selected is when the session was last chosen to resume or print. The UI starts on the
+	// most recently selected session and --again resumes it.
+	Selected time.Time `json:"selected,omitzero"`
+	// Resumes lists when the session was resumed through codex-sessions, oldest first, up to
+	// maxResumes of them.
+	Resumes []time.Time `json:"resumes,omitempty"`
+}
+
+// Bookmark marks a transcript entry. Entries are identified by their timestamp; Index is the
+// position at the time the bookmark was made and is used when the timestamp is ambiguous.
+type Bookmark struct {
+	Timestamp time.Time `json:"timestamp"`
+	Index     int       `json:"index"`
+}
+
+// Empty reports whether m records nothing, in which case the store keeps no entry for it.
+func (m Meta) Empty() bool {
+	return len(m.Bookmarks) == 0 && !m.Pinned && !m.Hidden && m.Note == "" && m.Workdir == "" && m.Selected.IsZero() && len(m.Resumes) == 0
+}
+
+// AddResume records a resume at t, forgetting the oldest ones beyond maxResumes.
+func (m *Meta) AddResume(t time.Time) {
+	m.Resumes = append(m.Resumes, t)
+	if len(m.Resumes) > maxResumes {
+		m.Resumes = m.Resumes[len(m.Resumes)-maxResumes:]
+	}
+}
+
+// Frecency scores how often and how recently the session was resumed: every resume counts 1,
+// halving with each week that has passed since. Sessions never resumed score 0.
+func (m Meta) Frecency(now time.Time) float64 {
+	var score float64
+	for _, t := range m.Resumes {
+		score += math.Exp2(-float64(now.Sub(t)) / float64(frecencyHalfLife))
+	}
+	return score
+}
+
+// Store is the in-memory copy of the sidecar file of one sessions root.
+type Store struct {
+	path     string
+	sessions map[string]Meta
+	readOnly bool
+}
+
+// Path returns the location of the sidecar file for sessionsRoot.
+func Path(sessionsRoot string) string {
+	return filepath.Join(filepath.Clean(sessionsRoot), fileName)
+}
+
+// Open reads the sidecar file of sessionsRoot. A missing file yields an empty store.
+func Open(sessionsRoot string) (*Store, error) {
+	s := &Store{path: Path(sessionsRoot), sessions: make(map[string]Meta)}
+	all, err := read(s.path)
+	if err != nil {
+		return s, err
+	}
+	s.sessions = all
+	return s, nil
+}
+
+// read decodes the sidecar file at path. A missing file yields an empty map.
+func read(path string) (map[string]Meta, error) {
+	all := make(map[string]Meta)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return all, nil
+		}
+		return all, fmt.Errorf("read session metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return make(map[string]Meta), fmt.Errorf("decode session metadata %s: %w", path, err)
+	}
+	if all == nil {
+		all = make(map[string]Meta)
+	}
+	return all, nil
+}
+
+// Get returns the annotations of the session with the given ID.
+func (s *Store) Get(id string) Meta {
+	return s.sessions[id]
+}
+
+// WithWorkdir returns sess with its working directory replaced by the one set in its Workdir,
+// if any. A nil Store returns sess unchanged.
+func (s *Store) WithWorkdir(sess sessions.Session) sessions.Session {
+	if s == nil {
+		return sess
+	}
+	if dir := s.sessions[sess.ID].Workdir; dir != "" {
+		sess.WorkingDir = dir
+	}
+	return sess
+}
+
+// LastSelected returns the ID of the most recently selected session, or "" when none was.
+func (s *Store) LastSelected() string {
+	var id string
+	var latest time.Time
+	for sid, meta := range s.sessions {
+		if meta.Selected.After(latest) {
+			id, latest = sid, meta.Selected
+		}
+	}
+	return id
+}
+
+// SetReadOnly makes Set and Update fail with sessions.ErrReadOnly instead of writing the file.
+func (s *Store) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// Set replaces the annotations of a session and writes the store to disk, like Update. It suits
+// annotations that do not build on earlier ones, such as those of an imported session; changes
+// to what is recorded already belong in Update.
+func (s *Store) Set(id string, meta Meta) error {
+	return s.Update(id, func(m *Meta) { *m = meta })
+}
+
+// Update changes the annotations of a session with change and writes the store to disk. The file
+// is locked and read again first, and change is applied to the annotations it holds then, so
+// what other instances saved since the store was opened, for this session or others, is kept. A
+// file that cannot be decoded is left alone rather than overwritten.
+func (s *Store) Update(id string, change func(meta *Meta)) error {
+	if s.readOnly {
+		return sessions.ErrReadOnly
+	}
+	unlock, err := lock(s.path + lockSuffix)
+	if err != nil {
+		return fmt.Errorf("lock session metadata: %w", err)
+	}
+	defer unlock()
+	all, err := read(s.path)
+	if err != nil {
+		return err
+	}
+	meta := all[id]
+	change(&meta)
+	if meta.Empty() {
+		delete(all, id)
+	} else {
+		all[id] = meta
+	}
+	if err := write(s.path, all); err != nil {
+		return err
+	}
+	s.sessions = all
+	return nil
+}
+
+// write replaces the file at path with all. The data goes to a temporary file of its own that is
+// synced and renamed over path, so the file is either the old or the new version even when the
+// process dies midway.
+func write(path string, all map[string]Meta) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode session metadata: %w", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write session metadata: %w", err)
+	}
+	tmp := file.Name()
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write session metadata: %w", err)
+	}
+	return nil
+}
+
+// ToggleBookmark adds a bookmark for the entry at index with timestamp ts, or removes it when it
+// already exists. It reports whether the entry is bookmarked afterwards.
+func (m *Meta) ToggleBookmark(ts time.Time, index int) bool {
+	for i, b := range m.Bookmarks {
+		if b.Timestamp.Equal(ts) && b.Index == index {
+			m.Bookmarks = append(m.Bookmarks[:i], m.Bookmarks[i+1:]...)
+			return false
+		}
+	}
+	m.Bookmarks = append(m.Bookmarks, Bookmark{Timestamp: ts, Index: index})
+	return true
+}
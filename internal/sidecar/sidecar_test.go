@@ -0,0 +1,101 @@
+package sidecar
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentUpdates(t *testing.T) {
+	root := t.TempDir()
+	const writers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every writer has a store of its own, as separate processes would, opened before
+			// any of the others wrote.
+			store, err := Open(root)
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- store.Update("shared", func(meta *Meta) {
+				meta.Bookmarks = append(meta.Bookmarks, Bookmark{Index: i})
+			})
+			errs <- store.Update(fmt.Sprintf("own-%d", i), func(meta *Meta) {
+				meta.Note = fmt.Sprint(i)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for _, b := range store.Get("shared").Bookmarks {
+		seen[b.Index] = true
+	}
+	for i := range writers {
+		if !seen[i] {
+			t.Errorf("bookmark of writer %d was lost", i)
+		}
+		if note := store.Get(fmt.Sprintf("own-%d", i)).Note; note != fmt.Sprint(i) {
+			t.Errorf("note of writer %d = %q", i, note)
+		}
+	}
+}
+
+func TestUpdateKeepsChangesOfStaleStores(t *testing.T) {
+	root := t.TempDir()
+	stale, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Update("s", func(meta *Meta) { meta.Note = "from another process" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := stale.Update("s", func(meta *Meta) { meta.Pinned = true }); err != nil {
+		t.Fatal(err)
+	}
+	if got := stale.Get("s"); !got.Pinned || got.Note != "from another process" {
+		t.Errorf("after updating a stale store: %+v, want the note kept and the session pinned", got)
+	}
+
+	if err := stale.Update("s", func(meta *Meta) { *meta = Meta{} }); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Get("s"); !got.Empty() {
+		t.Errorf("cleared entry = %+v, want it removed", got)
+	}
+}
+
+func TestUpdateReadOnly(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetReadOnly(true)
+	called := false
+	if err := store.Update("s", func(*Meta) { called = true }); err == nil || called {
+		t.Errorf("Update in read-only mode = %v, change called %v; want an error and no call", err, called)
+	}
+}
@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// Action categories, in the order the help overlay lists them.
+const (
+	categorySession    = "Session"
+	categoryNavigation = "Navigation"
+	categorySearch     = "Search"
+	categoryPreview    = "Preview"
+	categoryGeneral    = "General"
+)
+
+var categoryOrder = []string{categorySession, categoryNavigation, categorySearch, categoryPreview, categoryGeneral}
+
+// action is a user-invocable operation exposed through keybindings and the help overlay. id
+// names bindable actions (see defaultBindings); the keys of the others are fixed.
+type action struct {
+	category string
+	id       string
+	name     string
+	keys     string
+	run      func()
+}
+
+// actions returns every action with the keys bound to it.
+func (m *model) actions() []action {
+	acts := []action{
+		{category: categorySession, id: actResume, name: "Resume session", run: m.openSelected},
+		{category: categorySession, id: actNew, name: "Start new session", run: m.newSession},
+		{category: categorySession, id: actDelete, name: "Delete session (to trash)", run: m.deleteAndRefresh},
+		{category: categorySession, id: actUndo, name: "Undo delete", run: m.undoDelete},
+		{category: categorySession, id: actArchive, name: "Archive session", run: m.archiveSelected},
+		{category: categorySession, id: actExport, name: "Export session as Markdown", run: m.exportSelected},
+		{category: categorySession, id: actExportView, name: "Export the listed sessions as Markdown or CSV", run: m.exportView},
+		{category: categorySession, id: actCopyID, name: "Copy session ID", run: m.copySelectedID},
+		{category: categorySession, id: actOpenDir, name: "Open working directory", run: m.openSelectedDir},
+		{category: categorySession, id: actWorkdir, name: "Change working directory (project moved)", run: m.relocateSelected},
+		{category: categorySession, id: actPin, name: "Pin / unpin session", run: m.togglePin},
+		{category: categorySession, id: actHide, name: "Hide / unhide session", run: m.toggleHidden},
+		{category: categorySession, id: actThread, name: "Show forks and parent (thread)", run: m.showThread},
+		{category: categorySession, id: actFiles, name: "Show rollout files", run: m.showFiles},
+		{category: categorySession, id: actDedupe, name: "Move duplicate rollout files to the trash", run: m.dedupe},
+		{category: categorySession, id: actContinuations, name: "Expand / fold continued rollouts", run: m.toggleContinuations},
+		{category: categorySession, id: actNote, name: "Edit note", run: m.editNote},
+		{category: categorySession, id: actView, name: "View transcript", run: m.viewTranscript},
+		{category: categorySession, id: actDiffs, name: "Show files changed by apply_patch", run: m.showPatches},
+		{category: categorySession, id: actScript, name: "Collect shell commands into a script", run: m.showScript},
+		{category: categorySession, id: actMark, name: "Mark / unmark session (--no-resume)", run: m.toggleMark},
+		{category: categoryNavigation, id: actUp, name: "Move up", run: func() { m.moveSelectionBy(-1) }},
+		{category: categoryNavigation, id: actDown, name: "Move down", run: func() { m.moveSelectionBy(1) }},
+		{category: categoryNavigation, id: actPageUp, name: "Page up", run: func() { m.moveSelectionBy(-m.pageSize) }},
+		{category: categoryNavigation, id: actPageDown, name: "Page down", run: func() { m.moveSelectionBy(m.pageSize) }},
+		{category: categoryNavigation, id: actHalfPageUp, name: "Half page up", run: func() { m.moveSelectionBy(-m.pageSize / 2) }},
+		{category: categoryNavigation, id: actHalfPageDown, name: "Half page down", run: func() { m.moveSelectionBy(m.pageSize / 2) }},
+		{category: categoryNavigation, id: actTop, name: "Jump to top", run: func() { m.moveSelectionBy(-len(m.filtered)) }},
+		{category: categoryNavigation, id: actBottom, name: "Jump to bottom", run: func() { m.moveSelectionBy(len(m.filtered)) }},
+		{category: categoryNavigation, id: actSort, name: "Change sort order", run: m.cycleSort},
+		{category: categorySearch, id: actSearch, name: "Focus search", run: func() { m.setSearching(true) }},
+		{category: categorySearch, id: actClearSearch, name: "Clear search", run: m.clearQuery},
+		{category: categorySearch, id: actClearFilter, name: "Clear a filter", run: m.clearFilter},
+		{category: categorySearch, id: actCase, name: "Switch letter case matching", run: m.cycleCase},
+		{category: categorySearch, id: actTimeRange, name: "Limit by update time", run: m.pickTimeRange},
+		{category: categorySearch, id: actRepo, name: "Limit to current git repository", run: m.toggleRepoFilter},
+		{category: categorySearch, id: actGrep, name: "Grep all transcripts", run: m.grepTranscripts},
+		{category: categorySearch, id: actSaveSearch, name: "Save search", run: m.saveSearch},
+		{category: categorySearch, id: actRecallSearch, name: "Recall saved search", run: m.recallSearch},
+		{category: categoryPreview, id: actTogglePreview, name: "Toggle preview", run: m.togglePreview},
+		{category: categoryPreview, id: actFocusPreview, name: "Focus preview", run: m.focusPreview},
+		{category: categoryPreview, name: "Select preview range", keys: "v, Space", run: m.focusPreview},
+		{category: categoryPreview, name: "Export preview range as Markdown", keys: "y", run: m.exportPreviewRange},
+		{category: categoryPreview, name: "Search transcript", keys: "/", run: m.inPreview(m.searchPreview)},
+		{category: categoryPreview, name: "Next / previous match", keys: "n, N", run: m.inPreview(func() { m.jumpToMatch(1) })},
+		{category: categoryPreview, name: "First / last user message", keys: "[, ]", run: m.inPreview(func() { m.jumpToUserMessage(false) })},
+		{category: categoryPreview, name: "Toggle bookmark", keys: "m", run: m.inPreview(m.toggleBookmark)},
+		{category: categoryPreview, name: "Jump to next bookmark", keys: "'", run: m.inPreview(m.jumpToBookmark)},
+		{category: categoryPreview, name: "Show patch diff", keys: "d", run: m.inPreview(m.showEntryPatch)},
+		{category: categoryGeneral, id: actReload, name: "Reload sessions", run: m.reload},
+		{category: categoryGeneral, id: actLoadAll, name: "Load all sessions", run: m.loadAll},
+		{category: categoryGeneral, id: actHelp, name: "Show help", run: m.showHelp},
+		{category: categoryGeneral, id: actCommands, name: "Command palette", run: m.showCommands},
+		{category: categoryGeneral, id: actSuspend, name: "Suspend to the shell", run: m.suspend},
+		{category: categoryGeneral, id: actQuit, name: "Quit", run: m.quit},
+	}
+	for i, act := range acts {
+		if act.id != "" {
+			acts[i].keys = m.keys.keys(act.id)
+		}
+		if clear := m.keys.keys(actClearSearch); act.id == actQuit && clear != "" {
+			acts[i].keys = strings.TrimSuffix(clear+" (empty search), "+acts[i].keys, ", ")
+		}
+	}
+	return acts
+}
+
+// runAction runs the action bound to a key. The clear-search key quits when the search is
+// already empty.
+func (m *model) runAction(id string) {
+	if id == actClearSearch && m.query == "" {
+		id = actQuit
+	}
+	for _, act := range m.actions() {
+		if act.id == id {
+			act.run()
+			return
+		}
+	}
+}
+
+func (m *model) selectedSession() (sessions.Session, bool) {
+	if len(m.filtered) == 0 {
+		return sessions.Session{}, false
+	}
+	return m.entries[m.filtered[m.selected]].session, true
+}
+
+func (m *model) resumeSelected() {
+	m.resumeWith(nil)
+}
+
+// resumeWith resumes the highlighted session, passing args on to codex resume.
+func (m *model) resumeWith(args []string) {
+	if marked := m.markedSessions(); len(marked) > 0 {
+		m.chosen = Selection{Session: marked[0], Sessions: marked}
+		m.app.Stop()
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	if m.dirMissing(sess) {
+		m.chooseDir(sess, args)
+		return
+	}
+	m.chosen = Selection{Session: sess, Args: args}
+	m.app.Stop()
+}
+
+func (m *model) archiveSelected() {
+	if !m.writable("archive") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok {
+		m.setStatus("Nothing to archive")
+		return
+	}
+	if !m.withinRoot(sess, "archive") || !m.confirmActive(sess, "archive it") {
+		return
+	}
+	root := sess.RootOr(m.sessionsRoot)
+	if err := sessions.ArchiveFiles(sess, root, m.files); err != nil {
+		m.setStatus(fmt.Sprintf("Archive failed: %v", err))
+		return
+	}
+	if m.files.DryRun {
+		m.setStatus(fmt.Sprintf("Dry run: session %s would be archived to %s", sess.ID, sessions.ArchiveDir(root)))
+		return
+	}
+	m.removeEntry(m.filtered[m.selected])
+	m.refresh()
+	m.setStatus(fmt.Sprintf("Session %s archived to %s", sess.ID, sessions.ArchiveDir(root)))
+}
+
+// exportSelected writes the full transcript of the highlighted session to "<id>.md".
+func (m *model) exportSelected() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	entries, err := sessions.ReadTranscript(sess)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	path := sess.ID + ".md"
+	entries = m.redactor.Entries(entries)
+	write := func(w io.Writer) error {
+		return sessions.WriteMarkdown(w, sess, entries)
+	}
+	m.saveFile(path, m.app.GetFocus(), write, func(err error) {
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Export failed: %v", err))
+			return
+		}
+		m.setStatus(fmt.Sprintf("Exported to %s", path))
+	})
+}
+
+// copySelectedID places the session ID on the clipboard through the terminal (OSC 52).
+func (m *model) copySelectedID() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	m.screen.SetClipboard([]byte(sess.ID))
+	m.setStatus(fmt.Sprintf("Copied %s", sess.ID))
+}
+
+func (m *model) openSelectedDir() {
+	sess, ok := m.selectedSession()
+	if !ok || sess.WorkingDir == "" {
+		m.setStatus("No working directory recorded")
+		return
+	}
+	if err := openPath(sess.WorkingDir); err != nil {
+		m.setStatus(fmt.Sprintf("Open failed: %v", err))
+	}
+}
+
+func (m *model) deleteAndRefresh() {
+	m.deleteSelected()
+	m.refresh()
+}
+
+func (m *model) clearQuery() {
+	if m.query == "" {
+		return
+	}
+	m.setQuery("")
+}
+
+func (m *model) quit() {
+	m.chosen = Selection{}
+	m.app.Stop()
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const activeMark = "●"
+
+// activeTTL bounds how long an in-use check is reused, so redraws do not stat files every time.
+const activeTTL = 5 * time.Second
+
+type activeState struct {
+	active  bool
+	checked time.Time
+}
+
+// isActive reports whether sess is being written by a running Codex process.
+func (m *model) isActive(sess sessions.Session) bool {
+	if m.active == nil {
+		m.active = make(map[string]activeState)
+	}
+	state, ok := m.active[sess.ID]
+	if !ok || time.Since(state.checked) > activeTTL {
+		state = activeState{active: sessions.IsActive(sess), checked: time.Now()}
+		m.active[sess.ID] = state
+	}
+	return state.active
+}
+
+// confirmActive guards destructive actions on a session that is still in use: the first attempt
+// only warns, repeating the action on the same session goes ahead.
+func (m *model) confirmActive(sess sessions.Session, what string) bool {
+	if !m.isActive(sess) || m.forceID == sess.ID {
+		m.forceID = ""
+		return true
+	}
+	m.forceID = sess.ID
+	m.setStatus(fmt.Sprintf("Session %s is active in a running Codex process; repeat to %s anyway", sess.ID, what))
+	return false
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// activeFilter is something currently keeping sessions out of the list, as shown in the info
+// line, with the way to lift it.
+type activeFilter struct {
+	label string
+	clear func()
+}
+
+// activeFilters lists the filter terms of the query, the time range, the repository scope,
+// folded continuation rollouts and hidden sessions, in the order the info line shows them. The
+// free text of the query is included when withText is set.
+func (m *model) activeFilters(withText bool) []activeFilter {
+	var active []activeFilter
+	filters, text := parseQuery(m.query)
+	for _, f := range filters {
+		active = append(active, activeFilter{label: f.key + ":" + f.value, clear: func() {
+			m.setQuery(removeTerms(m.query, func(key, value string, isFilter bool) bool {
+				return isFilter && key == f.key && value == f.value
+			}))
+		}})
+	}
+	if withText && text != "" {
+		active = append(active, activeFilter{label: fmt.Sprintf("Search text %q", text), clear: func() {
+			m.setQuery(removeTerms(m.query, func(_, _ string, isFilter bool) bool { return !isFilter }))
+		}})
+	}
+	if !m.timeRange.IsZero() {
+		active = append(active, activeFilter{label: "Updated: " + m.timeRange.String(), clear: func() {
+			m.setTimeRange(sessions.TimeRange{})
+		}})
+	}
+	if m.repoOnly {
+		active = append(active, activeFilter{label: "Repo: " + m.repoRoot, clear: m.toggleRepoFilter})
+	}
+	folded, hidden := 0, 0
+	for _, entry := range m.entries {
+		if m.collapsed(entry.session.ID) {
+			folded++
+		}
+		if !m.showHidden && m.meta != nil && m.meta.Get(entry.session.ID).Hidden {
+			hidden++
+		}
+	}
+	if folded > 0 {
+		active = append(active, activeFilter{label: fmt.Sprintf("Folded rollouts: %d", folded), clear: m.expandAllContinuations})
+	}
+	if hidden > 0 && !wantsHidden(filters) {
+		active = append(active, activeFilter{label: fmt.Sprintf("Hidden sessions: %d", hidden), clear: func() {
+			m.showHidden = true
+			m.invalidateFilter()
+			m.applyFilter()
+			m.refresh()
+			m.setStatus("Showing hidden sessions (marked " + hiddenMark + ")")
+		}})
+	}
+	return active
+}
+
+// filterSummary describes the active filters for the info line, e.g. " | Filters: model:gpt,
+// Updated: last 7 days (c clears)", or "" when none is active.
+func (m *model) filterSummary() string {
+	active := m.activeFilters(false)
+	if len(active) == 0 {
+		return ""
+	}
+	labels := make([]string, len(active))
+	for i, f := range active {
+		labels[i] = f.label
+	}
+	summary := " | Filters: " + strings.Join(labels, ", ")
+	if chords := m.keys.chords[actClearFilter]; len(chords) > 0 {
+		summary += fmt.Sprintf(" (%s clears)", chords[0])
+	}
+	return summary
+}
+
+// clearFilter offers the active filters and lifts the chosen one, or all of them.
+func (m *model) clearFilter() {
+	active := m.activeFilters(true)
+	if len(active) == 0 {
+		m.setStatus("No filters are active")
+		return
+	}
+	items := make([]string, 0, len(active)+1)
+	for _, f := range active {
+		items = append(items, f.label)
+	}
+	if len(active) > 1 {
+		items = append(items, "All of the above")
+	}
+	m.pick(" Clear filter ", items, func(index int) {
+		if index < len(active) {
+			active[index].clear()
+			return
+		}
+		for _, f := range active {
+			f.clear()
+		}
+		m.setStatus("Cleared every filter")
+	})
+}
+
+// removeTerms returns query without the fields drop selects. drop receives the lower-cased key
+// and value of filter terms and whether the field is one.
+func removeTerms(query string, drop func(key, value string, isFilter bool) bool) string {
+	var kept []string
+	for _, field := range strings.Fields(query) {
+		key, value, ok := strings.Cut(field, ":")
+		key = strings.ToLower(key)
+		_, known := filterMatchers[key]
+		isFilter := ok && known && value != ""
+		if !drop(key, strings.ToLower(value), isFilter) {
+			kept = append(kept, field)
+		}
+	}
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+)
+
+const bookmarkMark = "◆"
+
+// resolveBookmarks maps stored bookmarks to entry indexes of the loaded transcript.
+func (p *preview) resolveBookmarks(marks []sidecar.Bookmark) {
+	p.bookmarks = make(map[int]bool, len(marks))
+	for _, b := range marks {
+		if b.Index >= 0 && b.Index < len(p.entries) && p.entries[b.Index].Timestamp.Equal(b.Timestamp) {
+			p.bookmarks[b.Index] = true
+			continue
+		}
+		for i, entry := range p.entries {
+			if entry.Timestamp.Equal(b.Timestamp) {
+				p.bookmarks[i] = true
+				break
+			}
+		}
+	}
+	for i, entry := range p.entries {
+		heading := entry.Heading()
+		if p.bookmarks[i] {
+			heading = bookmarkMark + " " + heading
+		}
+		if cell := p.table.GetCell(i, 1); cell != nil {
+			cell.SetText(heading)
+		}
+	}
+}
+
+// nextBookmark returns the first bookmarked entry after the cursor, wrapping around.
+func (p *preview) nextBookmark() (int, bool) {
+	if len(p.bookmarks) == 0 {
+		return -1, false
+	}
+	indexes := make([]int, 0, len(p.bookmarks))
+	for i := range p.bookmarks {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	cursor, _ := p.table.GetSelection()
+	for _, i := range indexes {
+		if i > cursor {
+			return i, true
+		}
+	}
+	return indexes[0], true
+}
+
+// loadBookmarks applies the stored bookmarks of the previewed session.
+func (m *model) loadBookmarks() {
+	if m.meta == nil {
+		return
+	}
+	m.preview.resolveBookmarks(m.meta.Get(m.preview.sessionID).Bookmarks)
+}
+
+// toggleBookmark bookmarks the highlighted transcript entry, or removes its bookmark.
+func (m *model) toggleBookmark() {
+	if !m.writable("bookmarking") {
+		return
+	}
+	if m.meta == nil || len(m.preview.entries) == 0 {
+		m.setStatus("Nothing to bookmark")
+		return
+	}
+	cursor, _ := m.preview.table.GetSelection()
+	if cursor < 0 || cursor >= len(m.preview.entries) {
+		return
+	}
+	id := m.preview.sessionID
+	var added bool
+	err := m.meta.Update(id, func(meta *sidecar.Meta) {
+		added = meta.ToggleBookmark(m.preview.entries[cursor].Timestamp, cursor)
+	})
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Bookmark failed: %v", err))
+		return
+	}
+	m.loadBookmarks()
+	m.refreshTable()
+	if added {
+		m.setStatus(fmt.Sprintf("Bookmarked entry %d", cursor+1))
+	} else {
+		m.setStatus(fmt.Sprintf("Removed bookmark from entry %d", cursor+1))
+	}
+}
+
+func (m *model) jumpToBookmark() {
+	i, ok := m.preview.nextBookmark()
+	if !ok {
+		m.setStatus("No bookmarks in this session (m to add)")
+		return
+	}
+	m.preview.table.Select(i, 0)
+}
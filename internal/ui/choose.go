@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// inlineScreen draws on the main screen instead of the alternate one, so a picker embedded in a
+// tmux or zellij popup does not flicker, and erases what it drew on exit.
+type inlineScreen struct {
+	tcell.Screen
+}
+
+func newInlineScreen() (tcell.Screen, error) {
+	if os.Getenv("TCELL_ALTSCREEN") == "" {
+		os.Setenv("TCELL_ALTSCREEN", "disable")
+	}
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+	return inlineScreen{screen}, nil
+}
+
+func (s inlineScreen) Fini() {
+	s.Clear()
+	s.Show()
+	s.Screen.Fini()
+}
+
+// fitHeight confines root to the top height rows of the screen; 0 keeps the whole screen.
+func fitHeight(root tview.Primitive, height int) tview.Primitive {
+	if height <= 0 {
+		return root
+	}
+	return tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(root, height, 0, true).
+		AddItem(nil, 0, 1, false)
+}
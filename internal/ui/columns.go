@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/rivo/tview"
+)
+
+// baseColumns is the number of always-visible columns (Updated, Session ID, Directory, Last Action).
+const baseColumns = 4
+
+// column is an optional built-in column enabled through the "columns" config setting.
+type column struct {
+	key   string
+	title string
+	sort  sortMode
+	value func(m *model, s sessions.Session) string
+	// numeric columns are right-aligned.
+	numeric bool
+	// width is the number of cells the column gets, or its title's width when that is wider.
+	width int
+}
+
+var optionalColumns = []column{
+	{key: "messages", title: "Msgs", sort: sortMessages, value: func(_ *model, s sessions.Session) string {
+		return strconv.Itoa(s.Messages())
+	}, numeric: true, width: 5},
+	{key: "duration", title: "Duration", sort: sortDuration, value: func(_ *model, s sessions.Session) string {
+		return formatDuration(s.Duration())
+	}, numeric: true, width: 8},
+	{key: "model", title: "Model", sort: sortModel, value: func(_ *model, s sessions.Session) string {
+		return s.Model
+	}, width: 14},
+	{key: "root", title: "Root", sort: sortRoot, value: func(_ *model, s sessions.Session) string {
+		return abbreviatePath(s.Root, 30)
+	}, width: 30},
+	{key: "host", title: "Host", sort: sortHost, value: func(_ *model, s sessions.Session) string {
+		if s.Host == "" {
+			return "-"
+		}
+		return s.Host
+	}, width: 12},
+	{key: "cost", title: "Cost", sort: sortCost, value: func(m *model, s sessions.Session) string {
+		if cost, ok := m.prices.Cost(s); ok {
+			return formatCost(cost)
+		}
+		return "-"
+	}, numeric: true, width: 8},
+	{key: "entries", title: "Entries", sort: sortMessages, value: func(_ *model, s sessions.Session) string {
+		if counts := s.Counts.String(); counts != "" {
+			return counts
+		}
+		return "-"
+	}, numeric: true, width: 12},
+	{key: "changes", title: "Changes", sort: sortChanges, value: func(_ *model, s sessions.Session) string {
+		if len(s.Patches.Files) == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d +%d -%d", len(s.Patches.Files), s.Patches.Added, s.Patches.Removed)
+	}, numeric: true, width: 14},
+}
+
+// resolveColumns looks up the optional columns named in keys, in the given order.
+func resolveColumns(keys []string) ([]column, error) {
+	var cols []column
+	for _, key := range keys {
+		col, ok := findColumn(key)
+		if !ok {
+			names := make([]string, len(optionalColumns))
+			for i, c := range optionalColumns {
+				names[i] = c.key
+			}
+			return cols, fmt.Errorf("unknown column %q (available: %s)", key, strings.Join(names, ", "))
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+func findColumn(key string) (column, bool) {
+	for _, col := range optionalColumns {
+		if col.key == key {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+func (c column) align() int {
+	if c.numeric {
+		return tview.AlignRight
+	}
+	return tview.AlignLeft
+}
+
+// columnSort returns the sort mode applied when the header of displayed column col is clicked.
+func (m *model) columnSort(col int) (sortMode, bool) {
+	if col < 0 || col >= len(m.visibleCols) {
+		return 0, false
+	}
+	col = m.visibleCols[col]
+	switch col {
+	case 0:
+		return sortUpdated, true
+	case 1:
+		return sortID, true
+	case 2:
+		return sortDirectory, true
+	}
+	if i := col - baseColumns; i >= 0 && i < len(m.columns) {
+		return m.columns[i].sort, true
+	}
+	return 0, false
+}
+
+// formatCost renders an estimated cost in US dollars, e.g. "$0.42".
+func formatCost(cost float64) string {
+	if cost < 0.01 && cost > 0 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// formatDuration renders d compactly, e.g. "45s", "13m", "2h13m" or "3d4h".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
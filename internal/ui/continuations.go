@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const continuationMark = "↻"
+
+// continuationOf returns the lower-cased ID of the latest session of the continuation group id
+// belongs to, or "" when the session was not continued in another rollout.
+func (m *model) continuationOf(id string) string {
+	if m.continued == nil {
+		list := make([]sessions.Session, len(m.entries))
+		for i, entry := range m.entries {
+			list[i] = entry.session
+		}
+		m.continued = make(map[string]string)
+		m.continuedSize = make(map[string]int)
+		for member, latest := range sessions.Continuations(list) {
+			latest = strings.ToLower(latest)
+			m.continued[strings.ToLower(member)] = latest
+			m.continuedSize[latest]++
+		}
+	}
+	return m.continued[strings.ToLower(id)]
+}
+
+// collapsed reports whether the session is an earlier rollout of a continued conversation that
+// is folded into the row of the latest one.
+func (m *model) collapsed(id string) bool {
+	latest := m.continuationOf(id)
+	return latest != "" && latest != strings.ToLower(id) && !m.expanded[latest]
+}
+
+// earlierRollouts returns how many earlier rollouts are folded into the row of id.
+func (m *model) earlierRollouts(id string) int {
+	latest := m.continuationOf(id)
+	if latest != strings.ToLower(id) || m.expanded[latest] {
+		return 0
+	}
+	return m.continuedSize[latest] - 1
+}
+
+// toggleContinuations shows the earlier rollouts of the highlighted conversation as rows of
+// their own, or folds them back into the latest one.
+func (m *model) toggleContinuations() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	latest := m.continuationOf(sess.ID)
+	if latest == "" {
+		m.setStatus(fmt.Sprintf("Session %s has no continuation rollouts", sess.ID))
+		return
+	}
+	if m.expanded == nil {
+		m.expanded = make(map[string]bool)
+	}
+	m.expanded[latest] = !m.expanded[latest]
+	m.invalidateFilter()
+	m.applyFilter()
+	if !m.expanded[latest] {
+		// The highlighted row may just have been folded away.
+		m.selectSession(m.entryID(latest))
+	}
+	m.refresh()
+	if m.expanded[latest] {
+		m.setStatus(fmt.Sprintf("Showing %d rollouts of this conversation (x folds them again)", m.continuedSize[latest]))
+	} else {
+		m.setStatus("Folded the earlier rollouts into the latest one")
+	}
+}
+
+// expandAllContinuations shows the earlier rollouts of every continued conversation.
+func (m *model) expandAllContinuations() {
+	m.continuationOf("")
+	if m.expanded == nil {
+		m.expanded = make(map[string]bool)
+	}
+	for latest := range m.continuedSize {
+		m.expanded[latest] = true
+	}
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+	m.setStatus("Showing the earlier rollouts of every continued conversation")
+}
+
+// entryID returns the ID of the session whose lower-cased ID is id, as recorded.
+func (m *model) entryID(id string) string {
+	for _, entry := range m.entries {
+		if strings.EqualFold(entry.session.ID, id) {
+			return entry.session.ID
+		}
+	}
+	return id
+}
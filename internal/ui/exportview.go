@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// exportView asks for a file name and writes the listed sessions there, with the columns of the
+// table, as CSV when the name ends in .csv and as a Markdown table otherwise.
+func (m *model) exportView() {
+	if len(m.filtered) == 0 {
+		m.setStatus("Nothing to export")
+		return
+	}
+	m.prompt(" Write the listed sessions to (.md or .csv) ", "sessions.md", m.table, func(path string) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			m.setStatus("No file name given; nothing was written")
+			return
+		}
+		format := writeMarkdownTable
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			format = writeCSV
+		}
+		rows := m.viewRows()
+		write := func(w io.Writer) error {
+			return format(w, rows)
+		}
+		m.saveFile(path, m.table, write, func(err error) {
+			if err != nil {
+				m.setStatus(fmt.Sprintf("Export failed: %v", err))
+				return
+			}
+			m.setStatus(fmt.Sprintf("Exported %d sessions to %s", len(rows)-1, path))
+		})
+	})
+}
+
+// viewRows returns the header and the rows of the listed sessions in table order. Every column
+// is included, also those too narrow to show, and values are not shortened to fit.
+func (m *model) viewRows() [][]string {
+	columns := baseColumns + len(m.columns) + len(m.extraCols)
+	header := make([]string, columns)
+	for column := range header {
+		header[column] = m.headerCell(column).Text
+	}
+	rows := [][]string{header}
+	for _, idx := range m.filtered {
+		sess := m.entries[idx].session
+		row := []string{formatTimestamp(sess.UpdatedAt), sess.ID, sess.WorkingDir, sess.LastAction}
+		if m.twoLineRows {
+			row[3] = strings.TrimSpace(messageLine(userMark, sess.LastUserMessage) + " " +
+				messageLine(assistantMark, sess.LastAssistantMessage))
+		}
+		for _, col := range m.columns {
+			row = append(row, col.value(m, sess))
+		}
+		for _, key := range m.extraCols {
+			row = append(row, m.entries[idx].extra[key])
+		}
+		for i, value := range row {
+			row[i] = m.redactor.Redact(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeMarkdownTable writes rows as a Markdown table with the first row as its header.
+func writeMarkdownTable(w io.Writer, rows [][]string) error {
+	var b strings.Builder
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, value := range row {
+			value = strings.Join(strings.Fields(value), " ")
+			cells[j] = strings.ReplaceAll(value, "|", `\|`)
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	filesPage     = "files"
+	corruptMark   = "✗"
+	duplicateMark = "⧉"
+)
+
+// showFiles opens an overlay listing the rollout files of the highlighted session with their
+// size and modification time, including files that were skipped because they could not be parsed
+// and copies of other files. d moves the copies to the trash.
+func (m *model) showFiles() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	var b strings.Builder
+	for _, path := range sess.FilePaths {
+		b.WriteString(describeFile(path, ""))
+	}
+	for _, path := range sess.SkippedFiles {
+		b.WriteString(describeFile(path, corruptMark+" skipped: could not be parsed"))
+	}
+	for _, path := range sess.DuplicateFiles {
+		b.WriteString(describeFile(path, duplicateMark+" duplicate: same content as a file above, left out"))
+	}
+
+	view := tview.NewTextView().SetWrap(false).SetText(strings.TrimRight(b.String(), "\n"))
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyEnter || event.Rune() == 'q' || event.Rune() == 'i' {
+			m.closeOverlay(filesPage)
+			return nil
+		}
+		if event.Rune() == 'd' && len(sess.DuplicateFiles) > 0 {
+			m.closeOverlay(filesPage)
+			m.dedupe()
+			return nil
+		}
+		return event
+	})
+	count := len(sess.FilePaths) + len(sess.SkippedFiles) + len(sess.DuplicateFiles)
+	title := fmt.Sprintf(" %s — %d rollout files ", sess.ID, count)
+	if len(sess.DuplicateFiles) > 0 {
+		title += "(d trashes the duplicates) "
+	}
+	view.SetBorder(true).SetTitle(title)
+	height := 2*count + 2
+	if height > maxOverlayHeight {
+		height = maxOverlayHeight
+	}
+	m.openOverlay(filesPage, centered(view, 100, height), view)
+}
+
+// dedupe moves the rollout files of the highlighted session that duplicate another of its files
+// to the trash.
+func (m *model) dedupe() {
+	if !m.writable("dedupe") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	if len(sess.DuplicateFiles) == 0 {
+		m.setStatus(fmt.Sprintf("%s has no duplicate rollout files", sess.ID))
+		return
+	}
+	if !m.withinRoot(sess, "dedupe") {
+		return
+	}
+	if _, err := sessions.TrashDuplicates(sess, sess.RootOr(m.sessionsRoot), m.files); err != nil {
+		m.setStatus(fmt.Sprintf("Moving the duplicates to the trash failed: %v", err))
+		return
+	}
+	if m.files.DryRun {
+		m.setStatus(fmt.Sprintf("Dry run: %d duplicate files of %s would be moved to trash", len(sess.DuplicateFiles), sess.ID))
+		return
+	}
+	m.entries[m.filtered[m.selected]].session.DuplicateFiles = nil
+	m.refresh()
+	m.setStatus(fmt.Sprintf("Moved %d duplicate files of %s to the trash", len(sess.DuplicateFiles), sess.ID))
+}
+
+func describeFile(path, note string) string {
+	detail := "missing"
+	if info, err := os.Stat(path); err == nil {
+		detail = fmt.Sprintf("%s, modified %s", formatSize(info.Size()), formatTimestamp(info.ModTime()))
+	}
+	if note != "" {
+		detail += "  " + note
+	}
+	return fmt.Sprintf("%s\n  %s\n", path, detail)
+}
+
+// formatSize renders n bytes with a binary unit, e.g. "3.2 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// queryFilter is a "key:value" term of the search query that restricts results by a session
+// field instead of fuzzy matching, e.g. "model:gpt-5".
+type queryFilter struct {
+	key   string
+	value string
+}
+
+// filterMatchers maps the recognised filter keys to their match function. value is lower-cased.
+var filterMatchers = map[string]func(m *model, r row, value string) bool{
+	"model": func(m *model, r row, value string) bool {
+		return strings.Contains(strings.ToLower(r.session.Model), value)
+	},
+	// The field terms fuzzy-match a single column instead of the whole search key, so text
+	// matching one field does not pull in sessions through another.
+	"dir": func(m *model, r row, value string) bool {
+		return fuzzy.MatchFold(value, r.session.WorkingDir)
+	},
+	"action": func(m *model, r row, value string) bool {
+		return fuzzy.MatchFold(value, r.session.LastAction)
+	},
+	"id": func(m *model, r row, value string) bool {
+		return fuzzy.MatchFold(value, r.session.ID)
+	},
+	"host": func(m *model, r row, value string) bool {
+		return strings.Contains(strings.ToLower(r.session.Host), value)
+	},
+	"cost": func(m *model, r row, value string) bool {
+		cost, ok := m.prices.Cost(r.session)
+		return ok && compareNumber(cost, value)
+	},
+	"ended": func(m *model, r row, value string) bool {
+		// "error" stands for every kind of failure.
+		if value == "error" {
+			return r.session.EndState.Failed()
+		}
+		return r.session.EndState.String() == value
+	},
+	"note": func(m *model, r row, value string) bool {
+		return strings.Contains(strings.ToLower(m.noteText(r.session.ID)), value)
+	},
+	"thread": func(m *model, r row, value string) bool {
+		root := m.threadRoot(value)
+		return root != "" && m.threadRoot(r.session.ID) == root
+	},
+	"is": func(m *model, r row, value string) bool {
+		switch value {
+		case "pinned":
+			return m.meta != nil && m.meta.Get(r.session.ID).Pinned
+		case "hidden":
+			return m.meta != nil && m.meta.Get(r.session.ID).Hidden
+		case "project":
+			return m.inProject(r.session.ID)
+		default:
+			return false
+		}
+	},
+}
+
+// compareNumber reports whether x satisfies cond, a number optionally preceded by >, >=, < or
+// <=, e.g. ">1.5". A bare number means at least that much.
+func compareNumber(x float64, cond string) bool {
+	op := cond[:len(cond)-len(strings.TrimLeft(cond, "<>="))]
+	n, err := strconv.ParseFloat(cond[len(op):], 64)
+	if err != nil {
+		return false
+	}
+	switch op {
+	case ">":
+		return x > n
+	case "<":
+		return x < n
+	case "<=":
+		return x <= n
+	case "", ">=":
+		return x >= n
+	default:
+		return false
+	}
+}
+
+// caseMode is how the free text of the query matches letter case.
+type caseMode int
+
+const (
+	// caseSmart matches case-sensitively only when the query has an upper-case letter, as fzf
+	// does.
+	caseSmart caseMode = iota
+	caseSensitive
+	caseIgnore
+)
+
+func (c caseMode) String() string {
+	switch c {
+	case caseSensitive:
+		return "sensitive"
+	case caseIgnore:
+		return "ignored"
+	default:
+		return "smart"
+	}
+}
+
+// sensitive reports whether query is matched case-sensitively.
+func (c caseMode) sensitive(query string) bool {
+	switch c {
+	case caseSensitive:
+		return true
+	case caseIgnore:
+		return false
+	default:
+		return strings.ToLower(query) != query
+	}
+}
+
+// cycleCase switches between smart-case, case-sensitive and case-insensitive matching.
+func (m *model) cycleCase() {
+	m.caseMode = (m.caseMode + 1) % 3
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+	m.setStatus("Letter case: " + m.caseMode.String())
+}
+
+// caseSummary tells in the info line how letter case is matched, unless it is the smart default.
+func (m *model) caseSummary() string {
+	if m.caseMode == caseSmart {
+		return ""
+	}
+	return " | Case: " + m.caseMode.String()
+}
+
+// parseQuery splits query into filter terms and the remaining free text used for fuzzy matching.
+// Terms with an unknown key are kept as text so paths and snippets containing ':' still match.
+func parseQuery(query string) ([]queryFilter, string) {
+	var filters []queryFilter
+	var text []string
+	for _, field := range strings.Fields(query) {
+		key, value, ok := strings.Cut(field, ":")
+		key = strings.ToLower(key)
+		if _, known := filterMatchers[key]; ok && known && value != "" {
+			filters = append(filters, queryFilter{key: key, value: strings.ToLower(value)})
+			continue
+		}
+		text = append(text, field)
+	}
+	return filters, strings.Join(text, " ")
+}
+
+// matches reports whether r passes all filters, the time range and the repository filter, and
+// is not folded into a later continuation. Hidden sessions only match
+// when hidden sessions are shown or the query asks for them with is:hidden.
+func (m *model) matches(r row, filters []queryFilter) bool {
+	if !m.timeRange.Contains(r.session.UpdatedAt) {
+		return false
+	}
+	if m.repoOnly && !pathWithin(r.session.WorkingDir, m.repoRoot) {
+		return false
+	}
+	if m.collapsed(r.session.ID) {
+		return false
+	}
+	if !m.showHidden && m.meta != nil && m.meta.Get(r.session.ID).Hidden && !wantsHidden(filters) {
+		return false
+	}
+	for _, f := range filters {
+		if !filterMatchers[f.key](m, r, f.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func wantsHidden(filters []queryFilter) bool {
+	for _, f := range filters {
+		if f.key == "is" && f.value == "hidden" {
+			return true
+		}
+	}
+	return false
+}
+
+// narrowing remembers the sessions matched by the previous query. When the next query keeps the
+// same filter terms and only extends the text, every match must be among them, so typing does
+// not rescan every session.
+type narrowing struct {
+	valid   bool
+	filters string
+	text    string
+	// matched holds the entry indexes matching filters and text, in no particular order.
+	matched []int
+}
+
+// narrowed returns the indexes of the entries passing filters that can match text, reusing the
+// previous result when possible, and records them as the new baseline. applyFilter overwrites
+// matched with the fuzzy matches once they are known.
+func (m *model) narrowed(filters []queryFilter, text string) []int {
+	key := fmt.Sprint(filters)
+	n := &m.narrow
+	var candidates []int
+	if n.valid && n.filters == key && strings.HasPrefix(text, n.text) {
+		candidates = append(candidates, n.matched...)
+	} else {
+		candidates = make([]int, 0, len(m.entries))
+		for i, entry := range m.entries {
+			if m.matches(entry, filters) {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+	*n = narrowing{valid: true, filters: key, text: text, matched: append(n.matched[:0], candidates...)}
+	return candidates
+}
+
+// invalidateFilter forgets the previous matches after entries or annotations change.
+func (m *model) invalidateFilter() {
+	m.narrow.valid = false
+	m.threads = nil
+	m.continued = nil
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/rivo/tview"
+)
+
+const (
+	grepPage = "grep"
+	// maxGrepResults caps the lines listed in the results view.
+	maxGrepResults = 1000
+)
+
+// grepTranscripts prompts for a regular expression, searches the transcripts of the listed
+// sessions for it (case-insensitively) and shows the matching lines.
+func (m *model) grepTranscripts() {
+	m.prompt(" Grep transcripts (regular expression) ", m.grepPattern, m.table, func(text string) {
+		pattern := strings.TrimSpace(text)
+		if pattern == "" {
+			return
+		}
+		m.grepPattern = pattern
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Invalid pattern: %v", err))
+			return
+		}
+		list := make([]sessions.Session, len(m.filtered))
+		for i, idx := range m.filtered {
+			list[i] = m.entries[idx].session
+		}
+		m.setStatus(fmt.Sprintf("Searching %d transcripts for /%s/…", len(list), pattern))
+		redactor, candidates := m.redactor, m.candidates
+		go func() {
+			if candidates != nil {
+				list = candidates(list, re.String())
+			}
+			matches, err := sessions.Grep(context.Background(), list, re, 0, func(sess sessions.Session) ([]sessions.TranscriptEntry, error) {
+				entries, err := sessions.ReadTranscript(sess)
+				return redactor.Entries(entries), err
+			})
+			m.app.QueueUpdateDraw(func() {
+				m.showGrepResults(pattern, matches, err)
+			})
+		}()
+	})
+}
+
+// showGrepResults lists the matching lines; Enter opens the transcript of a match in the
+// preview with the matching entry highlighted.
+func (m *model) showGrepResults(pattern string, matches []sessions.GrepMatch, err error) {
+	if len(matches) == 0 {
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Grep failed: %v", err))
+		} else {
+			m.setStatus(fmt.Sprintf("No transcript matches /%s/", pattern))
+		}
+		return
+	}
+	status := fmt.Sprintf("%d matching lines for /%s/", len(matches), pattern)
+	if len(matches) > maxGrepResults {
+		status += fmt.Sprintf(", showing the first %d", maxGrepResults)
+		matches = matches[:maxGrepResults]
+	}
+	if err != nil {
+		status += fmt.Sprintf(" (some transcripts could not be read: %v)", err)
+	}
+	m.setStatus(status)
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(m.theme.selectedStyle())
+	for _, match := range matches {
+		line := strings.Join(strings.Fields(match.Line), " ")
+		list.AddItem(tview.Escape(fmt.Sprintf("%s  %s: %s", truncateText(match.Session.ID, 12), match.Entry.Heading(), line)), "", 0, nil)
+	}
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		m.closeOverlay(grepPage)
+		m.openGrepMatch(matches[index])
+	})
+	list.SetDoneFunc(func() {
+		m.closeOverlay(grepPage)
+	})
+	list.SetBorder(true).SetTitle(tview.Escape(fmt.Sprintf(" /%s/ — %d lines ", pattern, len(matches))))
+	m.openOverlay(grepPage, centered(list, 120, min(len(matches)+2, maxOverlayHeight)), list)
+}
+
+// openGrepMatch highlights the session of match and focuses the preview on the matching entry.
+func (m *model) openGrepMatch(match sessions.GrepMatch) {
+	m.selectSession(match.Session.ID)
+	if sess, ok := m.selectedSession(); !ok || sess.ID != match.Session.ID {
+		m.setStatus(fmt.Sprintf("Session %s is no longer listed", match.Session.ID))
+		return
+	}
+	m.refresh()
+	m.focusPreview()
+	m.syncPreview()
+	if match.Index < len(m.preview.entries) {
+		m.preview.table.Select(match.Index, 0)
+	}
+	m.setStatus(fmt.Sprintf("%s line %d of the %s entry", match.Session.ID, match.LineNumber, match.Entry.Heading()))
+}
@@ -0,0 +1,12 @@
+package ui
+
+// showHelp opens the help overlay: every action grouped by category with the keys bound under
+// the active keymap. Typing filters the list and Enter runs the highlighted action.
+func (m *model) showHelp() {
+	m.showPalette(" Help — type to filter, Enter to run ", true)
+}
+
+// showCommands opens the command palette, a flat fuzzy-filtered list of actions.
+func (m *model) showCommands() {
+	m.showPalette(" Commands ", false)
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/internal/history"
+)
+
+// recordQuery adds the current query to the search history and persists it.
+func (m *model) recordQuery() {
+	query := strings.TrimSpace(m.query)
+	if query != "" && (len(m.history) == 0 || m.history[len(m.history)-1] != query) {
+		m.history = history.Add(m.history, query)
+		if m.saveHistory != nil {
+			// Failing to remember a query is not worth interrupting the user for.
+			_ = m.saveHistory(m.history)
+		}
+	}
+	m.histPos = len(m.history)
+}
+
+// historyStep moves through the search history like a shell: step -1 recalls an older query,
+// step 1 a newer one, and stepping past the newest restores what was being typed. It only
+// applies while the query is empty or still shows a recalled entry; otherwise, and past the
+// newest entry, it reports false so Up/Down move the list selection instead.
+func (m *model) historyStep(step int) bool {
+	browsing := m.query == "" || m.histPos < len(m.history) && m.query == m.history[m.histPos]
+	if !browsing {
+		return false
+	}
+	pos := m.histPos + step
+	switch {
+	case pos < 0:
+		return len(m.history) > 0
+	case pos > len(m.history):
+		return false
+	}
+	if m.histPos == len(m.history) {
+		m.histDraft = m.query
+	}
+	m.histPos = pos
+	if pos == len(m.history) {
+		m.setQuery(m.histDraft)
+	} else {
+		m.setQuery(m.history[pos])
+	}
+	return true
+}
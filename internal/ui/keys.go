@@ -0,0 +1,318 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Uri2001/codex-sessions/internal/config"
+	"github.com/gdamore/tcell/v2"
+)
+
+// keyChord is a key press as bound in a keymap: a special key, or a rune with an optional Alt.
+type keyChord struct {
+	key tcell.Key
+	r   rune
+	alt bool
+}
+
+// namedKeys maps the key names accepted in bindings (lower-cased) to tcell keys. Ctrl+<letter>
+// and single characters are handled by parseChord.
+var namedKeys = map[string]tcell.Key{
+	"enter":     tcell.KeyEnter,
+	"esc":       tcell.KeyEsc,
+	"escape":    tcell.KeyEsc,
+	"tab":       tcell.KeyTab,
+	"shift+tab": tcell.KeyBacktab,
+	"backspace": tcell.KeyBackspace,
+	"del":       tcell.KeyDelete,
+	"delete":    tcell.KeyDelete,
+	"ins":       tcell.KeyInsert,
+	"insert":    tcell.KeyInsert,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+}
+
+// keyNames are the display names of special keys in the help overlay.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEnter:     "Enter",
+	tcell.KeyEsc:       "Esc",
+	tcell.KeyTab:       "Tab",
+	tcell.KeyBacktab:   "Shift+Tab",
+	tcell.KeyBackspace: "Backspace",
+	tcell.KeyDelete:    "Del",
+	tcell.KeyInsert:    "Ins",
+	tcell.KeyUp:        "Up",
+	tcell.KeyDown:      "Down",
+	tcell.KeyLeft:      "Left",
+	tcell.KeyRight:     "Right",
+	tcell.KeyHome:      "Home",
+	tcell.KeyEnd:       "End",
+	tcell.KeyPgUp:      "PgUp",
+	tcell.KeyPgDn:      "PgDn",
+}
+
+// parseChord parses a key name such as "p", "Space", "Ctrl+R", "Alt+x", "F3" or "PgDn".
+// Names are case-insensitive except for single characters.
+func parseChord(name string) (keyChord, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return keyChord{key: tcell.KeyRune, r: r}, nil
+	}
+	lower := strings.ToLower(strings.ReplaceAll(name, "-", "+"))
+	if key, ok := namedKeys[lower]; ok {
+		return keyChord{key: key}, nil
+	}
+	if lower == "space" {
+		return keyChord{key: tcell.KeyRune, r: ' '}, nil
+	}
+	if rest, ok := strings.CutPrefix(lower, "f"); ok {
+		if n, err := strconv.Atoi(rest); err == nil && n >= 1 && n <= 12 {
+			return keyChord{key: tcell.KeyF1 + tcell.Key(n-1)}, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(lower, "ctrl+"); ok && len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z' {
+		return keyChord{key: tcell.KeyCtrlA + tcell.Key(rest[0]-'a')}, nil
+	}
+	if strings.HasPrefix(lower, "alt+") {
+		if r, size := utf8.DecodeRuneInString(name[len("alt+"):]); size > 0 && size == len(name)-len("alt+") {
+			return keyChord{key: tcell.KeyRune, r: r, alt: true}, nil
+		}
+	}
+	return keyChord{}, fmt.Errorf("unknown key %q", name)
+}
+
+// chordOf returns the chord of a key event.
+func chordOf(event *tcell.EventKey) keyChord {
+	switch event.Key() {
+	case tcell.KeyRune:
+		return keyChord{key: tcell.KeyRune, r: event.Rune(), alt: event.Modifiers()&tcell.ModAlt != 0}
+	case tcell.KeyBackspace2:
+		return keyChord{key: tcell.KeyBackspace}
+	default:
+		return keyChord{key: event.Key()}
+	}
+}
+
+func (c keyChord) String() string {
+	switch {
+	case c.key == tcell.KeyRune && c.r == ' ':
+		return "Space"
+	case c.key == tcell.KeyRune && c.alt:
+		return "Alt+" + string(c.r)
+	case c.key == tcell.KeyRune:
+		return string(c.r)
+	case c.key >= tcell.KeyF1 && c.key <= tcell.KeyF12:
+		return fmt.Sprintf("F%d", c.key-tcell.KeyF1+1)
+	}
+	if name, ok := keyNames[c.key]; ok {
+		return name
+	}
+	if c.key >= tcell.KeyCtrlA && c.key <= tcell.KeyCtrlZ {
+		return "Ctrl+" + string(rune('A'+c.key-tcell.KeyCtrlA))
+	}
+	return fmt.Sprintf("key %d", c.key)
+}
+
+// Bindable actions, named as in the "keys" configuration setting.
+const (
+	actResume        = "resume"
+	actNew           = "new"
+	actDelete        = "delete"
+	actUndo          = "undo"
+	actArchive       = "archive"
+	actExport        = "export"
+	actExportView    = "export-view"
+	actCopyID        = "copy-id"
+	actOpenDir       = "open-dir"
+	actWorkdir       = "workdir"
+	actPin           = "pin"
+	actHide          = "hide"
+	actThread        = "thread"
+	actFiles         = "files"
+	actContinuations = "continuations"
+	actMark          = "mark"
+	actNote          = "note"
+	actView          = "view"
+	actDiffs         = "diffs"
+	actScript        = "script"
+	actDedupe        = "dedupe"
+	actUp            = "up"
+	actDown          = "down"
+	actPageUp        = "page-up"
+	actPageDown      = "page-down"
+	actHalfPageUp    = "half-page-up"
+	actHalfPageDown  = "half-page-down"
+	actTop           = "top"
+	actBottom        = "bottom"
+	actSort          = "sort"
+	actSearch        = "search"
+	actClearSearch   = "clear-search"
+	actClearFilter   = "clear-filter"
+	actCase          = "case"
+	actTimeRange     = "time-range"
+	actRepo          = "repo"
+	actGrep          = "grep"
+	actSaveSearch    = "save-search"
+	actRecallSearch  = "recall-search"
+	actTogglePreview = "toggle-preview"
+	actFocusPreview  = "focus-preview"
+	actReload        = "reload"
+	actLoadAll       = "load-all"
+	actHelp          = "help"
+	actCommands      = "commands"
+	actSuspend       = "suspend"
+	actQuit          = "quit"
+)
+
+// defaultBindings lists the bindable actions in the order conflicts are resolved, with their
+// keys under the default keymap and the keys the vim keymap adds.
+var defaultBindings = []struct {
+	action string
+	keys   []string
+	vim    []string
+}{
+	{actResume, []string{"Enter"}, nil},
+	{actNew, []string{"n"}, nil},
+	{actDelete, []string{"Del"}, nil},
+	{actUndo, []string{"u"}, nil},
+	{actArchive, []string{"a"}, nil},
+	{actExport, []string{"e"}, nil},
+	{actExportView, []string{"E"}, nil},
+	{actCopyID, []string{"y"}, nil},
+	{actOpenDir, []string{"o"}, nil},
+	{actWorkdir, []string{"W"}, nil},
+	{actPin, []string{"p"}, nil},
+	{actHide, []string{"h"}, nil},
+	{actThread, []string{"t"}, nil},
+	{actFiles, []string{"i"}, nil},
+	{actContinuations, []string{"x"}, nil},
+	{actMark, []string{"Space"}, nil},
+	{actNote, []string{"N"}, nil},
+	{actView, []string{"v"}, nil},
+	{actDiffs, []string{"D"}, nil},
+	{actScript, []string{"!"}, nil},
+	{actDedupe, nil, nil},
+	{actUp, []string{"Up"}, []string{"k"}},
+	{actDown, []string{"Down"}, []string{"j"}},
+	{actPageUp, []string{"PgUp"}, nil},
+	{actPageDown, []string{"PgDn"}, nil},
+	{actHalfPageUp, nil, []string{"Ctrl+U"}},
+	{actHalfPageDown, nil, []string{"Ctrl+D"}},
+	{actTop, nil, []string{"g"}},
+	{actBottom, nil, []string{"G"}},
+	{actSort, []string{"s"}, nil},
+	{actSearch, []string{"/", "Ctrl+F"}, nil},
+	{actClearSearch, []string{"Esc"}, nil},
+	{actClearFilter, []string{"c"}, nil},
+	{actCase, []string{"Alt+c"}, nil},
+	{actTimeRange, []string{"F3"}, nil},
+	{actRepo, []string{"."}, nil},
+	{actGrep, []string{"Ctrl+G"}, nil},
+	{actSaveSearch, []string{"Ctrl+S"}, nil},
+	{actRecallSearch, []string{"F4"}, nil},
+	{actTogglePreview, []string{"F2"}, nil},
+	{actFocusPreview, []string{"Tab"}, nil},
+	{actReload, []string{"Ctrl+R"}, nil},
+	{actLoadAll, []string{"L"}, nil},
+	{actHelp, []string{"?", "F1"}, nil},
+	{actCommands, []string{"Ctrl+P"}, []string{":"}},
+	{actSuspend, []string{"Ctrl+Z"}, nil},
+	{actQuit, []string{"Ctrl+C"}, nil},
+}
+
+// searchActions may be triggered while the search field has focus; every other key edits the
+// query there.
+var searchActions = map[string]bool{actSaveSearch: true, actRecallSearch: true, actCase: true, actQuit: true}
+
+// keyBindings binds key chords to actions.
+type keyBindings struct {
+	actions map[keyChord]string
+	// chords lists the keys of every action in binding order.
+	chords map[string][]keyChord
+}
+
+// newKeyBindings builds the bindings of the named keymap preset with overrides, which replace the
+// keys of the actions they name (an empty list unbinds one). Problems (unknown actions or keys,
+// one key bound to two actions) are reported in the returned error, and the bindings are
+// built anyway: a key overridden by the user wins over a default binding, otherwise the action listed
+// first in defaultBindings keeps it.
+func newKeyBindings(preset string, overrides map[string][]string) (*keyBindings, error) {
+	var problems []string
+	known := make(map[string]bool, len(defaultBindings))
+	for _, b := range defaultBindings {
+		known[b.action] = true
+	}
+	names := make([]string, 0, len(overrides))
+	for action := range overrides {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	for _, action := range names {
+		if !known[action] {
+			problems = append(problems, fmt.Sprintf("unknown action %q", action))
+		}
+	}
+
+	km := &keyBindings{actions: map[keyChord]string{}, chords: map[string][]keyChord{}}
+	bind := func(action string, names []string, user bool) {
+		for _, name := range names {
+			chord, err := parseChord(name)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", action, err))
+				continue
+			}
+			if other, taken := km.actions[chord]; taken && other != action {
+				if user {
+					problems = append(problems, fmt.Sprintf("%s is bound to both %s and %s, keeping %s", chord, other, action, other))
+				}
+				continue
+			}
+			km.actions[chord] = action
+			km.chords[action] = append(km.chords[action], chord)
+		}
+	}
+	// User bindings go first so they win over the defaults they collide with.
+	for _, b := range defaultBindings {
+		if keys, ok := overrides[b.action]; ok {
+			bind(b.action, keys, true)
+		}
+	}
+	for _, b := range defaultBindings {
+		if _, ok := overrides[b.action]; ok {
+			continue
+		}
+		bind(b.action, b.keys, false)
+		if preset == config.KeymapVim {
+			bind(b.action, b.vim, false)
+		}
+	}
+	if len(problems) > 0 {
+		return km, errors.New(strings.Join(problems, "; "))
+	}
+	return km, nil
+}
+
+// action returns the action bound to chord.
+func (km *keyBindings) action(chord keyChord) (string, bool) {
+	action, ok := km.actions[chord]
+	return action, ok
+}
+
+// keys returns the keys bound to action for the help overlay, e.g. "/, Ctrl+F".
+func (km *keyBindings) keys(action string) string {
+	names := make([]string, len(km.chords[action]))
+	for i, chord := range km.chords[action] {
+		names[i] = chord.String()
+	}
+	return strings.Join(names, ", ")
+}
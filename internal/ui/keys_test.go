@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Uri2001/codex-sessions/internal/config"
+)
+
+func TestKeyBindingConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		// bound maps key names to the action they must trigger, "" for none.
+		bound map[string]string
+		// keys maps actions to the keys the help overlay must list for them.
+		keys map[string]string
+		// problems are substrings the error must contain; none means no error.
+		problems []string
+	}{
+		{
+			name:   "defaults",
+			preset: config.KeymapDefault,
+			bound:  map[string]string{"Enter": actResume, "p": actPin, "Ctrl+F": actSearch, "k": ""},
+			keys:   map[string]string{actSearch: "/, Ctrl+F", actHelp: "?, F1"},
+		},
+		{
+			name:   "vim adds keys",
+			preset: config.KeymapVim,
+			bound:  map[string]string{"k": actUp, "j": actDown, "Up": actUp, ":": actCommands, "G": actBottom},
+			keys:   map[string]string{actUp: "Up, k", actCommands: "Ctrl+P, :"},
+		},
+		{
+			name:      "user key wins over a default",
+			preset:    config.KeymapDefault,
+			overrides: map[string][]string{actResume: {"p"}},
+			bound:     map[string]string{"p": actResume, "Enter": ""},
+			keys:      map[string]string{actResume: "p", actPin: ""},
+		},
+		{
+			name:      "user key wins over a vim default",
+			preset:    config.KeymapVim,
+			overrides: map[string][]string{actSort: {"k"}},
+			bound:     map[string]string{"k": actSort, "Up": actUp, "s": ""},
+			keys:      map[string]string{actUp: "Up"},
+		},
+		{
+			name:      "two user keys keep the action listed first",
+			preset:    config.KeymapDefault,
+			overrides: map[string][]string{actHide: {"x"}, actPin: {"x", "P"}},
+			bound:     map[string]string{"x": actPin, "P": actPin, "h": ""},
+			keys:      map[string]string{actPin: "x, P", actHide: "", actContinuations: ""},
+			problems:  []string{"x is bound to both pin and hide, keeping pin"},
+		},
+		{
+			name:      "an empty list unbinds",
+			preset:    config.KeymapDefault,
+			overrides: map[string][]string{actDelete: {}},
+			bound:     map[string]string{"Del": ""},
+		},
+		{
+			name:      "an override replaces the vim keys too",
+			preset:    config.KeymapVim,
+			overrides: map[string][]string{actUp: {"Ctrl+K"}},
+			bound:     map[string]string{"Ctrl+K": actUp, "k": "", "Up": ""},
+		},
+		{
+			name:      "unknown action and key",
+			preset:    config.KeymapDefault,
+			overrides: map[string][]string{"launch": {"l"}, actPin: {"Hyper+P", "Alt+p"}},
+			bound:     map[string]string{"Alt+p": actPin, "l": "", "p": ""},
+			problems:  []string{`unknown action "launch"`, `pin: unknown key "Hyper+P"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := newKeyBindings(tt.preset, tt.overrides)
+			switch {
+			case len(tt.problems) == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case len(tt.problems) > 0 && err == nil:
+				t.Errorf("no error, want %q", tt.problems)
+			}
+			for _, problem := range tt.problems {
+				if err != nil && !strings.Contains(err.Error(), problem) {
+					t.Errorf("error %q does not mention %q", err, problem)
+				}
+			}
+			for name, want := range tt.bound {
+				chord, err := parseChord(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, _ := km.action(chord); got != want {
+					t.Errorf("%s triggers %q, want %q", name, got, want)
+				}
+			}
+			for action, want := range tt.keys {
+				if got := km.keys(action); got != want {
+					t.Errorf("keys(%s) = %q, want %q", action, got, want)
+				}
+			}
+		})
+	}
+}
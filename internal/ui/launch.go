@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// noTemplate is the first entry of the template list and starts Codex without a prompt.
+const noTemplate = "(no template)"
+
+// newSession asks for a directory and, when prompt templates are configured, a template, then
+// exits so the caller can start a new Codex session there.
+func (m *model) newSession() {
+	initial, _ := os.Getwd()
+	if sess, ok := m.selectedSession(); ok && !m.dirMissing(sess) && sess.WorkingDir != "" {
+		initial = sess.WorkingDir
+	}
+	m.prompt(" New session in directory ", initial, m.table, func(text string) {
+		dir := strings.TrimSpace(text)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			m.setStatus(fmt.Sprintf("%s is not a directory", dir))
+			return
+		}
+		if len(m.templates) == 0 {
+			m.startSession(dir, "")
+			return
+		}
+		names := make([]string, 0, len(m.templates))
+		for name := range m.templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		m.pick(" Prompt template ", append([]string{noTemplate}, names...), func(index int) {
+			prompt := ""
+			if index > 0 {
+				prompt = m.templates[names[index-1]]
+			}
+			m.startSession(dir, prompt)
+		})
+	})
+}
+
+func (m *model) startSession(dir, prompt string) {
+	m.chosen = Selection{New: true, Dir: dir, Prompt: prompt}
+	m.app.Stop()
+}
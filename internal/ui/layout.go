@@ -5,3 +5,89 @@ import "github.com/rivo/tview"
 func listSpacer() *tview.Box {
 	return tview.NewBox()
 }
+
+// centered wraps p in a flex layout that keeps it centred with the given size.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// columnSpec describes how a table column is sized: it gets at least min and at most max
+// columns (0 for no limit), shares the remaining width with the others by weight, and columns
+// with the lowest priority are dropped first when even the minimums do not fit.
+type columnSpec struct {
+	min, max int
+	weight   int
+	priority int
+}
+
+// allocateWidths divides width among columns separated by one blank cell. It returns the width
+// of every column, 0 for the dropped ones. At least one column is always kept.
+func allocateWidths(specs []columnSpec, width int) []int {
+	widths := make([]int, len(specs))
+	kept := make([]bool, len(specs))
+	for i := range specs {
+		kept[i] = true
+	}
+	used := func() int {
+		n, total := 0, 0
+		for i, spec := range specs {
+			if kept[i] {
+				n++
+				total += spec.min
+			}
+		}
+		return total + max(n-1, 0)
+	}
+	for n := len(specs); n > 1 && used() > width; n-- {
+		drop := -1
+		for i, spec := range specs {
+			// On equal priority the rightmost column goes first.
+			if kept[i] && (drop < 0 || spec.priority <= specs[drop].priority) {
+				drop = i
+			}
+		}
+		kept[drop] = false
+	}
+
+	for i, spec := range specs {
+		if kept[i] {
+			widths[i] = spec.min
+		}
+	}
+	rest := width - used()
+	for rest > 0 {
+		total := 0
+		for i, spec := range specs {
+			if kept[i] && (spec.max == 0 || widths[i] < spec.max) {
+				total += spec.weight
+			}
+		}
+		if total == 0 {
+			break
+		}
+		given := 0
+		for i, spec := range specs {
+			if !kept[i] || spec.weight == 0 || (spec.max != 0 && widths[i] >= spec.max) {
+				continue
+			}
+			add := max(rest*spec.weight/total, 1)
+			if spec.max != 0 {
+				add = min(add, spec.max-widths[i])
+			}
+			add = min(add, rest-given)
+			widths[i] += add
+			given += add
+		}
+		if given == 0 {
+			break
+		}
+		rest -= given
+	}
+	return widths
+}
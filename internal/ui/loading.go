@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// loadTick is how often sessions streamed in the background are added to the table.
+const loadTick = 100 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// startLoading runs m.stream in the background and adds the sessions it reports to the table in
+// batches, animating a spinner in the info line until it finishes. A load already in progress
+// is cancelled first. Once the scan completes, sessions it did not report are dropped and
+// metadata enrichment starts.
+func (m *model) startLoading() {
+	if m.cancelLoad != nil {
+		m.cancelLoad()
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancelLoad = cancel
+	m.loading = true
+	var mu sync.Mutex
+	var pending []sessions.Session
+	seen := make(map[string]bool)
+	done := make(chan error, 1)
+	// The scan works on its own copy, so later changes of m.load wait for the next one.
+	opts := m.load
+
+	go func() {
+		done <- m.stream(ctx, opts, func(sess sessions.Session) {
+			mu.Lock()
+			pending = append(pending, sess)
+			mu.Unlock()
+		})
+	}()
+
+	take := func() []sessions.Session {
+		mu.Lock()
+		defer mu.Unlock()
+		batch := pending
+		pending = nil
+		return batch
+	}
+
+	go func() {
+		ticker := time.NewTicker(loadTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-done:
+				batch := take()
+				m.app.QueueUpdateDraw(func() {
+					if ctx.Err() != nil {
+						return
+					}
+					m.loading = false
+					m.addSessions(batch, seen)
+					m.dropUnseen(seen)
+					if err != nil {
+						m.setStatus(err.Error())
+					}
+					m.startEnrichment(ctx)
+				})
+				return
+			case <-ticker.C:
+				batch := take()
+				m.app.QueueUpdateDraw(func() {
+					if ctx.Err() != nil {
+						return
+					}
+					m.spinner++
+					m.addSessions(batch, seen)
+				})
+			}
+		}
+	}()
+}
+
+// addSessions inserts streamed sessions, replacing earlier reports of the same ID, records
+// their IDs in seen and keeps the highlighted session selected.
+func (m *model) addSessions(batch []sessions.Session, seen map[string]bool) {
+	if len(batch) > 0 {
+		selectedID := ""
+		if sess, ok := m.selectedSession(); ok {
+			selectedID = sess.ID
+		}
+		index := make(map[string]int, len(m.entries))
+		for i, entry := range m.entries {
+			index[entry.session.ID] = i
+		}
+		for _, sess := range batch {
+			seen[sess.ID] = true
+			if i, ok := index[sess.ID]; ok {
+				m.setRow(i, sess)
+				continue
+			}
+			index[sess.ID] = len(m.entries)
+			m.entries = append(m.entries, row{})
+			m.setRow(len(m.entries)-1, sess)
+		}
+		m.invalidateFilter()
+		m.applyFilter()
+		if selectedID != "" {
+			m.selectSession(selectedID)
+		}
+		m.selectStart()
+	}
+	m.refresh()
+}
+
+// dropUnseen removes the sessions a completed scan did not report, e.g. ones deleted outside
+// the UI before a reload.
+func (m *model) dropUnseen(seen map[string]bool) {
+	kept := m.entries[:0]
+	for _, entry := range m.entries {
+		if seen[entry.session.ID] {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(m.entries) {
+		return
+	}
+	m.entries = kept
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+}
+
+// reload scans the sessions roots again, cancelling a scan that is still running.
+func (m *model) reload() {
+	if m.stream == nil {
+		m.setStatus("Reloading is not available")
+		return
+	}
+	m.missingDirs = nil
+	m.startLoading()
+	m.setStatus("Reloading sessions…")
+}
+
+// loadAll lifts the file limit the sessions were loaded with and reads every rollout file.
+func (m *model) loadAll() {
+	if m.load.FileLimit == 0 || m.stream == nil {
+		m.setStatus("All sessions are loaded")
+		return
+	}
+	m.load.FileLimit = 0
+	m.reload()
+	m.setStatus("Loading all sessions…")
+}
+
+// limitSummary tells in the info line that only the newest rollout files were read.
+func (m *model) limitSummary() string {
+	if m.load.FileLimit == 0 || m.stream == nil {
+		return ""
+	}
+	summary := fmt.Sprintf(" | Newest %d files", m.load.FileLimit)
+	if chords := m.keys.chords[actLoadAll]; len(chords) > 0 {
+		summary += fmt.Sprintf(" (%s loads all)", chords[0])
+	}
+	return summary
+}
+
+// loadingIndicator returns the spinner shown in the info line while sessions are loading.
+func (m *model) loadingIndicator() string {
+	if !m.loading {
+		return ""
+	}
+	return fmt.Sprintf(" | Loading %s", spinnerFrames[m.spinner%len(spinnerFrames)])
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// setupMouse enables mouse handling: clicking selects, double-clicking resumes, the wheel moves
+// the selection and clicking a column header changes the sort order.
+func (m *model) setupMouse() {
+	m.app.EnableMouse(true)
+
+	m.table.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		switch action {
+		case tview.MouseScrollUp:
+			m.moveSelectionBy(-1)
+			return tview.MouseConsumed, nil
+		case tview.MouseScrollDown:
+			m.moveSelectionBy(1)
+			return tview.MouseConsumed, nil
+		case tview.MouseLeftClick:
+			x, y := event.Position()
+			row, col := m.table.CellAt(x, y)
+			if row == 0 {
+				if mode, ok := m.columnSort(col); ok {
+					m.setSort(mode)
+				}
+				m.app.SetFocus(m.table)
+				return tview.MouseConsumed, nil
+			}
+		case tview.MouseLeftDoubleClick:
+			x, y := event.Position()
+			if row, _ := m.table.CellAt(x, y); row > 0 && row < m.tableRow(len(m.filtered)) {
+				m.selected, _ = m.filteredAt(row)
+				m.openSelected()
+				return tview.MouseConsumed, nil
+			}
+		}
+		return action, event
+	})
+
+	// Keep focus-dependent state in sync when focus moves by clicking.
+	m.table.SetFocusFunc(func() {
+		m.searching = false
+		m.preview.focused = false
+		m.refreshSearchView()
+	})
+	m.searchView.SetFocusFunc(func() {
+		m.searching = true
+		m.preview.focused = false
+		m.refreshSearchView()
+	})
+	m.preview.table.SetFocusFunc(func() {
+		m.searching = false
+		m.preview.focused = true
+		m.refreshSearchView()
+	})
+}
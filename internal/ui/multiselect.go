@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const markedMark = "✓"
+
+// toggleMark marks or unmarks the highlighted session for a multiple selection and moves to the
+// next row, so several sessions can be marked by holding the key.
+func (m *model) toggleMark() {
+	if !m.multiSelect {
+		m.setStatus("Marking several sessions needs --no-resume or --choose")
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok {
+		m.setStatus("Nothing to mark")
+		return
+	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[sess.ID] {
+		delete(m.marked, sess.ID)
+	} else {
+		m.marked[sess.ID] = true
+	}
+	m.moveSelectionBy(1)
+	m.refreshInfoView()
+	if len(m.marked) == 0 {
+		m.setStatus("No sessions marked")
+		return
+	}
+	m.setStatus(fmt.Sprintf("%d sessions marked; %s chooses them all", len(m.marked), m.keys.keys(actResume)))
+}
+
+// markedSessions returns the marked sessions in list order, including marked sessions that the
+// current filter hides.
+func (m *model) markedSessions() []sessions.Session {
+	var out []sessions.Session
+	for _, entry := range m.entries {
+		if m.marked[entry.session.ID] {
+			out = append(out, entry.session)
+		}
+	}
+	return out
+}
+
+// markSummary describes the marked sessions for the info line, or returns "" when none are.
+func (m *model) markSummary() string {
+	if len(m.marked) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" | Marked: %d", len(m.marked))
+}
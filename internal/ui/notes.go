@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	notePage = "note"
+	noteMark = "✎"
+	// maxNoteLines is the most lines of a note the preview shows above the transcript.
+	maxNoteLines = 6
+)
+
+// editNote opens the note of the highlighted session in a text area. Ctrl+S saves it, Esc
+// discards the changes and Ctrl+E continues editing in $EDITOR.
+func (m *model) editNote() {
+	if !m.writable("notes") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok || m.meta == nil {
+		m.setStatus("Nothing to annotate")
+		return
+	}
+	area := tview.NewTextArea().
+		SetText(m.meta.Get(sess.ID).Note, true).
+		SetTextStyle(tcell.StyleDefault.Background(m.theme.background).Foreground(m.theme.text))
+	area.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlS:
+			m.closeOverlay(notePage)
+			m.saveNote(sess, area.GetText())
+			return nil
+		case tcell.KeyEsc:
+			m.closeOverlay(notePage)
+			m.setStatus("Note not changed")
+			return nil
+		case tcell.KeyCtrlE:
+			text, err := m.editInEditor(area.GetText(), ".md")
+			if err != nil {
+				m.setStatus(fmt.Sprintf("Editor failed: %v", err))
+				return nil
+			}
+			m.closeOverlay(notePage)
+			m.saveNote(sess, text)
+			return nil
+		}
+		return event
+	})
+	area.SetBorder(true).SetTitle(fmt.Sprintf(" Note on %s — Ctrl+S saves, Esc cancels, Ctrl+E opens $EDITOR ", truncateText(sess.ID, 20)))
+	m.openOverlay(notePage, centered(area, 80, 12), area)
+}
+
+// saveNote stores text as the note of sess; an empty note removes it.
+func (m *model) saveNote(sess sessions.Session, text string) {
+	note := strings.TrimSpace(text)
+	if err := m.meta.Update(sess.ID, func(meta *sidecar.Meta) { meta.Note = note }); err != nil {
+		m.setStatus(fmt.Sprintf("Saving the note failed: %v", err))
+		return
+	}
+	m.invalidateFilter()
+	m.applyFilter()
+	m.selectSession(sess.ID)
+	m.refresh()
+	m.syncPreview()
+	if note == "" {
+		m.setStatus(fmt.Sprintf("Removed the note on %s", sess.ID))
+	} else {
+		m.setStatus(fmt.Sprintf("Saved the note on %s", sess.ID))
+	}
+}
+
+// editInEditor suspends the UI, lets the user edit text in $VISUAL or $EDITOR (vi, or Notepad
+// on Windows, when neither is set) and returns the edited text. The temporary file gets the
+// extension ext, so editors pick the right syntax.
+func (m *model) editInEditor(text, ext string) (string, error) {
+	file, err := os.CreateTemp("", "codex-sessions-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	path := file.Name()
+	defer os.Remove(path)
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// $EDITOR may carry arguments, e.g. "code --wait".
+	argv := append(strings.Fields(editor), path)
+	m.app.Suspend(func() {
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err = cmd.Run()
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// noteText returns the note of the session with the given ID, or "".
+func (m *model) noteText(id string) string {
+	if m.meta == nil {
+		return ""
+	}
+	return m.meta.Get(id).Note
+}
+
+// setNote shows note above the transcript, or hides the note box when it is empty.
+func (p *preview) setNote(note string) {
+	p.note.SetText(note)
+	if note == "" {
+		p.pane.ResizeItem(p.note, 0, 0)
+		return
+	}
+	lines := min(strings.Count(note, "\n")+1, maxNoteLines)
+	p.pane.ResizeItem(p.note, lines+2, 0)
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/rivo/tview"
+)
+
+const (
+	palettePage      = "palette"
+	maxOverlayHeight = 24
+)
+
+// showPalette opens an overlay listing every action. Typing fuzzy-filters the list by name and
+// Enter runs the highlighted action. When grouped is set, actions are listed under category
+// headings.
+func (m *model) showPalette(title string, grouped bool) {
+	actions := m.actions()
+	// rows maps table rows to indexes into visible; headings map to -1.
+	var (
+		visible []action
+		rows    []int
+	)
+
+	input := tview.NewInputField().
+		SetLabel("Action> ").
+		SetFieldWidth(0)
+
+	table := tview.NewTable().
+		SetSelectable(true, false)
+	table.SetSelectedStyle(m.theme.selectedStyle())
+
+	selectFrom := func(row, step int) {
+		for ; row >= 0 && row < len(rows); row += step {
+			if rows[row] >= 0 {
+				table.Select(row, 0)
+				return
+			}
+		}
+	}
+
+	fill := func(query string) {
+		visible = filterActions(actions, query)
+		if grouped {
+			visible = groupActions(visible)
+		}
+		rows = rows[:0]
+		table.Clear()
+		category := ""
+		for i, act := range visible {
+			if grouped && act.category != category {
+				category = act.category
+				table.SetCell(len(rows), 0, tview.NewTableCell(category).
+					SetSelectable(false).
+					SetStyle(m.theme.headerStyle()))
+				rows = append(rows, -1)
+			}
+			table.SetCell(len(rows), 0, tview.NewTableCell("  "+act.name).SetExpansion(1))
+			table.SetCell(len(rows), 1, tview.NewTableCell(act.keys).SetTextColor(m.theme.accent))
+			rows = append(rows, i)
+		}
+		table.ScrollToBeginning()
+		selectFrom(0, 1)
+	}
+
+	input.SetChangedFunc(fill)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := table.GetSelection()
+		switch event.Key() {
+		case tcell.KeyUp:
+			selectFrom(row-1, -1)
+			return nil
+		case tcell.KeyDown:
+			selectFrom(row+1, 1)
+			return nil
+		case tcell.KeyEnter:
+			m.closeOverlay(palettePage)
+			if row >= 0 && row < len(rows) && rows[row] >= 0 {
+				visible[rows[row]].run()
+			}
+			return nil
+		case tcell.KeyEsc:
+			m.closeOverlay(palettePage)
+			return nil
+		}
+		return event
+	})
+
+	fill("")
+
+	body := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(listSpacer(), 1, 0, false).
+		AddItem(table, 0, 1, false)
+	body.SetBorder(true).SetTitle(title)
+
+	height := len(rows) + 5
+	if height > maxOverlayHeight {
+		height = maxOverlayHeight
+	}
+	m.openOverlay(palettePage, centered(body, 64, height), input)
+}
+
+// groupActions orders actions by category, keeping their relative order within a category.
+func groupActions(actions []action) []action {
+	out := make([]action, 0, len(actions))
+	for _, category := range categoryOrder {
+		for _, act := range actions {
+			if act.category == category {
+				out = append(out, act)
+			}
+		}
+	}
+	return out
+}
+
+func filterActions(actions []action, query string) []action {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return actions
+	}
+	var out []action
+	for _, act := range actions {
+		if fuzzy.MatchFold(query, act.name) {
+			out = append(out, act)
+		}
+	}
+	return out
+}
+
+func (m *model) openOverlay(name string, p, focus tview.Primitive) {
+	m.pages.AddPage(name, p, true, true)
+	m.app.SetFocus(focus)
+}
+
+func (m *model) closeOverlay(name string) {
+	m.pages.RemovePage(name)
+	m.app.SetFocus(m.table)
+}
+
+func (m *model) overlayOpen() bool {
+	name, _ := m.pages.GetFrontPage()
+	return name != mainPage
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	patchesPage = "patches"
+	diffPage    = "diff"
+)
+
+// fileChange is every change the patches of a session make to one file, in order.
+type fileChange struct {
+	path           string
+	diffs          []sessions.FileDiff
+	added, removed int
+}
+
+// groupDiffs collects diffs by path, in the order the paths are first changed.
+func groupDiffs(diffs []sessions.FileDiff) []*fileChange {
+	var out []*fileChange
+	byPath := map[string]*fileChange{}
+	for _, diff := range diffs {
+		change := byPath[diff.Path]
+		if change == nil {
+			change = &fileChange{path: diff.Path}
+			byPath[diff.Path] = change
+			out = append(out, change)
+		}
+		change.diffs = append(change.diffs, diff)
+		change.added += diff.Added
+		change.removed += diff.Removed
+	}
+	return out
+}
+
+// showPatches lists the files changed by the apply_patch calls of the highlighted session;
+// Enter shows the diffs of a file.
+func (m *model) showPatches() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	patches, err := sessions.ReadPatches(sess)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Reading patches failed: %v", err))
+		return
+	}
+	var diffs []sessions.FileDiff
+	for _, patch := range patches {
+		diffs = append(diffs, patch.Files...)
+	}
+	if len(diffs) == 0 {
+		m.setStatus(fmt.Sprintf("%s made no apply_patch changes", sess.ID))
+		return
+	}
+	m.listChanges(sess.ID, groupDiffs(diffs), m.table)
+}
+
+// showEntryPatch shows the diff of the highlighted preview entry when it is an apply_patch call.
+func (m *model) showEntryPatch() {
+	cursor, _ := m.preview.table.GetSelection()
+	if cursor < 0 || cursor >= len(m.preview.entries) {
+		return
+	}
+	diffs := sessions.ParsePatch(m.preview.entries[cursor].Text)
+	if m.preview.entries[cursor].Role != "tool" || len(diffs) == 0 {
+		m.setStatus("The highlighted entry is not a patch")
+		return
+	}
+	changes := groupDiffs(diffs)
+	if len(changes) == 1 {
+		m.showDiff(changes[0], m.preview.table)
+		return
+	}
+	m.listChanges(m.preview.sessionID, changes, m.preview.table)
+}
+
+// listChanges opens a list of changed files with their added and removed line counts. Focus
+// returns to back when it is closed.
+func (m *model) listChanges(id string, changes []*fileChange, back tview.Primitive) {
+	var added, removed int
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(m.theme.selectedStyle())
+	for _, change := range changes {
+		added += change.added
+		removed += change.removed
+		list.AddItem(fmt.Sprintf("%s  %s %s", tview.Escape(change.path),
+			m.diffStyle(fmt.Sprintf("+%d", change.added)), m.diffStyle(fmt.Sprintf("-%d", change.removed))), "", 0, nil)
+	}
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		m.showDiff(changes[index], list)
+	})
+	list.SetDoneFunc(func() {
+		m.pages.RemovePage(patchesPage)
+		m.app.SetFocus(back)
+	})
+	list.SetBorder(true).SetTitle(fmt.Sprintf(" %s — %d files +%d -%d ", truncateText(id, 20), len(changes), added, removed))
+	m.openOverlay(patchesPage, centered(list, 100, min(len(changes)+2, maxOverlayHeight)), list)
+}
+
+// showDiff pages through the changes made to one file, colored like a unified diff.
+func (m *model) showDiff(change *fileChange, back tview.Primitive) {
+	var b strings.Builder
+	for i, diff := range change.diffs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		heading := fmt.Sprintf("%s %s", diff.Op, diff.Path)
+		if diff.MoveTo != "" {
+			heading += " → " + diff.MoveTo
+		}
+		fmt.Fprintf(&b, "[::b]%s (+%d -%d)[::-]\n", tview.Escape(heading), diff.Added, diff.Removed)
+		for _, line := range diff.Lines {
+			b.WriteString(m.diffStyle(tview.Escape(m.redactor.Redact(line))) + "\n")
+		}
+	}
+	view := tview.NewTextView().SetDynamicColors(true).SetWrap(false).SetText(b.String())
+	view.SetTextColor(m.theme.text).SetBackgroundColor(m.theme.background)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'q' {
+			m.pages.RemovePage(diffPage)
+			m.app.SetFocus(back)
+			return nil
+		}
+		return event
+	})
+	view.SetBorder(true).SetTitle(fmt.Sprintf(" %s +%d -%d — q closes ", change.path, change.added, change.removed))
+	m.openOverlay(diffPage, view, view)
+}
+
+// diffStyle colors a diff line by its first character: added lines in the accent color,
+// removed ones in the error color and hunk headers muted. Mono themes leave it as it is.
+func (m *model) diffStyle(line string) string {
+	if m.theme.mono || line == "" {
+		return line
+	}
+	var color tcell.Color
+	switch line[0] {
+	case '+':
+		color = m.theme.accent
+	case '-':
+		color = m.theme.errorRow
+	case '@':
+		color = m.theme.muted
+	}
+	if css := color.CSS(); css != "" {
+		return "[" + css + "]" + line + "[-]"
+	}
+	return line
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"github.com/rivo/tview"
+)
+
+const pickPage = "pick"
+
+// pick opens an overlay listing items. Enter closes it and calls done with the index of the
+// highlighted item; Esc closes it without calling done.
+func (m *model) pick(title string, items []string, done func(index int)) {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(m.theme.selectedStyle())
+	for _, item := range items {
+		list.AddItem(item, "", 0, nil)
+	}
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		m.closeOverlay(pickPage)
+		done(index)
+	})
+	list.SetDoneFunc(func() {
+		m.closeOverlay(pickPage)
+	})
+	list.SetBorder(true).SetTitle(title)
+
+	height := len(items) + 2
+	if height > maxOverlayHeight {
+		height = maxOverlayHeight
+	}
+	m.openOverlay(pickPage, centered(list, 64, height), list)
+}
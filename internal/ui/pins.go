@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+)
+
+const (
+	pinMark    = "★"
+	hiddenMark = "⊘"
+)
+
+func (m *model) isPinned(idx int) bool {
+	return m.meta != nil && m.meta.Get(m.entries[idx].session.ID).Pinned
+}
+
+// pinnedFirst moves the sessions of the project file to the top of the filtered list, followed
+// by pinned sessions, keeping the order within each group.
+func (m *model) pinnedFirst() {
+	group := func(idx int) int {
+		switch {
+		case m.inProject(m.entries[idx].session.ID):
+			return 0
+		case m.isPinned(idx):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(m.filtered, func(i, j int) bool {
+		return group(m.filtered[i]) < group(m.filtered[j])
+	})
+}
+
+// togglePin pins or unpins the highlighted session.
+func (m *model) togglePin() {
+	if !m.writable("pinning") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok || m.meta == nil {
+		m.setStatus("Nothing to pin")
+		return
+	}
+	err := m.meta.Update(sess.ID, func(meta *sidecar.Meta) { meta.Pinned = !meta.Pinned })
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Pin failed: %v", err))
+		return
+	}
+	m.invalidateFilter()
+	m.applyFilter()
+	m.selectSession(sess.ID)
+	m.refresh()
+	if m.meta.Get(sess.ID).Pinned {
+		m.setStatus(fmt.Sprintf("Pinned session %s", sess.ID))
+	} else {
+		m.setStatus(fmt.Sprintf("Unpinned session %s", sess.ID))
+	}
+}
+
+// toggleHidden hides the highlighted session from the default view, or unhides it.
+func (m *model) toggleHidden() {
+	if !m.writable("hiding") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok || m.meta == nil {
+		m.setStatus("Nothing to hide")
+		return
+	}
+	err := m.meta.Update(sess.ID, func(meta *sidecar.Meta) { meta.Hidden = !meta.Hidden })
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Hide failed: %v", err))
+		return
+	}
+	m.invalidateFilter()
+	m.applyFilter()
+	m.selectSession(sess.ID)
+	m.refresh()
+	if m.meta.Get(sess.ID).Hidden {
+		m.setStatus(fmt.Sprintf("Hid session %s (search is:hidden to find it again)", sess.ID))
+	} else {
+		m.setStatus(fmt.Sprintf("Unhid session %s", sess.ID))
+	}
+}
+
+// selectSession moves the selection to the session with the given ID if it is visible.
+func (m *model) selectSession(id string) {
+	for i, idx := range m.filtered {
+		if m.entries[idx].session.ID == id {
+			m.selected = i
+			return
+		}
+	}
+}
+
+// selectStart highlights the session selected last time once it is listed.
+func (m *model) selectStart() {
+	if m.startAt == "" {
+		return
+	}
+	for i, idx := range m.filtered {
+		if m.entries[idx].session.ID == m.startAt {
+			m.selected = i
+			m.startAt = ""
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// plainPageLen is the number of sessions the plain interface lists at a time.
+const plainPageLen = 20
+
+const plainHelp = `Type a number to resume that session, words to filter the list, Enter for more, "-" to clear the filter, "q" to quit.`
+
+const plainMultiHelp = `Type numbers separated by spaces to choose those sessions, words to filter the list, Enter for more, "-" to clear the filter, "q" to quit.`
+
+// RunPlain is a line-oriented alternative to Run for screen readers and terminals where the
+// full-screen UI cannot be drawn. It writes a numbered list of sessions to out and reads
+// commands from in, one per line. When items is empty the sessions are loaded with
+// opts.Stream first. Of the options, it honours Query, Status, TimeRange, ShowHidden,
+// SessionsRoot (for hidden sessions) and MultiSelect, which accepts several numbers at once.
+func RunPlain(items []sessions.Session, opts Options, in io.Reader, out io.Writer) (Selection, error) {
+	if len(items) == 0 && opts.Stream != nil {
+		fmt.Fprintln(out, "Loading sessions…")
+		var err error
+		if items, err = collectStream(opts.Stream, opts.Load); err != nil && len(items) == 0 {
+			return Selection{}, err
+		}
+	}
+	meta, _ := sidecar.Open(opts.SessionsRoot)
+	var all []sessions.Session
+	for _, sess := range items {
+		if !opts.TimeRange.Contains(sess.UpdatedAt) {
+			continue
+		}
+		if !opts.ShowHidden && meta != nil && meta.Get(sess.ID).Hidden {
+			continue
+		}
+		all = append(all, sess)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].UpdatedAt.After(all[j].UpdatedAt) })
+
+	if opts.Status != "" {
+		fmt.Fprintln(out, opts.Status)
+	}
+	help := plainHelp
+	if opts.MultiSelect {
+		help = plainMultiHelp
+	}
+	fmt.Fprintln(out, help)
+	query := opts.Query
+	list := plainFilter(all, query)
+	shown := plainList(out, list, query, 0)
+	input := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !input.Scan() {
+			fmt.Fprintln(out)
+			return Selection{}, input.Err()
+		}
+		line := strings.TrimSpace(input.Text())
+		switch {
+		case line == "q":
+			return Selection{}, nil
+		case line == "":
+			if shown >= len(list) {
+				fmt.Fprintln(out, "No more sessions.")
+				continue
+			}
+			shown = plainList(out, list, query, shown)
+		case line == "-":
+			query = ""
+			list = all
+			shown = plainList(out, list, query, 0)
+		case line == "?":
+			fmt.Fprintln(out, help)
+		default:
+			if numbers, ok := plainNumbers(line); ok && (len(numbers) == 1 || opts.MultiSelect) {
+				var chosen []sessions.Session
+				for _, n := range numbers {
+					if n < 1 || n > len(list) {
+						fmt.Fprintf(out, "There is no session %d; choose 1 to %d.\n", n, len(list))
+						chosen = nil
+						break
+					}
+					chosen = append(chosen, list[n-1])
+				}
+				switch {
+				case len(chosen) == 0:
+					continue
+				case opts.MultiSelect:
+					return Selection{Session: chosen[0], Sessions: chosen}, nil
+				}
+				return Selection{Session: chosen[0]}, nil
+			}
+			query = line
+			list = plainFilter(all, query)
+			shown = plainList(out, list, query, 0)
+		}
+	}
+}
+
+// plainNumbers parses a line of numbers separated by spaces or commas.
+func plainNumbers(line string) ([]int, bool) {
+	fields := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' })
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = n
+	}
+	return numbers, len(numbers) > 0
+}
+
+// plainList prints the sessions of list from index start on, one page at a time, and returns
+// the index after the last one printed.
+func plainList(out io.Writer, list []sessions.Session, query string, start int) int {
+	if len(list) == 0 {
+		if query != "" {
+			fmt.Fprintf(out, "No sessions match %q.\n", query)
+		} else {
+			fmt.Fprintln(out, "No sessions found.")
+		}
+		return 0
+	}
+	end := min(start+plainPageLen, len(list))
+	if start == 0 {
+		if query != "" {
+			fmt.Fprintf(out, "%d sessions match %q.\n", len(list), query)
+		} else {
+			fmt.Fprintf(out, "%d sessions.\n", len(list))
+		}
+	}
+	for i := start; i < end; i++ {
+		sess := list[i]
+		fmt.Fprintf(out, "%d. %s, updated %s", i+1, sess.ID, formatTimestamp(sess.UpdatedAt))
+		if sess.WorkingDir != "" {
+			fmt.Fprintf(out, ", in %s", sess.WorkingDir)
+		}
+		if sess.LastAction != "" {
+			fmt.Fprintf(out, ". %s", strings.Join(strings.Fields(sess.LastAction), " "))
+		}
+		fmt.Fprintln(out)
+	}
+	if end < len(list) {
+		fmt.Fprintf(out, "Showing %d to %d of %d; press Enter for more.\n", start+1, end, len(list))
+	}
+	return end
+}
+
+// plainFilter returns the sessions whose ID, directory, model or last action contain every word
+// of query, ignoring case.
+func plainFilter(list []sessions.Session, query string) []sessions.Session {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return list
+	}
+	var out []sessions.Session
+	for _, sess := range list {
+		text := strings.ToLower(strings.Join([]string{sess.ID, sess.WorkingDir, sess.Model, sess.LastAction}, "\n"))
+		match := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, sess)
+		}
+	}
+	return out
+}
+
+// collectStream runs stream with load to completion and returns the sessions it reported,
+// keeping the latest version of each.
+func collectStream(stream func(ctx context.Context, opts sessions.Options, emit func(sessions.Session)) error, load sessions.Options) ([]sessions.Session, error) {
+	index := make(map[string]int)
+	var list []sessions.Session
+	err := stream(context.Background(), load, func(sess sessions.Session) {
+		if i, ok := index[sess.ID]; ok {
+			list[i] = sess
+			return
+		}
+		index[sess.ID] = len(list)
+		list = append(list, sess)
+	})
+	return list, err
+}
@@ -0,0 +1,366 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// preview shows the transcript of the highlighted session next to the list. Rows can be
+// range-selected and exported as Markdown.
+type preview struct {
+	table *tview.Table
+	// pane stacks the note of the session, when it has one, and the activity timeline above the
+	// transcript table.
+	pane      *tview.Flex
+	note      *tview.TextView
+	timeline  *tview.Box
+	theme     theme
+	visible   bool
+	focused   bool
+	sessionID string
+	session   sessions.Session
+	entries   []sessions.TranscriptEntry
+	anchor    int
+	// query is the active transcript search, matched case-insensitively.
+	query string
+	// bookmarks holds the indexes of bookmarked entries.
+	bookmarks map[int]bool
+	// redactor masks secrets in the loaded transcript; nil shows it as recorded.
+	redactor *sessions.Redactor
+}
+
+func newPreview(t theme, redactor *sessions.Redactor) *preview {
+	p := &preview{
+		table: tview.NewTable().
+			SetSelectable(true, false),
+		theme:    t,
+		anchor:   -1,
+		redactor: redactor,
+	}
+	p.table.SetBorder(true).SetTitle(" Preview ")
+	p.table.SetSelectedStyle(t.selectedStyle())
+	p.table.SetSelectionChangedFunc(func(row, column int) {
+		p.paint()
+	})
+	p.note = tview.NewTextView().SetWrap(true).SetWordWrap(true)
+	p.note.SetBorder(true).SetTitle(" Note ")
+	p.timeline = p.newTimeline()
+	p.pane = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p.note, 0, 0, false).
+		AddItem(p.timeline, 0, 0, false).
+		AddItem(p.table, 0, 1, true)
+	return p
+}
+
+// load reads the transcript of sess unless it is already displayed.
+func (p *preview) load(sess sessions.Session) error {
+	if p.sessionID == sess.ID && p.entries != nil {
+		return nil
+	}
+	p.sessionID = sess.ID
+	p.session = sess
+	p.anchor = -1
+	entries, err := sessions.ReadTranscript(sess)
+	if err != nil {
+		p.entries = []sessions.TranscriptEntry{}
+		p.table.Clear()
+		p.table.SetCell(0, 0, tview.NewTableCell(err.Error()).SetSelectable(false))
+		p.showTimeline()
+		return err
+	}
+	p.entries = p.redactor.Entries(entries)
+	p.table.Clear()
+	for i, entry := range p.entries {
+		p.table.SetCell(i, 0, tview.NewTableCell(formatClock(entry)))
+		p.table.SetCell(i, 1, tview.NewTableCell(entry.Heading()).SetTextColor(p.theme.roleColor(entry.Role)))
+		p.table.SetCell(i, 2, tview.NewTableCell(truncateText(strings.Join(strings.Fields(entry.Text), " "), 200)).
+			SetExpansion(1))
+	}
+	p.table.Select(0, 0)
+	p.table.ScrollToBeginning()
+	p.showTimeline()
+	return nil
+}
+
+func (p *preview) clear() {
+	p.sessionID = ""
+	p.entries = nil
+	p.bookmarks = nil
+	p.anchor = -1
+	p.table.Clear()
+	p.showTimeline()
+}
+
+// selectedRange returns the inclusive row range covered by the current selection.
+func (p *preview) selectedRange() (int, int) {
+	cursor, _ := p.table.GetSelection()
+	if p.anchor < 0 {
+		return cursor, cursor
+	}
+	if p.anchor < cursor {
+		return p.anchor, cursor
+	}
+	return cursor, p.anchor
+}
+
+func (p *preview) paint() {
+	from, to := p.selectedRange()
+	_, rangeBg, rangeAttrs := p.theme.rangeStyle().Decompose()
+	for i := range p.entries {
+		bg, attrs := p.theme.background, tcell.AttrNone
+		if p.anchor >= 0 && i >= from && i <= to {
+			bg, attrs = rangeBg, rangeAttrs
+		}
+		for col := 0; col < p.table.GetColumnCount(); col++ {
+			if cell := p.table.GetCell(i, col); cell != nil {
+				cell.SetBackgroundColor(bg).SetAttributes(attrs)
+			}
+		}
+	}
+	switch {
+	case p.anchor >= 0:
+		p.table.SetTitle(fmt.Sprintf(" Preview — selecting %d-%d ", from+1, to+1))
+	case p.query != "":
+		p.table.SetTitle(fmt.Sprintf(" Preview — /%s ", p.query))
+	default:
+		p.table.SetTitle(" Preview ")
+	}
+}
+
+func (p *preview) matchesAt(i int) bool {
+	query := strings.ToLower(p.query)
+	entry := p.entries[i]
+	return strings.Contains(strings.ToLower(entry.Text), query) ||
+		strings.Contains(strings.ToLower(entry.Heading()), query)
+}
+
+// findMatch returns the next entry matching the search query in direction step (1 or -1),
+// starting after the cursor and wrapping around.
+func (p *preview) findMatch(step int) (int, bool) {
+	n := len(p.entries)
+	if p.query == "" || n == 0 {
+		return -1, false
+	}
+	cursor, _ := p.table.GetSelection()
+	for k := 1; k <= n; k++ {
+		i := ((cursor+step*k)%n + n) % n
+		if p.matchesAt(i) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// matchPosition returns the 1-based rank of entry i among all matches and the match count.
+func (p *preview) matchPosition(i int) (int, int) {
+	pos, total := 0, 0
+	for j := range p.entries {
+		if p.matchesAt(j) {
+			total++
+			if j <= i {
+				pos = total
+			}
+		}
+	}
+	return pos, total
+}
+
+// findRole returns the first (or last) entry with the given role.
+func (p *preview) findRole(role string, last bool) (int, bool) {
+	for k := range p.entries {
+		i := k
+		if last {
+			i = len(p.entries) - 1 - k
+		}
+		if p.entries[i].Role == role {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (p *preview) toggleAnchor() {
+	if p.anchor >= 0 {
+		p.anchor = -1
+	} else {
+		p.anchor, _ = p.table.GetSelection()
+	}
+	p.paint()
+}
+
+// exportRange returns the file name in the current directory the selected entries are exported
+// to, and the entries.
+func (p *preview) exportRange() (string, []sessions.TranscriptEntry, error) {
+	if len(p.entries) == 0 {
+		return "", nil, fmt.Errorf("nothing to export")
+	}
+	from, to := p.selectedRange()
+	if from < 0 || to >= len(p.entries) {
+		return "", nil, fmt.Errorf("selection out of range")
+	}
+	path := fmt.Sprintf("%s-%d-%d.md", p.session.ID, from+1, to+1)
+	return path, p.entries[from : to+1], nil
+}
+
+func (m *model) togglePreview() {
+	m.preview.visible = !m.preview.visible
+	if m.preview.visible {
+		m.body.ResizeItem(m.preview.pane, 0, 1)
+		m.syncPreview()
+		return
+	}
+	m.body.ResizeItem(m.preview.pane, 0, 0)
+	m.blurPreview()
+}
+
+func (m *model) focusPreview() {
+	if !m.preview.visible {
+		m.togglePreview()
+	}
+	m.preview.focused = true
+	m.app.SetFocus(m.preview.table)
+}
+
+// inPreview wraps a preview action so it focuses the preview first when run from the palette.
+func (m *model) inPreview(fn func()) func() {
+	return func() {
+		m.focusPreview()
+		fn()
+	}
+}
+
+func (m *model) blurPreview() {
+	m.preview.focused = false
+	m.preview.anchor = -1
+	m.preview.paint()
+	m.app.SetFocus(m.table)
+}
+
+// syncPreview loads the highlighted session into the preview when it is visible.
+func (m *model) syncPreview() {
+	if m.preview == nil || !m.preview.visible {
+		return
+	}
+	if len(m.filtered) == 0 {
+		m.preview.clear()
+		m.preview.setNote("")
+		return
+	}
+	sess, _ := m.selectedSession()
+	m.preview.setNote(m.noteText(sess.ID))
+	if err := m.preview.load(sess); err != nil {
+		m.setStatus(fmt.Sprintf("Preview failed: %v", err))
+		return
+	}
+	m.loadBookmarks()
+}
+
+func (m *model) handlePreviewEvent(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyTab, tcell.KeyEsc:
+		m.blurPreview()
+		return nil
+	case tcell.KeyCtrlC:
+		m.quit()
+		return nil
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'v', ' ':
+			m.preview.toggleAnchor()
+		case 'y':
+			m.exportPreviewRange()
+		case '/':
+			m.searchPreview()
+		case 'n':
+			m.jumpToMatch(1)
+		case 'N':
+			m.jumpToMatch(-1)
+		case '[':
+			m.jumpToUserMessage(false)
+		case ']':
+			m.jumpToUserMessage(true)
+		case 'm':
+			m.toggleBookmark()
+		case '\'':
+			m.jumpToBookmark()
+		case 'd':
+			m.showEntryPatch()
+		}
+		return nil
+	}
+	return event
+}
+
+// searchPreview prompts for a transcript search and jumps to the first match after the cursor.
+func (m *model) searchPreview() {
+	m.prompt(" Search transcript ", m.preview.query, m.preview.table, func(text string) {
+		m.preview.query = strings.TrimSpace(text)
+		m.preview.paint()
+		if m.preview.query == "" {
+			m.setStatus("")
+			return
+		}
+		m.jumpToMatch(1)
+	})
+}
+
+func (m *model) jumpToMatch(step int) {
+	if m.preview.query == "" {
+		m.setStatus("No transcript search (press / to search)")
+		return
+	}
+	i, ok := m.preview.findMatch(step)
+	if !ok {
+		m.setStatus(fmt.Sprintf("No matches for %q", m.preview.query))
+		return
+	}
+	m.preview.table.Select(i, 0)
+	pos, total := m.preview.matchPosition(i)
+	m.setStatus(fmt.Sprintf("Match %d of %d for %q", pos, total, m.preview.query))
+}
+
+func (m *model) jumpToUserMessage(last bool) {
+	i, ok := m.preview.findRole("user", last)
+	if !ok {
+		m.setStatus("No user messages in this session")
+		return
+	}
+	m.preview.table.Select(i, 0)
+}
+
+// exportPreviewRange writes the selected preview range (or the highlighted entry) as Markdown.
+func (m *model) exportPreviewRange() {
+	if !m.preview.visible {
+		m.togglePreview()
+	}
+	path, entries, err := m.preview.exportRange()
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	sess := m.preview.session
+	write := func(w io.Writer) error {
+		return sessions.WriteMarkdown(w, sess, entries)
+	}
+	m.saveFile(path, m.app.GetFocus(), write, func(err error) {
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Export failed: %v", err))
+			return
+		}
+		m.preview.anchor = -1
+		m.preview.paint()
+		m.setStatus(fmt.Sprintf("Exported to %s", path))
+	})
+}
+
+func formatClock(entry sessions.TranscriptEntry) string {
+	if entry.Timestamp.IsZero() {
+		return "--:--"
+	}
+	return entry.Timestamp.Local().Format("15:04:05")
+}
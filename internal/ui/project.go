@@ -0,0 +1,17 @@
+package ui
+
+import "strings"
+
+// projectMark flags sessions listed in the project's .codex-sessions file.
+const projectMark = "⌂"
+
+// inProject reports whether id matches one of the IDs or prefixes of the project file.
+func (m *model) inProject(id string) bool {
+	id = strings.ToLower(id)
+	for _, prefix := range m.project {
+		if strings.HasPrefix(id, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
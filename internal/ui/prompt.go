@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const promptPage = "prompt"
+
+// prompt opens a one-line input overlay pre-filled with initial. Enter closes it and calls done
+// with the entered text; Esc closes it without calling done. Focus then returns to back.
+func (m *model) prompt(title, initial string, back tview.Primitive, done func(text string)) {
+	input := tview.NewInputField().
+		SetLabel("> ").
+		SetText(initial).
+		SetFieldWidth(0).
+		SetFieldBackgroundColor(m.theme.background).
+		SetFieldTextColor(m.theme.text)
+	input.SetDoneFunc(func(key tcell.Key) {
+		m.pages.RemovePage(promptPage)
+		m.app.SetFocus(back)
+		if key == tcell.KeyEnter {
+			done(input.GetText())
+		}
+	})
+	input.SetBorder(true).SetTitle(title)
+	m.openOverlay(promptPage, centered(input, 60, 3), input)
+}
+
+// confirm asks question in a prompt and calls yes when the answer is "y" or "yes"; any other
+// answer shows declined in the status line.
+func (m *model) confirm(question, declined string, back tview.Primitive, yes func()) {
+	m.prompt(" "+question+" (y/N) ", "", back, func(text string) {
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "y", "yes":
+			yes()
+		default:
+			m.setStatus(declined)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"github.com/rivo/tview"
+)
+
+const quickActionsPage = "quick-actions"
+
+// readOnlyArgs resume a session in Codex's read-only sandbox.
+var readOnlyArgs = []string{"--sandbox", "read-only"}
+
+// openSelected runs when a session is opened with Enter or a double click: it resumes the
+// session, or offers the quick actions menu first when Options.EnterMenu is set. Pickers that
+// only print the selection skip the menu, since nothing is resumed.
+func (m *model) openSelected() {
+	if m.enterMenu && !m.multiSelect {
+		m.quickActions()
+		return
+	}
+	m.resumeSelected()
+}
+
+// quickActions offers what can be done with the highlighted session, each with a shortcut key.
+func (m *model) quickActions() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	items := []struct {
+		name     string
+		shortcut rune
+		run      func()
+	}{
+		{"Resume", 'r', m.resumeSelected},
+		{"Resume read-only (--sandbox read-only)", 'R', m.resumeReadOnly},
+		{"View transcript", 'v', m.viewTranscript},
+		{"Export as Markdown", 'e', m.exportSelected},
+		{"Copy ID", 'y', m.copySelectedID},
+		{"Delete (to trash)", 'd', m.deleteAndRefresh},
+		{"Archive", 'a', m.archiveSelected},
+	}
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(m.theme.selectedStyle())
+	for _, item := range items {
+		list.AddItem(item.name, "", item.shortcut, nil)
+	}
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		m.closeOverlay(quickActionsPage)
+		items[index].run()
+	})
+	list.SetDoneFunc(func() {
+		m.closeOverlay(quickActionsPage)
+	})
+	list.SetBorder(true).SetTitle(" " + truncateText(sess.ID, 40) + " ")
+	m.openOverlay(quickActionsPage, centered(list, 48, len(items)+2), list)
+}
+
+// resumeReadOnly resumes the highlighted session in the read-only sandbox.
+func (m *model) resumeReadOnly() {
+	m.resumeWith(readOnlyArgs)
+}
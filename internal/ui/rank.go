@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"math"
+	"time"
+)
+
+// Ranking weighs the components of a search result's score. Results are ordered by
+//
+//	Relevance*relevance + Recency*recency + Pinned*pinned
+//
+// where relevance falls from 1 towards 0 as the fuzzy distance grows relative to the query
+// length, recency halves every HalfLife since the last update, and pinned is 1 for pinned
+// sessions.
+type Ranking struct {
+	Relevance float64
+	Recency   float64
+	HalfLife  time.Duration
+	Pinned    float64
+}
+
+// DefaultRanking orders results by fuzzy distance, then recency, with pinned sessions first.
+func DefaultRanking() Ranking {
+	return Ranking{Relevance: 1, HalfLife: 7 * 24 * time.Hour, Pinned: 10}
+}
+
+func (r Ranking) score(distance, queryLen int, age time.Duration, pinned bool) float64 {
+	relevance := 1 / (1 + float64(distance)/float64(max(queryLen, 1)))
+	score := r.Relevance * relevance
+	if r.Recency != 0 && r.HalfLife > 0 {
+		score += r.Recency * math.Exp2(-max(age, 0).Hours()/r.HalfLife.Hours())
+	}
+	if pinned {
+		score += r.Pinned
+	}
+	return score
+}
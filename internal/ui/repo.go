@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// toggleRepoFilter limits the list to sessions started inside the git repository containing the
+// current directory, or shows every session again.
+func (m *model) toggleRepoFilter() {
+	if m.repoOnly {
+		m.repoOnly = false
+		m.invalidateFilter()
+		m.applyFilter()
+		m.refresh()
+		m.setStatus("Showing sessions of every directory")
+		return
+	}
+	if m.repoRoot == "" {
+		cwd, err := os.Getwd()
+		if err == nil {
+			m.repoRoot = gitRoot(cwd)
+		}
+		if m.repoRoot == "" {
+			m.setStatus("The current directory is not inside a git repository")
+			return
+		}
+	}
+	m.repoOnly = true
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+	m.setStatus("Showing sessions in " + m.repoRoot + " (press . again for all)")
+}
+
+// gitRoot returns the nearest directory at or above dir that contains .git (a directory, or a
+// file for worktrees and submodules), or "" when there is none.
+func gitRoot(dir string) string {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// pathWithin reports whether path is dir or below it, ignoring case on Windows where the file
+// system does.
+func pathWithin(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	path, dir = filepath.Clean(strings.TrimSpace(path)), filepath.Clean(dir)
+	if runtime.GOOS == "windows" {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
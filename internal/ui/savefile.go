@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/rivo/tview"
+)
+
+// saveFile writes path with write and calls done with the outcome. An existing file is never
+// truncated unasked: the user is asked whether to replace it first, and done is not called when
+// they decline. Focus returns to back after the question.
+func (m *model) saveFile(path string, back tview.Primitive, write func(w io.Writer) error, done func(err error)) {
+	err := writeFile(path, false, write)
+	if !errors.Is(err, fs.ErrExist) {
+		done(err)
+		return
+	}
+	m.confirm(fmt.Sprintf("%s exists; overwrite it?", path), fmt.Sprintf("Kept %s; nothing was written", path), back, func() {
+		done(writeFile(path, true, write))
+	})
+}
+
+// writeFile creates the file at path and fills it with write. Without overwrite an existing file
+// is left alone and an error wrapping fs.ErrExist is returned.
+func writeFile(path string, overwrite bool, write func(w io.Writer) error) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
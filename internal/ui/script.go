@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const scriptPage = "script"
+
+// showScript collects the shell commands of the highlighted session into a sh script in an
+// editable text area. Ctrl+S writes it to a file, Ctrl+E continues editing in $EDITOR and Esc
+// closes it.
+func (m *model) showScript() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	commands, err := sessions.ReadShellCommands(sess)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Reading shell commands failed: %v", err))
+		return
+	}
+	if len(commands) == 0 {
+		m.setStatus(fmt.Sprintf("%s ran no shell commands", sess.ID))
+		return
+	}
+	var b strings.Builder
+	if err := sessions.WriteShellScript(&b, sess, commands); err != nil {
+		m.setStatus(fmt.Sprintf("Building the script failed: %v", err))
+		return
+	}
+	area := tview.NewTextArea().
+		SetText(m.redactor.Redact(b.String()), false).
+		SetTextStyle(tcell.StyleDefault.Background(m.theme.background).Foreground(m.theme.text))
+	area.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlS:
+			m.prompt(" Write the script to ", sess.ID+".sh", area, func(path string) {
+				m.writeScript(strings.TrimSpace(path), area.GetText())
+			})
+			return nil
+		case tcell.KeyEsc:
+			m.closeOverlay(scriptPage)
+			return nil
+		case tcell.KeyCtrlE:
+			text, err := m.editInEditor(area.GetText(), ".sh")
+			if err != nil {
+				m.setStatus(fmt.Sprintf("Editor failed: %v", err))
+				return nil
+			}
+			area.SetText(text, false)
+			return nil
+		}
+		return event
+	})
+	area.SetBorder(true).SetTitle(fmt.Sprintf(" %d shell commands of %s — Ctrl+S writes a file, Ctrl+E opens $EDITOR, Esc closes ",
+		len(commands), truncateText(sess.ID, 20)))
+	m.openOverlay(scriptPage, area, area)
+}
+
+// writeScript writes an executable script to path and closes the script overlay.
+func (m *model) writeScript(path, text string) {
+	if path == "" {
+		m.setStatus("No file name given; the script was not written")
+		return
+	}
+	if err := os.WriteFile(path, []byte(text), 0o755); err != nil {
+		m.setStatus(fmt.Sprintf("Writing the script failed: %v", err))
+		return
+	}
+	m.closeOverlay(scriptPage)
+	m.setStatus(fmt.Sprintf("Wrote the script to %s", path))
+}
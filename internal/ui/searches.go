@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// recallSearch lists the saved searches and applies the one picked.
+func (m *model) recallSearch() {
+	if len(m.savedSearches) == 0 {
+		m.setStatus("No saved searches (Ctrl+S saves the current query)")
+		return
+	}
+	names := make([]string, 0, len(m.savedSearches))
+	for name := range m.savedSearches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = runewidth.FillRight(name, 20) + " " + m.savedSearches[name]
+	}
+	m.pick(" Saved searches ", items, func(index int) {
+		m.setQuery(m.savedSearches[names[index]])
+		m.setStatus(fmt.Sprintf("Search %q", names[index]))
+	})
+}
+
+// saveSearch asks for a name and saves the current query under it.
+func (m *model) saveSearch() {
+	query := strings.TrimSpace(m.query)
+	if query == "" {
+		m.setStatus("Type a search query first")
+		return
+	}
+	m.prompt(" Save search as ", "", m.table, func(text string) {
+		name := strings.TrimSpace(text)
+		if name == "" {
+			return
+		}
+		if m.saveSearchFunc != nil {
+			if err := m.saveSearchFunc(name, query); err != nil {
+				m.setStatus(fmt.Sprintf("Save search: %v", err))
+				return
+			}
+		}
+		if m.savedSearches == nil {
+			m.savedSearches = make(map[string]string)
+		}
+		m.savedSearches[name] = query
+		m.setStatus(fmt.Sprintf("Saved search %q (F4 recalls it)", name))
+	})
+}
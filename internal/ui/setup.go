@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Setup describes the environment shown by the first-run screen.
+type Setup struct {
+	// SessionsDir is the sessions root that does not exist yet.
+	SessionsDir string
+	// CodexPath is where the codex binary was found, empty when it is not on PATH.
+	CodexPath string
+	// ConfigPath is the configuration file location; ConfigExists reports whether it is there.
+	ConfigPath   string
+	ConfigExists bool
+}
+
+// SetupChoice is what the user picked on the first-run screen.
+type SetupChoice struct {
+	CreateDir   bool
+	WriteConfig bool
+	// Continue opens the session list afterwards; false means the user quit.
+	Continue bool
+}
+
+// RunSetup shows the first-run screen, explaining where Codex keeps its sessions and offering to
+// create the directory and a config file. The caller performs the chosen actions.
+func RunSetup(s Setup) (SetupChoice, error) {
+	choice := SetupChoice{CreateDir: true, WriteConfig: !s.ConfigExists && s.ConfigPath != ""}
+	app := tview.NewApplication()
+
+	info := tview.NewTextView().SetWrap(true).SetText(setupText(s))
+	form := tview.NewForm().
+		AddCheckbox("Create the sessions directory", choice.CreateDir, func(checked bool) {
+			choice.CreateDir = checked
+		})
+	if choice.WriteConfig {
+		form.AddCheckbox("Write an initial config file", true, func(checked bool) {
+			choice.WriteConfig = checked
+		})
+	}
+	form.AddButton("Continue", func() {
+		choice.Continue = true
+		app.Stop()
+	}).AddButton("Quit", app.Stop)
+	form.SetCancelFunc(app.Stop)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(info, strings.Count(info.GetText(false), "\n")+2, 0, false).
+		AddItem(form, 0, 1, true)
+	layout.SetBorder(true).SetTitle(" codex-sessions setup ")
+
+	if err := app.SetRoot(centered(layout, 90, 24), true).Run(); err != nil {
+		return SetupChoice{}, err
+	}
+	if !choice.Continue {
+		return SetupChoice{}, nil
+	}
+	return choice, nil
+}
+
+func setupText(s Setup) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "No Codex sessions directory was found at\n  %s\n\n", s.SessionsDir)
+	fmt.Fprintf(&b, "Codex CLI writes one log per session to\n  %s\n", filepath.Join(s.SessionsDir, "YYYY", "MM", "DD", "rollout-<time>-<id>.jsonl"))
+	b.WriteString("Point --sessions-dir or CODEX_HOME elsewhere if your logs live in another place.\n\n")
+	if s.CodexPath != "" {
+		fmt.Fprintf(&b, "Codex CLI: %s\n", s.CodexPath)
+	} else {
+		b.WriteString("Codex CLI: not found on PATH; set --codex-bin or resume_cmd\n")
+	}
+	switch {
+	case s.ConfigPath == "":
+		b.WriteString("Config file: unavailable\n")
+	case s.ConfigExists:
+		fmt.Fprintf(&b, "Config file: %s\n", s.ConfigPath)
+	default:
+		fmt.Fprintf(&b, "Config file: %s (not created yet)\n", s.ConfigPath)
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type sortMode int
+
+const (
+	sortUpdated sortMode = iota
+	sortCreated
+	sortDirectory
+	sortID
+	sortMessages
+	sortDuration
+	sortModel
+	sortRoot
+	sortCost
+	sortFrecency
+	sortChanges
+	sortHost
+	sortModeCount
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortCreated:
+		return "created"
+	case sortDirectory:
+		return "directory"
+	case sortID:
+		return "id"
+	case sortMessages:
+		return "messages"
+	case sortDuration:
+		return "duration"
+	case sortModel:
+		return "model"
+	case sortRoot:
+		return "root"
+	case sortCost:
+		return "cost"
+	case sortFrecency:
+		return "frecency"
+	case sortChanges:
+		return "changes"
+	case sortHost:
+		return "host"
+	default:
+		return "updated"
+	}
+}
+
+// parseSortMode returns the sort mode named name, as shown in the info line. An empty name is
+// the default order.
+func parseSortMode(name string) (sortMode, bool) {
+	if name == "" {
+		return sortUpdated, true
+	}
+	for mode := sortUpdated; mode < sortModeCount; mode++ {
+		if strings.EqualFold(mode.String(), name) {
+			return mode, true
+		}
+	}
+	return sortUpdated, false
+}
+
+// sortFiltered orders the unranked (empty query) result set by the active sort mode.
+func (m *model) sortFiltered() {
+	sort.SliceStable(m.filtered, func(i, j int) bool {
+		return m.lessEntries(m.filtered[i], m.filtered[j])
+	})
+}
+
+func (m *model) lessEntries(i, j int) bool {
+	a, b := m.entries[i].session, m.entries[j].session
+	switch m.sortMode {
+	case sortCreated:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	case sortDirectory:
+		if da, db := strings.ToLower(a.WorkingDir), strings.ToLower(b.WorkingDir); da != db {
+			return da < db
+		}
+	case sortID:
+		return a.ID < b.ID
+	case sortMessages:
+		if a.Messages() != b.Messages() {
+			return a.Messages() > b.Messages()
+		}
+	case sortDuration:
+		if a.Duration() != b.Duration() {
+			return a.Duration() > b.Duration()
+		}
+	case sortModel:
+		if ma, mb := strings.ToLower(a.Model), strings.ToLower(b.Model); ma != mb {
+			return ma < mb
+		}
+	case sortRoot:
+		if a.Root != b.Root {
+			return a.Root < b.Root
+		}
+	case sortHost:
+		if ha, hb := strings.ToLower(a.Host), strings.ToLower(b.Host); ha != hb {
+			return ha < hb
+		}
+	case sortCost:
+		// Sessions without a price sort after every priced one.
+		ca, okA := m.prices.Cost(a)
+		cb, okB := m.prices.Cost(b)
+		if okA != okB {
+			return okA
+		}
+		if ca != cb {
+			return ca > cb
+		}
+	case sortFrecency:
+		if m.meta != nil {
+			now := time.Now()
+			if fa, fb := m.meta.Get(a.ID).Frecency(now), m.meta.Get(b.ID).Frecency(now); fa != fb {
+				return fa > fb
+			}
+		}
+	case sortChanges:
+		if ca, cb := a.Patches.Added+a.Patches.Removed, b.Patches.Added+b.Patches.Removed; ca != cb {
+			return ca > cb
+		}
+	}
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func (m *model) cycleSort() {
+	m.setSort((m.sortMode + 1) % sortModeCount)
+}
+
+func (m *model) setSort(mode sortMode) {
+	m.sortMode = mode
+	m.applyFilter()
+	m.refresh()
+	m.setStatus("Sorted by " + m.sortMode.String())
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package ui
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// suspend hands the terminal back to the shell and stops the process group, as Ctrl+Z does in
+// a line-oriented program. The UI is redrawn when the shell continues it with fg.
+func (m *model) suspend() {
+	m.app.Suspend(func() {
+		// SIGSTOP rather than SIGTSTP: the latter is caught by watchSuspend.
+		_ = syscall.Kill(0, syscall.SIGSTOP)
+	})
+	m.refresh()
+}
+
+// watchSuspend suspends the UI cleanly when SIGTSTP arrives from outside, e.g. `kill -TSTP`,
+// instead of leaving the terminal in raw mode. It stops watching when ctx is done.
+func (m *model) watchSuspend(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTSTP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				m.app.QueueUpdateDraw(m.suspend)
+			}
+		}
+	}()
+}
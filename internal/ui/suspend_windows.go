@@ -0,0 +1,10 @@
+package ui
+
+import "context"
+
+// suspend is not available on Windows, which has no job control.
+func (m *model) suspend() {
+	m.setStatus("Suspending is not supported on Windows")
+}
+
+func (m *model) watchSuspend(ctx context.Context) {}
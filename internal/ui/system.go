@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"log/slog"
+	"os/exec"
+	"runtime"
+)
+
+// openPath opens path with the platform's default file manager or handler.
+func openPath(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("explorer", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	slog.Info("exec", "argv", cmd.Args)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// sessionTable is the virtual content of the session table. tview asks for the cells of the rows
+// it is about to draw, so only the visible window is ever built and refreshing after a keystroke
+// costs the same with ten or ten thousand matches.
+type sessionTable struct {
+	tview.TableContentReadOnly
+	m *model
+}
+
+func (t sessionTable) GetRowCount() int {
+	return len(t.m.filtered)*t.m.rowLines() + 1
+}
+
+func (t sessionTable) GetColumnCount() int {
+	return len(t.m.visibleCols)
+}
+
+// GetCell maps the displayed column to the table column; columns dropped by layoutColumns are
+// left out.
+func (t sessionTable) GetCell(row, column int) *tview.TableCell {
+	if column < 0 || column >= len(t.m.visibleCols) {
+		return nil
+	}
+	column = t.m.visibleCols[column]
+	var cell *tview.TableCell
+	switch {
+	case row == 0:
+		cell = t.m.headerCell(column)
+	case row < t.GetRowCount():
+		pos, line := t.m.filteredAt(row)
+		idx := t.m.filtered[pos]
+		cell = t.m.sessionCell(idx, column, line)
+		if style, ok := t.m.rowStyle(t.m.entries[idx].session); ok && cell != nil {
+			cell.SetStyle(style)
+		}
+		// The table highlights a single row; the other line of the selected session follows it.
+		if t.m.twoLineRows && pos == t.m.selected && cell != nil {
+			cell.SetStyle(t.m.theme.selectedStyle())
+		}
+	}
+	if cell != nil {
+		cell.SetMaxWidth(t.m.colWidths[column])
+	}
+	return cell
+}
+
+// columnSpecs returns the sizing of every table column: the base columns, the optional ones and
+// the metadata provider ones, in table order.
+func (m *model) columnSpecs() []columnSpec {
+	specs := []columnSpec{
+		{min: 16, max: 16, priority: 3},            // Updated
+		{min: 12, max: 48, weight: 1, priority: 5}, // Session ID with badges and file count
+		{min: 12, max: 60, weight: 2, priority: 2}, // Directory
+		{min: 16, weight: 4, priority: 4},          // Last Action, or the last messages
+	}
+	if m.actionWidth > 0 {
+		specs[3].min = min(specs[3].min, m.actionWidth)
+		specs[3].max = m.actionWidth
+	}
+	for _, col := range m.columns {
+		width := max(col.width, len(col.title))
+		specs = append(specs, columnSpec{min: width, max: width, priority: 1})
+	}
+	for range m.extraCols {
+		specs = append(specs, columnSpec{min: 8, max: 24, weight: 1})
+	}
+	return specs
+}
+
+// layoutColumns sizes the table columns for a table width, dropping the least important ones
+// when they do not fit. It runs on every draw and only recomputes after a resize or a change of
+// columns.
+func (m *model) layoutColumns(width int) {
+	specs := m.columnSpecs()
+	if width == m.layoutWidth && len(specs) == len(m.colWidths) {
+		return
+	}
+	m.layoutWidth = width
+	m.colWidths = allocateWidths(specs, width)
+	m.visibleCols = m.visibleCols[:0]
+	for i, w := range m.colWidths {
+		if w > 0 {
+			m.visibleCols = append(m.visibleCols, i)
+		}
+	}
+}
+
+// rowLines returns the number of table rows each session takes.
+func (m *model) rowLines() int {
+	if m.twoLineRows {
+		return 2
+	}
+	return 1
+}
+
+// tableRow returns the table row of the first line of the session at filtered position pos.
+func (m *model) tableRow(pos int) int {
+	return pos*m.rowLines() + 1
+}
+
+// filteredAt returns the filtered position of the session shown on a table row below the
+// header, and which of its lines the row is.
+func (m *model) filteredAt(row int) (pos, line int) {
+	return (row - 1) / m.rowLines(), (row - 1) % m.rowLines()
+}
+
+// userMark and assistantMark lead the last messages of two-line rows.
+const (
+	userMark      = "›"
+	assistantMark = "‹"
+)
+
+// messageLine prefixes a last message of a two-line row with mark, or returns "" without one.
+func messageLine(mark, text string) string {
+	if text == "" {
+		return ""
+	}
+	return mark + " " + text
+}
+
+// failedMark flags sessions whose last entry was a failure; see sessions.EndState.
+const failedMark = "‼"
+
+// rowStyle returns the style of a session's row: sessions that ended with an error, sessions in
+// use and sessions not updated within m.dimAfter stand out from the rest.
+func (m *model) rowStyle(sess sessions.Session) (tcell.Style, bool) {
+	stale := m.dimAfter > 0 && time.Since(sess.UpdatedAt) > m.dimAfter
+	return m.theme.rowStyle(sess.EndState.Failed(), m.isActive(sess), stale)
+}
+
+func (m *model) headerCell(column int) *tview.TableCell {
+	cell := tview.NewTableCell("").
+		SetSelectable(false).
+		SetStyle(m.theme.headerStyle())
+	switch {
+	case column < baseColumns:
+		cell.SetText([baseColumns]string{"Updated", "Session ID", "Directory", "Last Action"}[column])
+		if column == 3 && m.twoLineRows {
+			cell.SetText("Last Messages")
+		}
+	case column < baseColumns+len(m.columns):
+		col := m.columns[column-baseColumns]
+		cell.SetText(col.title).SetAlign(col.align())
+	case column < baseColumns+len(m.columns)+len(m.extraCols):
+		cell.SetText(m.extraCols[column-baseColumns-len(m.columns)])
+	default:
+		return nil
+	}
+	return cell
+}
+
+// sessionCell returns the cell of a session's row in column. With two-line rows, line 0 holds
+// the session's fields and its last user message, and line 1 only its last assistant message.
+func (m *model) sessionCell(idx, column, line int) *tview.TableCell {
+	sess := m.entries[idx].session
+	width := m.colWidths[column]
+	if m.twoLineRows {
+		switch {
+		case column == 3 && line == 0:
+			return tview.NewTableCell(truncateText(messageLine(userMark, sess.LastUserMessage), width)).SetExpansion(2)
+		case column == 3:
+			return tview.NewTableCell(truncateText(messageLine(assistantMark, sess.LastAssistantMessage), width)).SetExpansion(2)
+		case line > 0:
+			return tview.NewTableCell("")
+		}
+	}
+	switch column {
+	case 0:
+		return tview.NewTableCell(formatTimestamp(sess.UpdatedAt)).SetExpansion(1)
+	case 1:
+		id := sess.ID
+		if badges := m.badges(sess); badges != "" {
+			id = badges + " " + id
+		}
+		if files := len(sess.FilePaths) + len(sess.SkippedFiles); files > 1 {
+			id += fmt.Sprintf(" (%d files)", files)
+		}
+		if n := m.earlierRollouts(sess.ID); n > 0 {
+			id += fmt.Sprintf(" (+%d)", n)
+		}
+		return tview.NewTableCell(truncateText(id, width)).SetExpansion(1)
+	case 2:
+		if m.dirMissing(sess) {
+			return tview.NewTableCell(missingDirMark + " " + abbreviatePath(sess.WorkingDir, width-2)).SetExpansion(1)
+		}
+		return tview.NewTableCell(abbreviatePath(sess.WorkingDir, width)).SetExpansion(1)
+	case 3:
+		return tview.NewTableCell(truncateText(sess.LastAction, width)).SetExpansion(2)
+	}
+	column -= baseColumns
+	if column < len(m.columns) {
+		col := m.columns[column]
+		return tview.NewTableCell(truncateText(col.value(m, sess), width)).SetAlign(col.align())
+	}
+	column -= len(m.columns)
+	if column < len(m.extraCols) {
+		return tview.NewTableCell(truncateText(m.entries[idx].extra[m.extraCols[column]], width)).SetExpansion(1)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// theme holds the colors used across the TUI. mono themes rely on attributes (bold, reverse)
+// instead of colors.
+type theme struct {
+	background  tcell.Color
+	text        tcell.Color
+	muted       tcell.Color
+	border      tcell.Color
+	header      tcell.Color
+	selectedFg  tcell.Color
+	selectedBg  tcell.Color
+	rangeBg     tcell.Color
+	prompt      tcell.Color
+	promptFocus tcell.Color
+	accent      tcell.Color
+	status      tcell.Color
+	// staleRow, activeRow and errorRow color the list rows of old sessions, sessions in use and
+	// sessions that ended with an error.
+	staleRow  tcell.Color
+	activeRow tcell.Color
+	errorRow  tcell.Color
+	mono      bool
+}
+
+var builtinThemes = map[string]theme{
+	"dark": {
+		background:  tcell.ColorDefault,
+		text:        tcell.ColorDefault,
+		muted:       tcell.ColorGray,
+		border:      tcell.ColorDefault,
+		header:      tcell.ColorDefault,
+		selectedFg:  tcell.ColorWhite,
+		selectedBg:  tcell.ColorBlue,
+		rangeBg:     tcell.ColorDarkSlateGray,
+		prompt:      tcell.ColorBlue,
+		promptFocus: tcell.ColorYellow,
+		accent:      tcell.ColorGreen,
+		status:      tcell.ColorDefault,
+		staleRow:    tcell.ColorGray,
+		activeRow:   tcell.ColorAqua,
+		errorRow:    tcell.ColorRed,
+	},
+	"light": {
+		background:  tcell.ColorDefault,
+		text:        tcell.ColorBlack,
+		muted:       tcell.ColorGray,
+		border:      tcell.ColorGray,
+		header:      tcell.ColorBlack,
+		selectedFg:  tcell.ColorWhite,
+		selectedBg:  tcell.ColorNavy,
+		rangeBg:     tcell.ColorLightGray,
+		prompt:      tcell.ColorNavy,
+		promptFocus: tcell.ColorDarkOrange,
+		accent:      tcell.ColorDarkGreen,
+		status:      tcell.ColorBlack,
+		staleRow:    tcell.ColorDarkGray,
+		activeRow:   tcell.ColorDarkCyan,
+		errorRow:    tcell.ColorMaroon,
+	},
+	"solarized": {
+		background:  tcell.NewHexColor(0x002b36),
+		text:        tcell.NewHexColor(0x839496),
+		muted:       tcell.NewHexColor(0x586e75),
+		border:      tcell.NewHexColor(0x586e75),
+		header:      tcell.NewHexColor(0xb58900),
+		selectedFg:  tcell.NewHexColor(0xfdf6e3),
+		selectedBg:  tcell.NewHexColor(0x268bd2),
+		rangeBg:     tcell.NewHexColor(0x073642),
+		prompt:      tcell.NewHexColor(0x268bd2),
+		promptFocus: tcell.NewHexColor(0xcb4b16),
+		accent:      tcell.NewHexColor(0x859900),
+		status:      tcell.NewHexColor(0x93a1a1),
+		staleRow:    tcell.NewHexColor(0x586e75),
+		activeRow:   tcell.NewHexColor(0x2aa198),
+		errorRow:    tcell.NewHexColor(0xdc322f),
+	},
+}
+
+var monoTheme = theme{
+	background:  tcell.ColorDefault,
+	text:        tcell.ColorDefault,
+	muted:       tcell.ColorDefault,
+	border:      tcell.ColorDefault,
+	header:      tcell.ColorDefault,
+	selectedFg:  tcell.ColorDefault,
+	selectedBg:  tcell.ColorDefault,
+	rangeBg:     tcell.ColorDefault,
+	prompt:      tcell.ColorDefault,
+	promptFocus: tcell.ColorDefault,
+	accent:      tcell.ColorDefault,
+	status:      tcell.ColorDefault,
+	staleRow:    tcell.ColorDefault,
+	activeRow:   tcell.ColorDefault,
+	errorRow:    tcell.ColorDefault,
+	mono:        true,
+}
+
+// basicPalette is used to approximate theme colors on terminals with fewer than 256 colors.
+var basicPalette = []tcell.Color{
+	tcell.ColorBlack, tcell.ColorMaroon, tcell.ColorGreen, tcell.ColorOlive,
+	tcell.ColorNavy, tcell.ColorPurple, tcell.ColorTeal, tcell.ColorSilver,
+	tcell.ColorGray, tcell.ColorRed, tcell.ColorLime, tcell.ColorYellow,
+	tcell.ColorBlue, tcell.ColorFuchsia, tcell.ColorAqua, tcell.ColorWhite,
+}
+
+// resolveTheme picks the named built-in theme, applies user color overrides and adapts the
+// result to the terminal: NO_COLOR or a colorless terminal yields the mono theme, and terminals
+// with fewer than 256 colors get the closest basic colors.
+func resolveTheme(name string, overrides map[string]string, colors int) (theme, error) {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || colors < 8 {
+		return monoTheme, nil
+	}
+	if name == "" {
+		name = "dark"
+	}
+	t, ok := builtinThemes[name]
+	if !ok {
+		return builtinThemes["dark"], fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+	for key, value := range overrides {
+		slot := t.slot(key)
+		if slot == nil {
+			return t, fmt.Errorf("unknown theme color %q", key)
+		}
+		color := tcell.GetColor(value)
+		if color == tcell.ColorDefault && value != "default" {
+			return t, fmt.Errorf("invalid color %q for %s", value, key)
+		}
+		*slot = color
+	}
+	if colors < 256 {
+		for _, slot := range t.slots() {
+			if *slot != tcell.ColorDefault {
+				*slot = tcell.FindColor(*slot, basicPalette)
+			}
+		}
+	}
+	return t, nil
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *theme) slot(key string) *tcell.Color {
+	switch key {
+	case "background":
+		return &t.background
+	case "text":
+		return &t.text
+	case "muted":
+		return &t.muted
+	case "border":
+		return &t.border
+	case "header":
+		return &t.header
+	case "selected_fg":
+		return &t.selectedFg
+	case "selected_bg":
+		return &t.selectedBg
+	case "range_bg":
+		return &t.rangeBg
+	case "prompt":
+		return &t.prompt
+	case "prompt_focus":
+		return &t.promptFocus
+	case "accent":
+		return &t.accent
+	case "status":
+		return &t.status
+	case "stale_row":
+		return &t.staleRow
+	case "active_row":
+		return &t.activeRow
+	case "error_row":
+		return &t.errorRow
+	default:
+		return nil
+	}
+}
+
+func (t *theme) slots() []*tcell.Color {
+	return []*tcell.Color{
+		&t.background, &t.text, &t.muted, &t.border, &t.header, &t.selectedFg, &t.selectedBg,
+		&t.rangeBg, &t.prompt, &t.promptFocus, &t.accent, &t.status, &t.staleRow, &t.activeRow,
+		&t.errorRow,
+	}
+}
+
+// apply installs the theme as the tview default styles. It must run before widgets are created.
+func (t theme) apply() {
+	tview.Styles.PrimitiveBackgroundColor = t.background
+	tview.Styles.ContrastBackgroundColor = t.background
+	tview.Styles.MoreContrastBackgroundColor = t.background
+	tview.Styles.BorderColor = t.border
+	tview.Styles.TitleColor = t.text
+	tview.Styles.GraphicsColor = t.border
+	tview.Styles.PrimaryTextColor = t.text
+	tview.Styles.SecondaryTextColor = t.accent
+	tview.Styles.TertiaryTextColor = t.header
+	tview.Styles.InverseTextColor = t.selectedFg
+	tview.Styles.ContrastSecondaryTextColor = t.accent
+}
+
+func (t theme) selectedStyle() tcell.Style {
+	if t.mono {
+		return tcell.StyleDefault.Reverse(true)
+	}
+	return tcell.StyleDefault.Background(t.selectedBg).Foreground(t.selectedFg)
+}
+
+func (t theme) headerStyle() tcell.Style {
+	return tcell.StyleDefault.Bold(true).Foreground(t.header).Background(t.background)
+}
+
+func (t theme) promptStyle(focused bool) tcell.Style {
+	style := tcell.StyleDefault.Bold(true).Foreground(t.prompt).Background(t.background)
+	if focused {
+		style = style.Foreground(t.promptFocus)
+		if t.mono {
+			style = style.Underline(true)
+		}
+	}
+	return style
+}
+
+// rangeStyle highlights rows covered by a range selection.
+func (t theme) rangeStyle() tcell.Style {
+	if t.mono {
+		return tcell.StyleDefault.Underline(true)
+	}
+	return tcell.StyleDefault.Background(t.rangeBg).Foreground(t.text)
+}
+
+// rowStyle styles the list row of a session that ended with an error, is in use or is older
+// than the dimming age, in that order of precedence. The mono theme uses attributes instead of
+// colors. ok is false for rows drawn in the default style.
+func (t theme) rowStyle(failed, active, stale bool) (style tcell.Style, ok bool) {
+	style = tcell.StyleDefault.Background(t.background).Foreground(t.text)
+	switch {
+	case failed && t.mono:
+		return style.Underline(true), true
+	case failed:
+		return style.Foreground(t.errorRow), true
+	case active && t.mono:
+		return style.Bold(true), true
+	case active:
+		return style.Foreground(t.activeRow).Bold(true), true
+	case stale && t.mono:
+		return style.Dim(true), true
+	case stale:
+		return style.Foreground(t.staleRow), true
+	}
+	return style, false
+}
+
+func (t theme) roleColor(role string) tcell.Color {
+	if t.mono {
+		return tcell.ColorDefault
+	}
+	switch role {
+	case "user":
+		return t.accent
+	case "assistant":
+		return t.prompt
+	case "tool":
+		return t.promptFocus
+	default:
+		return t.muted
+	}
+}
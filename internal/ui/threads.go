@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const threadMark = "⑂"
+
+// threadRoot returns the ID of the root of the thread id belongs to, lower-cased, or "" when
+// the session is not linked to any other.
+func (m *model) threadRoot(id string) string {
+	if m.threads == nil {
+		list := make([]sessions.Session, len(m.entries))
+		for i, entry := range m.entries {
+			list[i] = entry.session
+		}
+		m.threads = make(map[string]string)
+		for member, root := range sessions.Threads(list) {
+			m.threads[strings.ToLower(member)] = strings.ToLower(root)
+		}
+	}
+	return m.threads[strings.ToLower(id)]
+}
+
+// showThread narrows the list to the thread of the highlighted session, or goes back to the
+// full list when a thread is already shown.
+func (m *model) showThread() {
+	if filters, _ := parseQuery(m.query); hasFilter(filters, "thread") {
+		m.clearQuery()
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	if m.threadRoot(sess.ID) == "" {
+		m.setStatus(fmt.Sprintf("Session %s is not linked to a fork or parent", sess.ID))
+		return
+	}
+	m.setQuery("thread:" + sess.ID)
+	m.selectSession(sess.ID)
+	m.refreshTable()
+	m.setStatus(fmt.Sprintf("Thread of %s: %d sessions (t returns to the full list)", sess.ID, len(m.filtered)))
+}
+
+func hasFilter(filters []queryFilter, key string) bool {
+	for _, f := range filters {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// timelineHeight fits the span line and the two activity rows inside the border.
+	timelineHeight = 5
+	timelineLabel  = "tools  "
+	idleMark       = '·'
+)
+
+// sparkLevels draws an activity count relative to the busiest cell of the timeline.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// activity counts the transcript entries of a session per time bucket: tool calls and their
+// output apart from messages and reasoning.
+type activity struct {
+	start, end  time.Time
+	bucket      time.Duration
+	tools, rest []int
+}
+
+// bucketActivity spreads the timestamped entries over at most cells buckets of whole minutes.
+// It reports false when fewer than two entries have a timestamp.
+func bucketActivity(entries []sessions.TranscriptEntry, cells int) (activity, bool) {
+	var a activity
+	stamped := 0
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+		if stamped == 0 || entry.Timestamp.Before(a.start) {
+			a.start = entry.Timestamp
+		}
+		if entry.Timestamp.After(a.end) {
+			a.end = entry.Timestamp
+		}
+		stamped++
+	}
+	if stamped < 2 || cells < 1 {
+		return a, false
+	}
+	a.start = a.start.Truncate(time.Minute)
+	minutes := int(a.end.Sub(a.start)/time.Minute) + 1
+	per := (minutes + cells - 1) / cells
+	a.bucket = time.Duration(per) * time.Minute
+	n := (minutes + per - 1) / per
+	a.tools, a.rest = make([]int, n), make([]int, n)
+	for _, entry := range entries {
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+		i := min(int(entry.Timestamp.Sub(a.start)/a.bucket), n-1)
+		if entry.Role == "tool" {
+			a.tools[i]++
+		} else {
+			a.rest[i]++
+		}
+	}
+	return a, true
+}
+
+// sparkline renders counts scaled to peak. Empty cells are blank, or marked idle when the other
+// row has no activity there either.
+func sparkline(counts, other []int, peak int) string {
+	out := make([]rune, len(counts))
+	for i, count := range counts {
+		switch {
+		case count > 0:
+			level := (count*len(sparkLevels) + peak - 1) / peak
+			out[i] = sparkLevels[min(level, len(sparkLevels))-1]
+		case other[i] == 0:
+			out[i] = idleMark
+		default:
+			out[i] = ' '
+		}
+	}
+	return string(out)
+}
+
+// newTimeline returns the box drawing the activity of the entries loaded in p, adapted to its
+// width whenever it is drawn.
+func (p *preview) newTimeline() *tview.Box {
+	box := tview.NewBox()
+	box.SetBorder(true).SetTitle(" Timeline ")
+	box.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		ix, iy, iw, _ := box.GetInnerRect()
+		a, ok := bucketActivity(p.entries, iw-len(timelineLabel))
+		if !ok {
+			return ix, iy, iw, 0
+		}
+		peak := 1
+		for i := range a.tools {
+			peak = max(peak, a.tools[i], a.rest[i])
+		}
+		span := fmt.Sprintf("%s – %s (%s), %s per cell", a.start.Local().Format("15:04"), a.end.Local().Format("15:04"),
+			formatDuration(a.end.Sub(a.start)), formatDuration(a.bucket))
+		tview.Print(screen, tview.Escape(span), ix, iy, iw, tview.AlignLeft, p.theme.muted)
+		rows := []struct {
+			label        string
+			counts, rest []int
+			role         string
+		}{
+			{"tools", a.tools, a.rest, "tool"},
+			{"other", a.rest, a.tools, "assistant"},
+		}
+		for i, row := range rows {
+			tview.Print(screen, row.label, ix, iy+1+i, len(timelineLabel), tview.AlignLeft, p.theme.muted)
+			tview.Print(screen, sparkline(row.counts, row.rest, peak), ix+len(timelineLabel), iy+1+i,
+				iw-len(timelineLabel), tview.AlignLeft, p.theme.roleColor(row.role))
+		}
+		return ix, iy, iw, 0
+	})
+	return box
+}
+
+// showTimeline shows the timeline box when the loaded transcript has timestamps to plot.
+func (p *preview) showTimeline() {
+	height := 0
+	if _, ok := bucketActivity(p.entries, 1); ok {
+		height = timelineHeight
+	}
+	p.pane.ResizeItem(p.timeline, height, 0)
+}
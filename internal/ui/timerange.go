@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// rangePreset is an entry of the date range picker; age 0 means any time.
+type rangePreset struct {
+	name string
+	age  time.Duration
+}
+
+var rangePresets = []rangePreset{
+	{name: "Any time"},
+	{name: "Last 24 hours", age: 24 * time.Hour},
+	{name: "Last 7 days", age: 7 * 24 * time.Hour},
+	{name: "Last 30 days", age: 30 * 24 * time.Hour},
+	{name: "Last 90 days", age: 90 * 24 * time.Hour},
+}
+
+const customRange = "Custom range…"
+
+// pickTimeRange offers preset ranges and a custom one typed as "since..until".
+func (m *model) pickTimeRange() {
+	items := make([]string, 0, len(rangePresets)+1)
+	for _, preset := range rangePresets {
+		items = append(items, preset.name)
+	}
+	m.pick(" Updated ", append(items, customRange), func(index int) {
+		if index < len(rangePresets) {
+			r := sessions.TimeRange{}
+			if age := rangePresets[index].age; age > 0 {
+				r.Since = time.Now().Add(-age)
+			}
+			m.setTimeRange(r)
+			return
+		}
+		m.prompt(" Updated since..until (e.g. 2w..3d or 2025-01-01..2025-01-31) ", "", m.table, func(text string) {
+			r, err := parseTimeRange(text, time.Now())
+			if err != nil {
+				m.setStatus(err.Error())
+				return
+			}
+			m.setTimeRange(r)
+		})
+	})
+}
+
+func (m *model) setTimeRange(r sessions.TimeRange) {
+	m.timeRange = r
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+	m.setStatus("Showing sessions updated " + r.String())
+	// Rollouts outside the range sessions were read for have not been read yet.
+	if !m.load.ScanRange.Covers(r) {
+		m.load.ScanRange = sessions.TimeRange{}
+		m.reload()
+	}
+}
+
+// parseTimeRange parses "since..until", where either side may be left out; text without ".."
+// is a start only.
+func parseTimeRange(text string, now time.Time) (sessions.TimeRange, error) {
+	since, until, _ := strings.Cut(text, "..")
+	var r sessions.TimeRange
+	var err error
+	if r.Since, err = sessions.ParseTimeBound(since, now, false); err != nil {
+		return r, err
+	}
+	if r.Until, err = sessions.ParseTimeBound(until, now, true); err != nil {
+		return r, err
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Since.Before(r.Until) {
+		return r, fmt.Errorf("empty range: %s is not before %s", strings.TrimSpace(since), strings.TrimSpace(until))
+	}
+	return r, nil
+}
@@ -1,26 +1,45 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 	"time"
 	"unicode"
 
-	"github.com/Uri2001/codex-sessions/internal/sessions"
+	"github.com/Uri2001/codex-sessions/internal/enrich"
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
 	"github.com/gdamore/tcell/v2"
 	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 )
 
 const (
 	searchPrompt   = "Search> "
+	statusHint     = "Press ? for help, Ctrl+P for commands"
 	defaultPageLen = 10
+	mainPage       = "main"
 )
 
 type row struct {
 	session   sessions.Session
 	searchKey string
+	// exactKey is searchKey before lower-casing, for case-sensitive searches.
+	exactKey string
+	// extra holds metadata provider fields keyed by column name.
+	extra map[string]string
+	// recordedDir is the working directory the rollout records when the sidecar replaced it.
+	recordedDir string
+}
+
+// trashedRow remembers a deleted row so it can be put back on undo.
+type trashedRow struct {
+	row   row
+	entry sessions.TrashEntry
 }
 
 type model struct {
@@ -31,55 +50,334 @@ type model struct {
 	query        string
 	status       string
 	sessionsRoot string
-	resumeID     string
+	files        sessions.FileOptions
+	chosen       Selection
+	keymap       string
+	keyOverrides map[string][]string
+	keys         *keyBindings
+	searching    bool
+	sortMode     sortMode
+	caseMode     caseMode
+	sortName     string
+	dimAfter     time.Duration
+	enterMenu    bool
+	actionWidth  int
+	twoLineRows  bool
+	trash        []trashedRow
+	enricher     *enrich.Runner
+	extraCols    []string
+	columnKeys   []string
+	columns      []column
+	meta         *sidecar.Store
+	showHidden   bool
+	ranking      Ranking
+	narrow       narrowing
+	stream       func(ctx context.Context, opts sessions.Options, emit func(sessions.Session)) error
+	load         sessions.Options
+	candidates   func(list []sessions.Session, pattern string) []sessions.Session
+	loading      bool
+	spinner      int
+	mouse        bool
+	themeName    string
+	themeColors  map[string]string
+	theme        theme
+
+	// colWidths holds the width of every table column for layoutWidth, 0 for dropped columns;
+	// visibleCols lists the columns shown. See layoutColumns.
+	colWidths   []int
+	visibleCols []int
+	layoutWidth int
+
+	// ctx is cancelled when the UI exits; cancelLoad stops the scan in progress.
+	ctx        context.Context
+	cancelLoad context.CancelFunc
+
+	// active caches in-use checks; forceID is the active session a destructive action was
+	// already refused for once.
+	active  map[string]activeState
+	forceID string
+	// missingDirs caches which working directories no longer exist.
+	missingDirs map[string]bool
+	templates   map[string]string
+	// repoOnly limits the list to sessions inside repoRoot, the git repository of the current
+	// directory, found on first use.
+	repoOnly bool
+	repoRoot string
+	// project lists the IDs or prefixes of the project file's sessions.
+	project []string
+	compact bool
+	height  int
+	// multiSelect enables marking sessions; marked holds the IDs of the marked ones.
+	multiSelect bool
+	marked      map[string]bool
+	// startAt is the session selected last time, highlighted once it is listed unless the user
+	// moved the cursor first.
+	startAt   string
+	timeRange sessions.TimeRange
+	prices    sessions.PriceTable
+	redactor  *sessions.Redactor
+	// threads maps lower-cased session IDs to their thread root; nil until first needed.
+	threads map[string]string
+	// continued maps lower-cased session IDs to the latest session of their continuation group
+	// and continuedSize counts each group; nil until first needed. expanded holds the groups
+	// whose earlier rollouts are shown.
+	continued     map[string]string
+	continuedSize map[string]int
+	expanded      map[string]bool
+	// savedSearches maps names to queries; saveSearchFunc persists a newly saved one.
+	savedSearches  map[string]string
+	saveSearchFunc func(name, query string) error
+	// history holds recent queries, oldest first. histPos is the entry Up/Down last recalled
+	// (len(history) when none) and histDraft the query typed before browsing started.
+	history   []string
+	histPos   int
+	histDraft string
+	// grepPattern is the last transcript grep, offered again by the next one.
+	grepPattern string
+	saveHistory func([]string) error
 
 	app        *tview.Application
-	searchView *tview.TextView
+	screen     tcell.Screen
+	pages      *tview.Pages
+	searchView *tview.InputField
 	infoView   *tview.TextView
+	body       *tview.Flex
 	table      *tview.Table
-	helpView   *tview.TextView
+	preview    *preview
 	statusView *tview.TextView
 }
 
-// Run launches the TUI and returns the session ID selected for resume, if any.
-func Run(items []sessions.Session, sessionsRoot, initialStatus string) (string, error) {
-	m := newModel(items, sessionsRoot, initialStatus)
+// Options configures the TUI.
+type Options struct {
+	SessionsRoot string
+	// Files controls how deleting, archiving and the other file operations touch the sessions
+	// directory.
+	Files sessions.FileOptions
+	// Status is shown in the status bar on startup, e.g. load warnings.
+	Status string
+	// Query pre-fills the search field.
+	Query string
+	// Keymap is one of the config.Keymap* names; Keys rebinds actions on top of it, mapping
+	// action names (see defaultBindings) to key names such as "Ctrl+R".
+	Keymap string
+	Keys   map[string][]string
+	// Mouse enables click, double-click and wheel handling.
+	Mouse bool
+	// Theme names a built-in theme; ThemeColors overrides individual theme colors.
+	Theme       string
+	ThemeColors map[string]string
+	// ShowHidden includes sessions hidden with the hide action.
+	ShowHidden bool
+	// Columns names optional built-in columns to show, e.g. "messages" or "duration".
+	Columns []string
+	// Sort names the initial sort order, e.g. "frecency"; empty means "updated".
+	Sort string
+	// EnterMenu makes Enter and double clicks open a menu of quick actions instead of resuming
+	// right away.
+	EnterMenu bool
+	// DimAfter dims the rows of sessions not updated for longer than this; 0 dims none.
+	DimAfter time.Duration
+	// ActionWidth caps the width of the Last Action column; 0 leaves it uncapped.
+	ActionWidth int
+	// TwoLineRows gives every session two lines, with its last user message on the first and
+	// its last assistant message on the second, in place of the last action.
+	TwoLineRows bool
+	// Ranking orders search results; the zero value means DefaultRanking.
+	Ranking Ranking
+	// History lists recent search queries, oldest first; SaveHistory, when set, persists it
+	// whenever a query is added.
+	History     []string
+	SaveHistory func([]string) error
+	// SavedSearches maps names to queries recalled with F4; SaveSearch, when set, persists a
+	// query saved with Ctrl+S.
+	SavedSearches map[string]string
+	SaveSearch    func(name, query string) error
+	// Redactor masks secrets in the preview and in exports; nil leaves transcripts as recorded.
+	Redactor *sessions.Redactor
+	// Prices estimates session costs for the cost column and the cost: search filter.
+	Prices sessions.PriceTable
+	// TimeRange initially limits the list by update time; F3 changes it.
+	TimeRange sessions.TimeRange
+	// Templates maps names to prompts offered when starting a new session.
+	Templates map[string]string
+	// Compact drops the info line and spacers and draws without switching to the alternate
+	// screen, for pickers embedded in tmux or zellij popups.
+	Compact bool
+	// Height limits the UI to the top rows of the terminal; 0 uses all of it.
+	Height int
+	// MultiSelect lets the user mark several sessions with Space; Enter then chooses all of
+	// them. Meant for printing the selection rather than resuming it.
+	MultiSelect bool
+	// Project lists the session IDs or prefixes of the project's .codex-sessions file. They are
+	// shown first and marked.
+	Project []string
+	// Enricher, when set, computes extra columns in the background after startup.
+	Enricher *enrich.Runner
+	// Stream, when set, scans for sessions with opts in the background, e.g. with
+	// sessions.StreamRootsContext. It runs at startup when no sessions are passed to Run and
+	// again on reload; the UI adds sessions as they arrive, and a session reported again
+	// replaces its earlier version. ctx is cancelled when the UI exits or the scan is restarted.
+	Stream func(ctx context.Context, opts sessions.Options, emit func(sessions.Session)) error
+	// Load holds the options Stream is first called with. Loading all files and widening the
+	// time range change the UI's own copy for later scans.
+	Load sessions.Options
+	// ContentCandidates, when set, narrows the sessions a transcript grep reads to those that may
+	// match the regular expression pattern, e.g. with a content index. It runs in the background.
+	ContentCandidates func(list []sessions.Session, pattern string) []sessions.Session
+}
+
+// Selection is what the user chose to resume or start.
+type Selection struct {
+	Session sessions.Session
+	// Dir replaces the session's working directory when the recorded one no longer exists and
+	// the user picked another; it is empty otherwise. For a new session it is where to start.
+	Dir string
+	// New asks for a new Codex session in Dir, seeded with Prompt when it is not empty.
+	New    bool
+	Prompt string
+	// Sessions lists the sessions marked with Options.MultiSelect, in list order, and Session is
+	// the first of them. It is nil when nothing was marked.
+	Sessions []sessions.Session
+	// Args are passed on to codex resume, e.g. to resume in the read-only sandbox.
+	Args []string
+}
+
+// Run launches the TUI and returns the session selected for resume or the new session to start.
+// The selection is the zero value when the user quit without choosing.
+func Run(items []sessions.Session, opts Options) (Selection, error) {
+	m := newModel(items, opts)
 	if err := m.run(); err != nil {
-		return "", err
+		return Selection{}, err
 	}
-	return m.resumeID, nil
+	m.recordQuery()
+	return m.chosen, nil
 }
 
-func newModel(items []sessions.Session, sessionsRoot, initialStatus string) *model {
+func newModel(items []sessions.Session, opts Options) *model {
 	rows := make([]row, len(items))
 	for i, sess := range items {
-		key := strings.ToLower(strings.Join([]string{
-			sess.ID,
-			sess.WorkingDir,
-			sess.LastAction,
-			sess.CreatedAt.Format(time.RFC3339),
-			sess.UpdatedAt.Format(time.RFC3339),
-		}, " "))
-		rows[i] = row{
-			session:   sess,
-			searchKey: key,
-		}
+		rows[i] = newRow(sess)
+	}
+	ranking := opts.Ranking
+	if ranking == (Ranking{}) {
+		ranking = DefaultRanking()
 	}
 	return &model{
 		entries:      rows,
 		pageSize:     defaultPageLen,
-		status:       initialStatus,
-		sessionsRoot: sessionsRoot,
+		status:       opts.Status,
+		query:        opts.Query,
+		sessionsRoot: opts.SessionsRoot,
+		files:        opts.Files,
+		keymap:       opts.Keymap,
+		keyOverrides: opts.Keys,
+		enricher:     opts.Enricher,
+		mouse:        opts.Mouse,
+		themeName:    opts.Theme,
+		themeColors:  opts.ThemeColors,
+		columnKeys:   opts.Columns,
+		sortName:     opts.Sort,
+		dimAfter:     opts.DimAfter,
+		enterMenu:    opts.EnterMenu,
+		actionWidth:  opts.ActionWidth,
+		twoLineRows:  opts.TwoLineRows,
+		showHidden:   opts.ShowHidden,
+		ranking:      ranking,
+		stream:       opts.Stream,
+		load:         opts.Load,
+		candidates:   opts.ContentCandidates,
+		templates:    opts.Templates,
+		project:      opts.Project,
+		compact:      opts.Compact,
+		multiSelect:  opts.MultiSelect,
+		height:       opts.Height,
+		timeRange:    opts.TimeRange,
+		prices:       opts.Prices,
+		redactor:     opts.Redactor,
+		// Copied so saving a search does not write into the caller's map.
+		savedSearches:  maps.Clone(opts.SavedSearches),
+		saveSearchFunc: opts.SaveSearch,
+		history:        opts.History,
+		histPos:        len(opts.History),
+		saveHistory:    opts.SaveHistory,
+	}
+}
+
+func newRow(sess sessions.Session) row {
+	key := strings.Join([]string{
+		sess.ID,
+		sess.WorkingDir,
+		sess.LastAction,
+		sess.Model,
+		sess.CreatedAt.Format(time.RFC3339),
+		sess.UpdatedAt.Format(time.RFC3339),
+	}, " ")
+	return row{
+		session:   sess,
+		searchKey: strings.ToLower(key),
+		exactKey:  key,
 	}
 }
 
 func (m *model) run() error {
-	m.app = tview.NewApplication()
+	newScreen := tcell.NewScreen
+	if m.compact {
+		newScreen = newInlineScreen
+	}
+	screen, err := newScreen()
+	if err != nil {
+		return err
+	}
+	m.screen = screen
+	m.app = tview.NewApplication().SetScreen(screen)
 
-	m.searchView = tview.NewTextView().
-		SetDynamicColors(true).
-		SetRegions(false).
-		SetWrap(false)
+	t, themeErr := resolveTheme(m.themeName, m.themeColors, screen.Colors())
+	if themeErr != nil && m.status == "" {
+		m.status = themeErr.Error()
+	}
+	m.theme = t
+	m.theme.apply()
+
+	store, metaErr := sidecar.Open(m.sessionsRoot)
+	if metaErr != nil && m.status == "" {
+		m.status = metaErr.Error()
+	}
+	store.SetReadOnly(m.files.ReadOnly)
+	m.meta = store
+	m.startAt = store.LastSelected()
+	for i, entry := range m.entries {
+		m.setRow(i, entry.session)
+	}
+
+	keys, keysErr := newKeyBindings(m.keymap, m.keyOverrides)
+	if keysErr != nil && m.status == "" {
+		m.status = "Keys: " + keysErr.Error()
+	}
+	m.keys = keys
+
+	cols, colErr := resolveColumns(m.columnKeys)
+	if colErr != nil && m.status == "" {
+		m.status = colErr.Error()
+	}
+	m.columns = cols
+
+	if mode, ok := parseSortMode(m.sortName); ok {
+		m.sortMode = mode
+	} else if m.status == "" {
+		m.status = fmt.Sprintf("Unknown sort order %q", m.sortName)
+	}
+
+	m.searchView = tview.NewInputField().
+		SetLabel(searchPrompt).
+		SetText(m.query).
+		SetFieldBackgroundColor(m.theme.background).
+		SetFieldTextColor(m.theme.text).
+		SetChangedFunc(func(text string) {
+			m.query = text
+			m.applyFilter()
+			m.refreshInfoView()
+			m.refreshTable()
+		})
 
 	m.infoView = tview.NewTextView().
 		SetDynamicColors(false).
@@ -87,128 +385,226 @@ func (m *model) run() error {
 		SetWrap(false)
 
 	m.table = tview.NewTable().
+		SetContent(sessionTable{m: m}).
 		SetSelectable(true, false).
 		SetFixed(1, 0)
 
-	m.table.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorBlue).Foreground(tcell.ColorWhite))
+	m.table.SetSelectedStyle(m.theme.selectedStyle())
 	m.table.SetSelectionChangedFunc(func(row, column int) {
 		if row <= 0 || len(m.filtered) == 0 {
 			m.selected = 0
+			m.syncPreview()
 			return
 		}
-		idx := row - 1
+		idx, _ := m.filteredAt(row)
 		if idx >= len(m.filtered) {
 			idx = len(m.filtered) - 1
 		}
 		m.selected = idx
+		m.syncPreview()
 	})
 	m.table.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-		visible := height - 1 // header row
+		m.layoutColumns(width)
+		visible := (height - 1) / m.rowLines() // below the header row
 		if visible < 1 {
 			visible = 1
 		}
-		m.pageSize = visible
+		if visible != m.pageSize {
+			m.pageSize = visible
+			// The info line above was drawn with the old page size, and after a resize the
+			// selected row may be out of view; update both with another draw.
+			go m.app.QueueUpdateDraw(func() {
+				m.refreshInfoView()
+				m.refreshTable()
+			})
+		}
 		return x, y, width, height
 	})
 
-	m.helpView = tview.NewTextView().
-		SetDynamicColors(true).
-		SetWrap(false).
-		SetText("[green]Up/Down move  PgUp/PgDn page  Enter resume  Del delete  Type to search  Backspace delete  Esc clear/exit  Ctrl+C quit")
-
 	m.statusView = tview.NewTextView().
 		SetDynamicColors(false).
-		SetWrap(false)
+		SetWrap(false).
+		SetTextColor(m.theme.status)
 
-	layout := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(m.searchView, 1, 0, false).
-		AddItem(listSpacer(), 1, 0, false).
-		AddItem(m.infoView, 1, 0, false).
+	m.preview = newPreview(m.theme, m.redactor)
+	m.body = tview.NewFlex().
 		AddItem(m.table, 0, 1, true).
-		AddItem(listSpacer(), 1, 0, false).
-		AddItem(m.helpView, 1, 0, false).
-		AddItem(m.statusView, 1, 0, false)
+		AddItem(m.preview.pane, 0, 0, false)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(m.searchView, 1, 0, false)
+	if m.compact {
+		layout.AddItem(m.body, 0, 1, true).
+			AddItem(m.statusView, 1, 0, false)
+	} else {
+		layout.AddItem(listSpacer(), 1, 0, false).
+			AddItem(m.infoView, 1, 0, false).
+			AddItem(m.body, 0, 1, true).
+			AddItem(listSpacer(), 1, 0, false).
+			AddItem(m.statusView, 1, 0, false)
+	}
+
+	m.pages = tview.NewPages().
+		AddPage(mainPage, layout, true, true)
 
-	m.app.SetRoot(layout, true)
+	m.app.SetRoot(fitHeight(m.pages, m.height), true)
 	m.app.SetFocus(m.table)
 
 	m.app.SetInputCapture(m.handleEvent)
+	if m.mouse {
+		m.setupMouse()
+	}
 
 	m.applyFilter()
-	m.refreshSearchView()
-	m.refreshInfoView()
-	m.refreshTable()
+	m.selectStart()
+	m.refresh()
 	m.setStatus(m.status)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.ctx = ctx
+	m.watchSuspend(ctx)
+	if m.stream != nil && len(m.entries) == 0 {
+		m.startLoading()
+	} else {
+		m.startEnrichment(ctx)
+	}
+
 	return m.app.Run()
 }
 
+// startEnrichment runs the metadata providers in the background, filling extra columns as
+// results arrive.
+func (m *model) startEnrichment(ctx context.Context) {
+	if m.enricher == nil {
+		return
+	}
+	m.extraCols = m.enricher.Columns()
+	items := make([]sessions.Session, len(m.entries))
+	for i, entry := range m.entries {
+		items[i] = entry.session
+	}
+	go func() {
+		err := m.enricher.Run(ctx, items, func(id string, fields map[string]string) {
+			m.app.QueueUpdateDraw(func() {
+				m.setExtra(id, fields)
+			})
+		})
+		if err != nil && ctx.Err() == nil {
+			m.app.QueueUpdateDraw(func() {
+				m.setStatus(fmt.Sprintf("Metadata: %v", err))
+			})
+		}
+	}()
+}
+
+func (m *model) setExtra(id string, fields map[string]string) {
+	for i := range m.entries {
+		if m.entries[i].session.ID == id {
+			m.entries[i].extra = fields
+			m.refreshTable()
+			return
+		}
+	}
+}
+
 func (m *model) handleEvent(event *tcell.EventKey) *tcell.EventKey {
+	if id, ok := m.keys.action(chordOf(event)); ok && id == actSuspend {
+		// Suspending works from every view, including overlays and the search field.
+		m.suspend()
+		return nil
+	}
+	if m.overlayOpen() {
+		return event
+	}
+	if m.preview.focused {
+		return m.handlePreviewEvent(event)
+	}
+	if m.searching {
+		return m.handleSearchEvent(event)
+	}
+	if event.Key() == tcell.KeyRune && unicode.IsControl(event.Rune()) {
+		return event
+	}
+	if id, ok := m.keys.action(chordOf(event)); ok {
+		m.runAction(id)
+		return nil
+	}
 	switch event.Key() {
 	case tcell.KeyRune:
-		r := event.Rune()
-		if unicode.IsControl(r) {
-			return event
-		}
-		m.query += string(r)
-		m.applyFilter()
-		m.refreshSearchView()
-		m.refreshInfoView()
-		m.refreshTable()
+		// Unbound characters do nothing rather than reaching the table's own key handling.
 		return nil
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		if m.query != "" {
-			m.query = dropLastRune(m.query)
-			m.applyFilter()
-			m.refreshSearchView()
-			m.refreshInfoView()
-			m.refreshTable()
+			m.setQuery(dropLastRune(m.query))
 		}
 		return nil
-	case tcell.KeyEsc:
-		if m.query != "" {
-			m.query = ""
-			m.applyFilter()
-			m.refreshSearchView()
-			m.refreshInfoView()
-			m.refreshTable()
-			return nil
+	}
+	return event
+}
+
+// handleSearchEvent lets the search input edit the query while keeping list navigation available.
+func (m *model) handleSearchEvent(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEnter, tcell.KeyEsc, tcell.KeyTab:
+		m.setSearching(false)
+		return nil
+	case tcell.KeyUp:
+		if !m.historyStep(-1) {
+			m.moveSelectionBy(-1)
 		}
-		m.resumeID = ""
-		m.app.Stop()
 		return nil
-	case tcell.KeyEnter:
-		if len(m.filtered) == 0 {
-			return nil
+	case tcell.KeyDown:
+		if !m.historyStep(1) {
+			m.moveSelectionBy(1)
 		}
-		idx := m.filtered[m.selected]
-		m.resumeID = m.entries[idx].session.ID
-		m.app.Stop()
 		return nil
-	case tcell.KeyDelete:
-		m.deleteSelected()
-		m.refreshSearchView()
-		m.refreshInfoView()
-		m.refreshTable()
+	case tcell.KeyPgUp:
+		m.moveSelectionBy(-m.pageSize)
 		return nil
 	case tcell.KeyPgDn:
 		m.moveSelectionBy(m.pageSize)
 		return nil
-	case tcell.KeyPgUp:
-		m.moveSelectionBy(-m.pageSize)
-		return nil
-	case tcell.KeyCtrlC:
-		m.resumeID = ""
-		m.app.Stop()
+	}
+	typed := event.Key() == tcell.KeyRune && event.Modifiers()&tcell.ModAlt == 0
+	if id, ok := m.keys.action(chordOf(event)); ok && searchActions[id] && !typed {
+		// The query can be typed on after switching the letter case mode.
+		if id != actCase {
+			m.setSearching(false)
+		}
+		m.runAction(id)
 		return nil
 	}
 	return event
 }
 
+func (m *model) setSearching(on bool) {
+	m.searching = on
+	m.recordQuery()
+	if on {
+		m.app.SetFocus(m.searchView)
+	} else {
+		m.app.SetFocus(m.table)
+	}
+	m.refreshSearchView()
+}
+
+// setQuery replaces the search text; the input's change handler re-filters the list.
+func (m *model) setQuery(query string) {
+	m.searchView.SetText(query)
+}
+
+func (m *model) refresh() {
+	m.refreshSearchView()
+	m.refreshInfoView()
+	m.refreshTable()
+}
+
 func (m *model) moveSelectionBy(delta int) {
 	if len(m.filtered) == 0 {
 		return
 	}
+	m.startAt = ""
 	next := m.selected + delta
 	if next < 0 {
 		next = 0
@@ -216,11 +612,14 @@ func (m *model) moveSelectionBy(delta int) {
 		next = len(m.filtered) - 1
 	}
 	m.selected = next
-	m.table.Select(m.selected+1, 0)
+	m.table.Select(m.tableRow(m.selected), 0)
 }
 
 func (m *model) refreshSearchView() {
-	m.searchView.SetText(fmt.Sprintf("[blue::b]%s[-:-:-]%s", searchPrompt, m.query))
+	m.searchView.SetLabelStyle(m.theme.promptStyle(m.searching))
+	if m.searchView.GetText() != m.query {
+		m.searchView.SetText(m.query)
+	}
 }
 
 func (m *model) refreshInfoView() {
@@ -230,68 +629,158 @@ func (m *model) refreshInfoView() {
 	if displaying > m.pageSize {
 		displaying = m.pageSize
 	}
-	info := fmt.Sprintf("Matches: %d / Total: %d | Showing: %d", matches, total, displaying)
+	info := fmt.Sprintf("Matches: %d / Total: %d | Showing: %d | Sort: %s", matches, total, displaying, m.sortMode)
+	info += m.filterSummary()
+	info += m.markSummary()
+	info += m.limitSummary()
+	info += m.caseSummary()
+	info += m.loadingIndicator()
 	m.infoView.SetText(info)
 }
 
+// refreshTable re-selects the current row; the cells themselves are produced on demand by
+// sessionTable.
 func (m *model) refreshTable() {
-	m.table.Clear()
-
-	headerStyle := tcell.StyleDefault.Bold(true)
-	m.table.SetCell(0, 0, tview.NewTableCell("Updated").
-		SetSelectable(false).
-		SetStyle(headerStyle))
-	m.table.SetCell(0, 1, tview.NewTableCell("Session ID").
-		SetSelectable(false).
-		SetStyle(headerStyle))
-	m.table.SetCell(0, 2, tview.NewTableCell("Directory").
-		SetSelectable(false).
-		SetStyle(headerStyle))
-	m.table.SetCell(0, 3, tview.NewTableCell("Last Action").
-		SetSelectable(false).
-		SetStyle(headerStyle))
-
-	for i, idx := range m.filtered {
-		sess := m.entries[idx].session
-		row := i + 1
-		m.table.SetCell(row, 0, tview.NewTableCell(formatTimestamp(sess.UpdatedAt)).
-			SetExpansion(1))
-		m.table.SetCell(row, 1, tview.NewTableCell(sess.ID).
-			SetExpansion(1))
-		m.table.SetCell(row, 2, tview.NewTableCell(abbreviatePath(sess.WorkingDir, 40)).
-			SetExpansion(1))
-		m.table.SetCell(row, 3, tview.NewTableCell(truncateText(sess.LastAction, 80)).
-			SetExpansion(2))
-	}
-
 	if len(m.filtered) > 0 {
 		if m.selected >= len(m.filtered) {
 			m.selected = len(m.filtered) - 1
 		}
-		m.table.Select(m.selected+1, 0)
+		m.table.Select(m.tableRow(m.selected), 0)
 	} else {
 		m.table.Select(0, 0)
 	}
 }
 
+// badges returns the indicators shown in front of a session ID in the list.
+func (m *model) badges(sess sessions.Session) string {
+	var marks []string
+	if m.marked[sess.ID] {
+		marks = append(marks, markedMark)
+	}
+	if m.isActive(sess) {
+		marks = append(marks, activeMark)
+	}
+	if m.threadRoot(sess.ID) != "" {
+		marks = append(marks, threadMark)
+	}
+	if m.continuationOf(sess.ID) != "" {
+		marks = append(marks, continuationMark)
+	}
+	if sess.EndState.Failed() {
+		marks = append(marks, failedMark)
+	}
+	if len(sess.SkippedFiles) > 0 {
+		marks = append(marks, corruptMark)
+	}
+	if len(sess.DuplicateFiles) > 0 {
+		marks = append(marks, duplicateMark)
+	}
+	if m.inProject(sess.ID) {
+		marks = append(marks, projectMark)
+	}
+	if m.meta == nil {
+		return strings.Join(marks, "")
+	}
+	meta := m.meta.Get(sess.ID)
+	if meta.Pinned {
+		marks = append(marks, pinMark)
+	}
+	if len(meta.Bookmarks) > 0 {
+		marks = append(marks, bookmarkMark)
+	}
+	if meta.Note != "" {
+		marks = append(marks, noteMark)
+	}
+	if meta.Hidden {
+		marks = append(marks, hiddenMark)
+	}
+	return strings.Join(marks, "")
+}
+
+// writable reports whether the sessions directory may be modified, telling the user otherwise.
+func (m *model) writable(what string) bool {
+	if !m.files.ReadOnly {
+		return true
+	}
+	m.setStatus(fmt.Sprintf("Read-only mode: %s is disabled", what))
+	return false
+}
+
+// withinRoot reports whether the files of sess lie inside its sessions root, telling the user
+// otherwise.
+func (m *model) withinRoot(sess sessions.Session, what string) bool {
+	if err := sessions.CheckWithinRoot(sess, sess.RootOr(m.sessionsRoot)); err != nil {
+		m.setStatus(fmt.Sprintf("Refusing to %s: %v", what, err))
+		return false
+	}
+	return true
+}
+
 func (m *model) deleteSelected() {
+	if !m.writable("delete") {
+		return
+	}
 	if len(m.filtered) == 0 {
 		m.setStatus("Nothing to delete")
 		return
 	}
 	idx := m.filtered[m.selected]
 	sess := m.entries[idx].session
-	if err := sessions.DeleteFiles(sess, m.sessionsRoot); err != nil {
+	if !m.withinRoot(sess, "delete") || !m.confirmActive(sess, "delete it") {
+		return
+	}
+	entry, err := sessions.TrashFiles(sess, sess.RootOr(m.sessionsRoot), m.files)
+	if err != nil && len(entry.Files) == 0 {
 		m.setStatus(fmt.Sprintf("Delete failed: %v", err))
 		return
 	}
+	if m.files.DryRun {
+		m.setStatus(fmt.Sprintf("Dry run: session %s would be moved to trash", sess.ID))
+		return
+	}
+	// Files moved before a failure are in the trash all the same, so undo must bring them back.
+	m.trash = append(m.trash, trashedRow{row: m.entries[idx], entry: entry})
+	m.removeEntry(idx)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Delete failed after moving %d files to trash (u to undo): %v", len(entry.Files), err))
+		return
+	}
+	m.setStatus(fmt.Sprintf("Session %s moved to trash (u to undo)", sess.ID))
+}
+
+// undoDelete restores the most recently trashed session of this run.
+func (m *model) undoDelete() {
+	if !m.writable("undo") {
+		return
+	}
+	if len(m.trash) == 0 {
+		m.setStatus("Nothing to undo")
+		return
+	}
+	last := m.trash[len(m.trash)-1]
+	if err := sessions.RestoreTrash(last.entry, m.files); err != nil {
+		m.setStatus(fmt.Sprintf("Undo failed: %v", err))
+		return
+	}
+	m.trash = m.trash[:len(m.trash)-1]
+	m.entries = append(m.entries, last.row)
+	m.invalidateFilter()
+	m.applyFilter()
+	m.refresh()
+	m.setStatus(fmt.Sprintf("Session %s restored", last.row.session.ID))
+}
+
+func (m *model) removeEntry(idx int) {
 	m.entries = append(m.entries[:idx], m.entries[idx+1:]...)
-	m.setStatus(fmt.Sprintf("Session %s deleted", sess.ID))
+	m.invalidateFilter()
 	m.applyFilter()
 }
 
 func (m *model) setStatus(text string) {
 	m.status = text
+	if text == "" {
+		text = statusHint
+	}
 	m.statusView.SetText(text)
 }
 
@@ -302,34 +791,63 @@ func (m *model) applyFilter() {
 		return
 	}
 
-	query := strings.TrimSpace(m.query)
+	filters, query := parseQuery(m.query)
+	sensitive := m.caseMode.sensitive(query)
+	if !sensitive {
+		query = strings.ToLower(query)
+	}
+	candidates := m.narrowed(filters, query)
+
 	if query == "" {
-		m.filtered = make([]int, len(m.entries))
-		for i := range m.entries {
-			m.filtered[i] = i
-		}
+		m.filtered = candidates
+		m.sortFiltered()
+		m.pinnedFirst()
 	} else {
-		keys := make([]string, len(m.entries))
-		for i, entry := range m.entries {
-			keys[i] = entry.searchKey
+		keys := make([]string, len(candidates))
+		for i, idx := range candidates {
+			keys[i] = m.entries[idx].searchKey
+			note := m.noteText(m.entries[idx].session.ID)
+			if sensitive {
+				keys[i] = m.entries[idx].exactKey
+			} else {
+				note = strings.ToLower(note)
+			}
+			if note != "" {
+				keys[i] += "\n" + note
+			}
+		}
+		// Search keys are lower-cased up front unless the case matters, so the case-sensitive
+		// variant is enough.
+		results := fuzzy.RankFind(query, keys)
+		now := time.Now()
+		scores := make([]float64, len(results))
+		for i, rank := range results {
+			idx := candidates[rank.OriginalIndex]
+			age := now.Sub(m.entries[idx].session.UpdatedAt)
+			pinned := m.isPinned(idx) || m.inProject(m.entries[idx].session.ID)
+			scores[i] = m.ranking.score(rank.Distance, len(query), age, pinned)
+		}
+		order := make([]int, len(results))
+		for i := range order {
+			order[i] = i
 		}
-		results := fuzzy.RankFindFold(query, keys)
-		sort.Slice(results, func(i, j int) bool {
-			a, b := results[i], results[j]
-			if a.Distance == b.Distance {
-				sessA := m.entries[a.OriginalIndex].session
-				sessB := m.entries[b.OriginalIndex].session
+		sort.Slice(order, func(i, j int) bool {
+			a, b := order[i], order[j]
+			if scores[a] == scores[b] {
+				sessA := m.entries[candidates[results[a].OriginalIndex]].session
+				sessB := m.entries[candidates[results[b].OriginalIndex]].session
 				if sessA.UpdatedAt.Equal(sessB.UpdatedAt) {
 					return sessA.ID < sessB.ID
 				}
 				return sessA.UpdatedAt.After(sessB.UpdatedAt)
 			}
-			return a.Distance < b.Distance
+			return scores[a] > scores[b]
 		})
 		m.filtered = m.filtered[:0]
-		for _, rank := range results {
-			m.filtered = append(m.filtered, rank.OriginalIndex)
+		for _, i := range order {
+			m.filtered = append(m.filtered, candidates[results[i].OriginalIndex])
 		}
+		m.narrow.matched = append(m.narrow.matched[:0], m.filtered...)
 	}
 
 	if len(m.filtered) == 0 {
@@ -361,13 +879,10 @@ func truncateText(text string, max int) string {
 	if text == "" {
 		return "-"
 	}
-	if len(text) <= max {
-		return text
-	}
 	if max <= 3 {
-		return text[:max]
+		return runewidth.Truncate(text, max, "")
 	}
-	return text[:max-3] + "..."
+	return runewidth.Truncate(text, max, "...")
 }
 
 func formatTimestamp(t time.Time) string {
@@ -377,16 +892,42 @@ func formatTimestamp(t time.Time) string {
 	return t.Local().Format("2006-01-02 15:04")
 }
 
+// abbreviatePath shortens path to max display columns by eliding its middle, keeping a Windows
+// drive letter or UNC share in front so sessions from different volumes stay distinguishable.
 func abbreviatePath(path string, max int) string {
+	// Rollouts written on Windows may carry a trailing carriage return.
+	path = strings.TrimRight(path, "\r\n")
 	if max <= 0 {
 		return path
 	}
-	if len(path) <= max {
+	width := runewidth.StringWidth(path)
+	if width <= max {
 		return path
 	}
 	const ellipsis = "..."
 	if max <= len(ellipsis) {
-		return path[:max]
+		return runewidth.Truncate(path, max, "")
+	}
+	prefix := ellipsis
+	if vol := volumeName(path); vol != "" && len(vol)+len(ellipsis)+1 < max/2 {
+		prefix = vol + path[len(vol):len(vol)+1] + ellipsis
+	}
+	// Cut everything but the last columns that fit after the prefix.
+	return runewidth.TruncateLeft(path, width-(max-runewidth.StringWidth(prefix)), prefix)
+}
+
+// volumeName returns the drive ("C:") or UNC share ("\\server\share") that path starts with,
+// independent of the operating system the UI runs on.
+func volumeName(path string) string {
+	if len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z') {
+		return path[:2]
+	}
+	if strings.HasPrefix(path, `\\`) {
+		parts := strings.SplitN(path[2:], `\`, 3)
+		if len(parts) == 3 {
+			return `\\` + parts[0] + `\` + parts[1]
+		}
 	}
-	return ellipsis + path[len(path)-(max-1):]
+	return ""
 }
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const viewerPage = "viewer"
+
+// viewer pages through the full transcript of a session, for rereading it without resuming
+// Codex. Every entry is a text region, so search matches can be highlighted and scrolled to.
+type viewer struct {
+	text    *tview.TextView
+	session sessions.Session
+	entries []sessions.TranscriptEntry
+	query   string
+	// current is the entry of the highlighted match, or -1.
+	current int
+}
+
+// viewTranscript opens the transcript of the highlighted session in a full-screen pager. It is
+// read through the preview, so the text is redacted and bookmarked entries are marked the same.
+func (m *model) viewTranscript() {
+	sess, ok := m.selectedSession()
+	if !ok {
+		m.setStatus("Nothing to view")
+		return
+	}
+	if err := m.preview.load(sess); err != nil {
+		m.setStatus(fmt.Sprintf("Reading the transcript failed: %v", err))
+		return
+	}
+	m.loadBookmarks()
+	v := &viewer{
+		text:    tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(true).SetWordWrap(true),
+		session: sess,
+		entries: m.preview.entries,
+		current: -1,
+	}
+	v.text.SetText(m.transcriptText(v.entries, m.preview.bookmarks))
+	v.text.SetTextColor(m.theme.text).SetBackgroundColor(m.theme.background)
+	v.text.SetBorder(true)
+	v.setTitle()
+	v.text.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			m.closeOverlay(viewerPage)
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'q':
+				m.closeOverlay(viewerPage)
+			case '/':
+				m.prompt(" Search the transcript ", v.query, v.text, func(text string) {
+					v.query = strings.TrimSpace(text)
+					v.current = -1
+					v.jump(1)
+				})
+			case 'n':
+				v.jump(1)
+			case 'N':
+				v.jump(-1)
+			default:
+				return event
+			}
+			return nil
+		}
+		return event
+	})
+	m.openOverlay(viewerPage, v.text, v.text)
+}
+
+// transcriptText renders entries as tagged text for a tview.TextView: a heading line colored by
+// role, followed by the complete text of the entry.
+func (m *model) transcriptText(entries []sessions.TranscriptEntry, bookmarks map[int]bool) string {
+	var b strings.Builder
+	for i, entry := range entries {
+		heading := entry.Heading()
+		if bookmarks[i] {
+			heading = bookmarkMark + " " + heading
+		}
+		color := "-"
+		if c := m.theme.roleColor(entry.Role); c != tcell.ColorDefault {
+			color = c.CSS()
+		}
+		fmt.Fprintf(&b, "[\"%d\"][%s::b]%s  %s[-::-]\n%s[\"\"]\n\n",
+			i, color, formatClock(entry), tview.Escape(heading), tview.Escape(strings.TrimSpace(entry.Text)))
+	}
+	return b.String()
+}
+
+// jump highlights the next entry matching the search in direction step (1 or -1), wrapping
+// around.
+func (v *viewer) jump(step int) {
+	if v.query == "" {
+		v.text.Highlight()
+		v.setTitle()
+		return
+	}
+	query := strings.ToLower(v.query)
+	n := len(v.entries)
+	start := v.current
+	if start < 0 && step < 0 {
+		start = 0
+	}
+	for k := 1; k <= n; k++ {
+		i := ((start+step*k)%n + n) % n
+		entry := v.entries[i]
+		if strings.Contains(strings.ToLower(entry.Text), query) ||
+			strings.Contains(strings.ToLower(entry.Heading()), query) {
+			v.current = i
+			v.text.Highlight(strconv.Itoa(i)).ScrollToHighlight()
+			v.setTitle()
+			return
+		}
+	}
+	v.current = -1
+	v.text.Highlight()
+	v.setTitle()
+}
+
+func (v *viewer) setTitle() {
+	id := truncateText(v.session.ID, 40)
+	switch {
+	case v.query == "":
+		v.text.SetTitle(fmt.Sprintf(" %s — q closes, / searches ", id))
+	case v.current < 0:
+		v.text.SetTitle(fmt.Sprintf(" %s — no entry matches /%s ", id, v.query))
+	default:
+		v.text.SetTitle(fmt.Sprintf(" %s — /%s, n / N jump, q closes ", id, v.query))
+	}
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+const missingDirMark = "⚠"
+
+// dirMissing reports whether sess records a working directory that no longer exists. Results
+// are cached per directory, since many sessions share one; reload clears the cache.
+func (m *model) dirMissing(sess sessions.Session) bool {
+	if sess.WorkingDir == "" {
+		return false
+	}
+	if m.missingDirs == nil {
+		m.missingDirs = make(map[string]bool)
+	}
+	missing, ok := m.missingDirs[sess.WorkingDir]
+	if !ok {
+		info, err := os.Stat(sess.WorkingDir)
+		missing = err != nil || !info.IsDir()
+		m.missingDirs[sess.WorkingDir] = missing
+	}
+	return missing
+}
+
+// chooseDir asks where to resume sess when its working directory is gone, offering the current
+// directory. An empty answer resumes without changing directory. args are passed on to codex.
+func (m *model) chooseDir(sess sessions.Session, args []string) {
+	initial, _ := os.Getwd()
+	m.setStatus(fmt.Sprintf("Working directory %s no longer exists; Enter resumes in the directory below, Esc cancels", sess.WorkingDir))
+	m.prompt(" Resume in directory ", initial, m.table, func(text string) {
+		dir := expandHome(strings.TrimSpace(text))
+		if dir != "" {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				m.setStatus(fmt.Sprintf("%s is not a directory", dir))
+				return
+			}
+		}
+		m.chosen = Selection{Session: sess, Dir: dir, Args: args}
+		m.app.Stop()
+	})
+}
+
+// setRow puts sess into the row at i, with the working directory set with W if there is one,
+// keeping the row's metadata provider fields.
+func (m *model) setRow(i int, sess sessions.Session) {
+	r := newRow(m.meta.WithWorkdir(sess))
+	if r.session.WorkingDir != sess.WorkingDir {
+		r.recordedDir = sess.WorkingDir
+	}
+	r.extra = m.entries[i].extra
+	m.entries[i] = r
+}
+
+// relocateSelected asks for the directory the highlighted session's project lives in now and
+// records it in the sidecar metadata, so filters and resuming in the working directory use it.
+// The rollout is not changed; an empty answer goes back to the directory it records.
+func (m *model) relocateSelected() {
+	if !m.writable("changing the working directory") {
+		return
+	}
+	sess, ok := m.selectedSession()
+	if !ok || m.meta == nil {
+		m.setStatus("No session to change the working directory of")
+		return
+	}
+	idx := m.filtered[m.selected]
+	recorded := m.entries[idx].recordedDir
+	if recorded == "" {
+		recorded = sess.WorkingDir
+	}
+	m.prompt(fmt.Sprintf(" Working directory of %s (empty for %s) ", truncateText(sess.ID, 12), abbreviatePath(recorded, 24)),
+		sess.WorkingDir, m.table, func(text string) {
+			dir := expandHome(strings.TrimSpace(text))
+			if dir != "" {
+				if abs, err := filepath.Abs(dir); err == nil {
+					dir = abs
+				}
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					m.setStatus(fmt.Sprintf("%s is not a directory", dir))
+					return
+				}
+			}
+			if dir == recorded {
+				dir = ""
+			}
+			if err := m.meta.Update(sess.ID, func(meta *sidecar.Meta) { meta.Workdir = dir }); err != nil {
+				m.setStatus(fmt.Sprintf("Changing the working directory failed: %v", err))
+				return
+			}
+			original := sess
+			original.WorkingDir = recorded
+			m.setRow(idx, original)
+			m.invalidateFilter()
+			m.applyFilter()
+			m.selectSession(sess.ID)
+			m.refresh()
+			if dir == "" {
+				m.setStatus(fmt.Sprintf("%s uses its recorded working directory %s again", sess.ID, recorded))
+			} else {
+				m.setStatus(fmt.Sprintf("%s now works in %s (recorded: %s)", sess.ID, dir, recorded))
+			}
+		})
+}
+
+// expandHome replaces a leading "~" of path by the home directory, as a shell would.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && !os.IsPathSeparator(rest[0])) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + rest
+}
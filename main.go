@@ -1,62 +1,555 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
-	"github.com/Uri2001/codex-sessions/internal/sessions"
-	"github.com/Uri2001/codex-sessions/internal/ui"
+	"github.com/Uri2001/codex-sessions/internal/config"
+	"github.com/Uri2001/codex-sessions/internal/enrich"
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
 )
 
 var (
-	flagSessionsDir = flag.String("sessions-dir", "", "Path to the Codex CLI sessions directory. Defaults to ~/.codex/sessions.")
-	flagCodexBin    = flag.String("codex-bin", "codex", "Codex CLI binary to invoke for resuming sessions.")
-	flagNoResume    = flag.Bool("no-resume", false, "Do not automatically run `codex resume`. Print the selected ID instead.")
+	flagSessionsDirs stringList
+	flagExcludes     stringList
 )
 
+// Exit statuses, so wrapper scripts can tell the outcomes apart. A selection that was made or
+// resumed, and every command that succeeds, exits 0.
+const (
+	exitError      = 1
+	exitNoSessions = 2
+	exitCancelled  = 130
+)
+
+// errCancelled ends the UI without an error message when it is closed without a selection.
+var errCancelled = errors.New("cancelled")
+
+// errNoSessions is wrapped by the errors of commands that found no session to work on.
+var errNoSessions = errors.New("no sessions found")
+
+// errNoMatches ends grep without an error message when nothing matched.
+var errNoMatches = fmt.Errorf("no matches: %w", errNoSessions)
+
+var (
+	flagCodexBin   = flag.String("codex-bin", "codex", "Codex CLI binary to invoke for resuming sessions.")
+	flagNoResume   = flag.Bool("no-resume", false, "Do not automatically run `codex resume`. Print the selected ID instead.")
+	flagOutput     = flag.String("output", "text", "How --no-resume and --choose print the selection: text for the bare ID, or json for the session's metadata.")
+	flagMaintain   = flag.Bool("maintain", false, "Run a maintenance pass over the sessions directory and exit (same as the prune command).")
+	flagMaintainBg = flag.Bool("maintain-after-resume", false, "Start a background maintenance pass after `codex resume` exits.")
+	flagEmptyTrash = flag.Bool("empty-trash", false, "Permanently remove all trashed sessions and exit (same as prune --empty-trash).")
+	flagConfig     = flag.String("config", "", "Path to the configuration file. Defaults to <user config dir>/codex-sessions/config.json.")
+	flagLast       = flag.Bool("last", false, "Skip the UI and resume the most recently updated session (same as resume --last).")
+	flagLastForCwd = flag.Bool("last-for-cwd", false, "Skip the UI and resume the most recently updated session started in the current directory.")
+	flagAgain      = flag.Bool("again", false, "Skip the UI and resume the session selected or resumed last time (same as resume --again).")
+	flagProjectDef = flag.Bool("project-default", false, "Skip the UI and resume the default session of the .codex-sessions project file (same as resume --project-default).")
+	flagAll        = flag.Bool("all", false, "Include hidden sessions in the UI and in list.")
+	flagReadOnly   = flag.Bool("read-only", false, "Refuse every operation that would modify the sessions directory (delete, archive, trash, prune, annotations).")
+	flagDryRun     = flag.Bool("dry-run", false, "Print what delete, archive and maintenance operations would change without touching disk.")
+	flagVerbose    = flag.Bool("verbose", false, "Log every file operation to stderr.")
+	flagLogFile    = flag.String("log-file", "", "Append diagnostics (files parsed, parse times, commands run) to this file.")
+	flagResumeCmd  = flag.String("resume-cmd", "", "Command template run instead of `codex resume`, e.g. 'codex resume {{.ID}} --model {{.Model}}'.")
+	flagSince      = flag.String("since", "", "Only show sessions updated since this time: an age like 7d or 36h, or a date like 2025-01-02.")
+	flagUntil      = flag.String("until", "", "Only show sessions updated before this time (a date includes that whole day).")
+	flagNoRedact   = flag.Bool("no-redact", false, "Show and export transcripts without masking secrets.")
+	flagChoose     = flag.Bool("choose", false, "Pick a session in a compact UI for tmux or zellij popups and print only its ID; exits 130 when nothing is picked.")
+	flagPlainUI    = flag.Bool("plain-ui", false, "Use a line-oriented numbered list instead of the full-screen UI, for screen readers and dumb terminals.")
+	flagHeight     = flag.Int("height", 0, "Number of terminal rows the UI uses; 0 uses the whole terminal.")
+	flagRestoreCwd = flag.Bool("restore-cwd", false, "Resume in the session's working directory with its recorded sandbox mode and approval policy.")
+	flagLimit      = flag.Int("limit", 0, "Read only the N most recently modified rollout files when the UI starts; 0 reads all of them.")
+	flagQuiet      = flag.Bool("quiet", false, "Do not print warnings to stderr; errors are still printed.")
+)
+
+func init() {
+	flag.Var(&flagSessionsDirs, "sessions-dir", "Path to a Codex CLI sessions directory. Repeat it or separate paths with '"+string(os.PathListSeparator)+"' to merge several roots. Defaults to ~/.codex/sessions.")
+	flag.Var(&flagExcludes, "exclude", "Skip the files and directories below the sessions roots matching this glob, e.g. archive or '*.sync-conflict-*'. Repeatable.")
+}
+
+// stringList is a repeatable flag whose values may also be separated by the OS path list separator.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, string(os.PathListSeparator))
+}
+
+func (l *stringList) Set(value string) error {
+	for _, part := range filepath.SplitList(value) {
+		if part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// env carries the state shared by all commands. The configuration is loaded on first use so
+// commands that do not need it never warn about it.
+type env struct {
+	// roots lists every sessions root; root is the first one, which also stores annotations.
+	roots []string
+	root  string
+	// timeRange limits listed sessions by update time, from --since and --until.
+	timeRange sessions.TimeRange
+	// load holds how rollout files are read, from the flags and the configuration; files how
+	// they are modified, from --read-only, --dry-run and --verbose.
+	load      sessions.Options
+	files     sessions.FileOptions
+	cfg       config.Config
+	cfgErr    error
+	cfgLoaded bool
+	// redact caches the redactor once redactLoaded is set.
+	redact       *sessions.Redactor
+	redactLoaded bool
+}
+
+func (e *env) config() (config.Config, error) {
+	if !e.cfgLoaded {
+		e.cfg, e.cfgErr = loadConfig(*flagConfig)
+		if e.cfgErr != nil {
+			warnf("%v", e.cfgErr)
+		}
+		e.cfgLoaded = true
+	}
+	return e.cfg, e.cfgErr
+}
+
+// redactor returns the secret masking applied to transcripts, or nil with --no-redact.
+func (e *env) redactor() *sessions.Redactor {
+	if *flagNoRedact {
+		return nil
+	}
+	if e.redactLoaded {
+		return e.redact
+	}
+	cfg, _ := e.config()
+	r, err := cfg.Redaction.Redactor()
+	if err != nil {
+		// The config was validated on load, so this only happens for a config that failed to
+		// load; mask with the built-in patterns rather than not at all.
+		r, _ = sessions.NewRedactor(nil, true)
+	}
+	e.redact, e.redactLoaded = r, true
+	return r
+}
+
+// readTranscript reads the transcript of sess with secrets masked.
+func (e *env) readTranscript(sess sessions.Session) ([]sessions.TranscriptEntry, error) {
+	entries, err := sessions.ReadTranscript(sess)
+	if err != nil {
+		return nil, err
+	}
+	return e.redactor().Entries(entries), nil
+}
+
 func main() {
-	flag.Parse()
+	flag.Usage = usage
+	// Parse errors exit with the common error status rather than the flag package's 2, which
+	// means that no sessions were found.
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(exitError)
+	}
+	if *flagChoose {
+		*flagNoResume = true
+	}
+	if *flagOutput != "text" && *flagOutput != "json" {
+		fatalf("--output: unknown format %q (want text or json)", *flagOutput)
+	}
+
+	e := &env{files: fileOptions(os.Stderr)}
+	dirs := []string(flagSessionsDirs)
+	if len(dirs) == 0 {
+		if cfg, _ := e.config(); len(cfg.SessionsDirs) > 0 {
+			dirs = cfg.SessionsDirs
+		} else {
+			dirs = []string{""}
+		}
+	}
+	for _, dir := range dirs {
+		root, err := sessions.ResolveDir(dir)
+		if err != nil {
+			fatalf("resolve sessions dir: %v", err)
+		}
+		e.roots = append(e.roots, root)
+	}
+	e.root = e.roots[0]
+	var err error
+	now := time.Now()
+	if e.timeRange.Since, err = sessions.ParseTimeBound(*flagSince, now, false); err != nil {
+		fatalf("--since: %v", err)
+	}
+	if e.timeRange.Until, err = sessions.ParseTimeBound(*flagUntil, now, true); err != nil {
+		fatalf("--until: %v", err)
+	}
+	cfg, _ := e.config()
+	e.load = sessions.Options{
+		Excludes:       append(cfg.Exclude, flagExcludes...),
+		IgnoredEntries: cfg.IgnoreEntries,
+		ScanRange:      e.timeRange,
+		SnippetLimit:   cfg.SnippetLength,
+		LastAction:     cfg.LastAction,
+		RootHosts:      cfg.Hosts,
+	}
+	if err := e.load.Check(); err != nil {
+		fatalf("--exclude: %v", err)
+	}
 
-	root, err := sessions.ResolveDir(*flagSessionsDir)
+	logPath := *flagLogFile
+	if logPath == "" {
+		cfg, _ := e.config()
+		logPath = cfg.LogFile
+	}
+	logFile, err := setupLogging(logPath)
 	if err != nil {
-		fatalf("resolve sessions dir: %v", err)
+		warnf("%v", err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	slog.Info("start", "args", os.Args[1:], "roots", e.roots)
+
+	name, args := "ui", []string(nil)
+	if flag.NArg() > 0 {
+		name, args = flag.Arg(0), flag.Args()[1:]
+	}
+	// The flat flags predate the subcommands and are kept as shortcuts.
+	switch {
+	case *flagEmptyTrash:
+		name, args = "prune", []string{"--empty-trash"}
+	case *flagMaintain:
+		name, args = "prune", nil
+	case *flagLast:
+		name, args = "resume", append([]string{"--last"}, args...)
+	case *flagLastForCwd:
+		name, args = "resume", append([]string{"--last-for-cwd"}, args...)
+	case *flagProjectDef:
+		name, args = "resume", append([]string{"--project-default"}, args...)
+	case *flagAgain:
+		name, args = "resume", append([]string{"--again"}, args...)
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(exitError)
+	}
+	if err := cmd.run(e, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		slog.Error("command failed", "command", cmd.name, "err", err)
+		if logFile != nil {
+			logFile.Close()
+		}
+		if !errors.Is(err, errCancelled) && !errors.Is(err, errNoMatches) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns the exit status for the error a command failed with.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errCancelled):
+		return exitCancelled
+	case errors.Is(err, errNoSessions):
+		return exitNoSessions
+	}
+	return exitError
+}
+
+// warnf prints a warning to stderr unless --quiet is set.
+func warnf(format string, args ...any) {
+	if *flagQuiet {
+		return
 	}
+	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
+}
 
-	list, loadErr := sessions.Load(root)
-	var status string
-	if loadErr != nil {
-		status = loadErr.Error()
-		fmt.Fprintf(os.Stderr, "warning: %v\n", loadErr)
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: codex-sessions [flags] [command] [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(out, "\nWithout a command the interactive UI is started.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
 
-	selectedID, err := ui.Run(list, root, status)
+// finish prints or resumes the chosen session (passing extraArgs through to codex) and kicks off
+// background maintenance if enabled. A non-empty dir replaces the session's working directory,
+// e.g. because the recorded one no longer exists, and codex is started there.
+func finish(e *env, sess sessions.Session, dir string, extraArgs []string) error {
+	rememberSelection(e, sess, !*flagNoResume)
+	if *flagNoResume {
+		if *flagOutput == "json" {
+			return json.NewEncoder(os.Stdout).Encode(newSessionRecord(e, sess))
+		}
+		fmt.Println(sess.ID)
+		return nil
+	}
+
+	cfg, _ := e.config()
+	if dir != "" {
+		sess.WorkingDir = dir
+	}
+	if *flagRestoreCwd || cfg.RestoreCwd {
+		dir, extraArgs = restoreEnvironment(sess, extraArgs)
+	}
+	tmpl := *flagResumeCmd
+	if tmpl == "" {
+		tmpl = cfg.ResumeCmd
+	}
+	argv, err := resumeCommand(tmpl, *flagCodexBin, sess, extraArgs)
 	if err != nil {
-		fatalf("run ui: %v", err)
+		return err
+	}
+	resumeErr := runCodex(argv, dir)
+	if (*flagMaintainBg || cfg.MaintainAfterResume) && !*flagDryRun && !*flagReadOnly {
+		startBackgroundMaintenance(e.roots)
 	}
-	if selectedID == "" {
+	if resumeErr != nil {
+		return fmt.Errorf("resume %s: %w", sess.ID, resumeErr)
+	}
+	return nil
+}
+
+// rememberSelection records sess as the latest selection, which the UI starts on and --again
+// resumes, and when resumed is set also the resume, for the frecency sort. Failing to record it
+// only warns: the resume itself matters more.
+func rememberSelection(e *env, sess sessions.Session, resumed bool) {
+	meta, err := sidecar.Open(e.root)
+	if err != nil {
+		// Writing back a store that could not be read would drop its annotations.
+		warnf("%v", err)
 		return
 	}
+	meta.SetReadOnly(e.files.ReadOnly)
+	now := time.Now()
+	err = meta.Update(sess.ID, func(m *sidecar.Meta) {
+		m.Selected = now
+		if resumed {
+			m.AddResume(now)
+		}
+	})
+	if err != nil && !errors.Is(err, sessions.ErrReadOnly) {
+		warnf("remember selection: %v", err)
+	}
+}
 
+// startNew starts a new Codex session in dir, seeded with prompt when it is not empty.
+func startNew(dir, prompt string, extraArgs []string) error {
 	if *flagNoResume {
-		fmt.Println(selectedID)
+		fmt.Fprintf(os.Stderr, "not starting a new session in %s: --no-resume is set\n", dir)
+		return nil
+	}
+	argv := append([]string{*flagCodexBin}, extraArgs...)
+	if prompt != "" {
+		argv = append(argv, prompt)
+	}
+	if err := runCodex(argv, dir); err != nil {
+		return fmt.Errorf("new session in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// restoreEnvironment returns the directory to resume sess in and extraArgs extended with the
+// sandbox mode and approval policy the session last ran with. Settings the user passed
+// explicitly win, and a working directory that no longer exists is skipped with a warning.
+func restoreEnvironment(sess sessions.Session, extraArgs []string) (string, []string) {
+	dir := sess.WorkingDir
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			warnf("working directory %s no longer exists; resuming in the current directory", dir)
+			dir = ""
+		}
+	}
+	if sess.SandboxMode != "" && !hasFlag(extraArgs, "-s", "--sandbox") {
+		extraArgs = append(extraArgs, "--sandbox", sess.SandboxMode)
+	}
+	if sess.ApprovalPolicy != "" && !hasFlag(extraArgs, "-a", "--ask-for-approval") {
+		extraArgs = append(extraArgs, "--ask-for-approval", sess.ApprovalPolicy)
+	}
+	return dir, extraArgs
+}
+
+// hasFlag reports whether args set one of the given flags, in either "--flag value" or
+// "--flag=value" form.
+func hasFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		for _, name := range names {
+			if arg == name || strings.HasPrefix(arg, name+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mostRecent returns the most recently updated session, optionally limited to sessions whose
+// working directory is the current directory. list must be ordered newest first, as returned by
+// sessions.Load.
+func mostRecent(list []sessions.Session, inCwd bool) (sessions.Session, error) {
+	if !inCwd {
+		if len(list) == 0 {
+			return sessions.Session{}, errNoSessions
+		}
+		return list[0], nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return sessions.Session{}, fmt.Errorf("determine current directory: %w", err)
+	}
+	for _, sess := range list {
+		if sess.WorkingDir != "" && samePath(sess.WorkingDir, cwd) {
+			return sess, nil
+		}
+	}
+	return sessions.Session{}, fmt.Errorf("%w for %s", errNoSessions, cwd)
+}
+
+// samePath compares two directory paths, ignoring case on Windows where the file system does.
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(strings.TrimSpace(a)), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// fileOptions returns the file options of --read-only and --dry-run, logging file operations to
+// w when --verbose or --dry-run is set.
+func fileOptions(w io.Writer) sessions.FileOptions {
+	opts := sessions.FileOptions{ReadOnly: *flagReadOnly, DryRun: *flagDryRun}
+	if *flagVerbose || *flagDryRun {
+		opts.Log = func(msg string) {
+			fmt.Fprintln(w, msg)
+		}
+	}
+	return opts
+}
+
+// setupLogging sends structured diagnostics to path, appending to it. Without a path they are
+// discarded, since stderr belongs to the TUI while it runs.
+func setupLogging(path string) (*os.File, error) {
+	discard := slog.New(slog.DiscardHandler)
+	slog.SetDefault(discard)
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	logger := slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
+	sessions.SetLogger(logger)
+	return file, nil
+}
+
+// startBackgroundMaintenance re-executes this binary with `prune` without waiting for it, so
+// housekeeping happens after the user has already got their shell back.
+func startBackgroundMaintenance(roots []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		warnf("maintenance skipped: %v", err)
 		return
 	}
+	var args []string
+	for _, root := range roots {
+		args = append(args, "--sessions-dir", root)
+	}
+	cmd := exec.Command(exe, append(args, "prune")...)
+	slog.Info("exec", "argv", cmd.Args, "background", true)
+	if err := cmd.Start(); err != nil {
+		warnf("maintenance skipped: %v", err)
+		return
+	}
+	_ = cmd.Process.Release()
+}
+
+func newEnricher(cfg config.Config) (*enrich.Runner, error) {
+	if len(cfg.MetadataProviders) == 0 {
+		return nil, nil
+	}
+	providers, err := enrich.FromConfig(cfg.MetadataProviders)
+	if err != nil {
+		return nil, err
+	}
+	cachePath, err := enrich.DefaultCachePath()
+	if err != nil {
+		cachePath = ""
+	}
+	return enrich.NewRunner(providers, cachePath), nil
+}
+
+func loadConfig(path string) (config.Config, error) {
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			return config.Default(), err
+		}
+	}
+	return config.Load(path)
+}
 
-	if err := runCodexResume(selectedID, *flagCodexBin, flag.Args()); err != nil {
-		fatalf("codex resume %s: %v", selectedID, err)
+// resolveCodexBin finds the Codex executable, or whichever program a resume command template
+// starts. On Windows the npm install provides codex.cmd next to a PowerShell shim, so the usual
+// extensions are tried explicitly and reported clearly when none is on PATH.
+func resolveCodexBin(codexBin string) (string, error) {
+	if runtime.GOOS != "windows" || filepath.Ext(codexBin) != "" {
+		return codexBin, nil
 	}
+	for _, ext := range []string{".exe", ".cmd", ".bat"} {
+		if path, err := exec.LookPath(codexBin + ext); err == nil {
+			return path, nil
+		}
+	}
+	if path, err := exec.LookPath(codexBin); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s not found on PATH (tried .exe, .cmd and .bat); set --codex-bin", codexBin)
 }
 
-func runCodexResume(sessionID, codexBin string, extraArgs []string) error {
-	args := append([]string{"resume", sessionID}, extraArgs...)
-	cmd := exec.Command(codexBin, args...)
+// runCodex runs argv as a child process attached to the terminal rather than replacing this
+// process, which works the same way on Windows, where exec(2) does not exist. A non-empty dir
+// sets the child's working directory.
+func runCodex(argv []string, dir string) error {
+	bin, err := resolveCodexBin(argv[0])
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(bin, argv[1:]...)
+	cmd.Dir = dir
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	slog.Info("exec", "argv", cmd.Args, "dir", dir)
+	// Ctrl+C reaches both processes; let the child decide what it means instead of exiting under it.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	start := time.Now()
+	err = cmd.Run()
+	slog.Info("exec finished", "argv", cmd.Args, "duration", time.Since(start), "err", err)
+	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			return fmt.Errorf("command exited with status %d", exitErr.ExitCode())
@@ -66,6 +559,16 @@ func runCodexResume(sessionID, codexBin string, extraArgs []string) error {
 	return nil
 }
 
+// flagPassed reports whether the named global flag was given on the command line, as opposed to
+// left at its default.
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		passed = passed || f.Name == name
+	})
+	return passed
+}
+
 func fatalf(format string, args ...any) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 	os.Exit(1)
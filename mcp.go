@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision implemented by the mcp command.
+const mcpProtocolVersion = "2025-06-18"
+
+// maxTranscriptChars caps get_transcript output so a long session does not flood the caller's
+// context window.
+const maxTranscriptChars = 60000
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes used by the MCP server.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "search_sessions",
+		Description: "Full-text search over the transcripts of past Codex sessions. Returns matching sessions with snippets of the matching entries.",
+		InputSchema: objectSchema(map[string]any{
+			"query": stringProperty("Text to look for, case-insensitive."),
+			"limit": integerProperty("Maximum number of sessions to return (default 10)."),
+		}, "query"),
+	},
+	{
+		Name:        "list_sessions",
+		Description: "List past Codex sessions, newest first, optionally filtered by ID, working directory or last action.",
+		InputSchema: objectSchema(map[string]any{
+			"query": stringProperty("Optional case-insensitive filter."),
+			"limit": integerProperty("Maximum number of sessions to return (default 20)."),
+		}),
+	},
+	{
+		Name:        "get_transcript",
+		Description: "Return the transcript of one session as Markdown.",
+		InputSchema: objectSchema(map[string]any{
+			"id": stringProperty("Session ID or unique ID prefix."),
+		}, "id"),
+	},
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func integerProperty(description string) map[string]any {
+	return map[string]any{"type": "integer", "description": description}
+}
+
+func runMCP(e *env, args []string) error {
+	fs := newFlagSet("mcp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return serveMCP(e, os.Stdin, os.Stdout)
+}
+
+// serveMCP answers newline-delimited JSON-RPC messages from r until it is closed.
+func serveMCP(e *env, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		if len(req.ID) == 0 {
+			// Notifications such as notifications/initialized need no answer.
+			continue
+		}
+		result, rpcErr := handleMCP(e, req)
+		if err := enc.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func handleMCP(e *env, req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "codex-sessions", "version": "1"},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string `json:"name"`
+			Arguments struct {
+				Query string `json:"query"`
+				ID    string `json:"id"`
+				Limit int    `json:"limit"`
+			} `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		args := params.Arguments
+		text, err := callMCPTool(e, params.Name, strings.ToLower(args.Query), args.ID, args.Limit)
+		if errors.Is(err, errUnknownTool) {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+func callMCPTool(e *env, name, query, id string, limit int) (string, error) {
+	switch name {
+	case "search_sessions":
+		if query == "" {
+			return "", errors.New("query is required")
+		}
+		list, _ := contentCandidates(e, visibleSessions(e), substringPattern(query))
+		return marshalText(searchTranscripts(e, list, query, defaultLimit(limit, 10)))
+	case "list_sessions":
+		return marshalText(filterRecords(e, visibleSessions(e), query, defaultLimit(limit, 20)))
+	case "get_transcript":
+		sess, err := resolveSession(visibleSessions(e), id)
+		if err != nil {
+			return "", err
+		}
+		entries, err := e.readTranscript(sess)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := sessions.WriteMarkdown(&b, sess, entries); err != nil {
+			return "", err
+		}
+		text := b.String()
+		if len(text) > maxTranscriptChars {
+			cut := maxTranscriptChars
+			for !isRuneStart(text[cut]) {
+				cut--
+			}
+			text = text[:cut] + "\n\n[transcript truncated]\n"
+		}
+		return text, nil
+	default:
+		return "", fmt.Errorf("%w %q", errUnknownTool, name)
+	}
+}
+
+func defaultLimit(limit, fallback int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+func marshalText(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,23 @@
+package sessions
+
+import (
+	"os"
+	"time"
+)
+
+// ActiveWindow is how recently a rollout file must have been written for its session to count
+// as in use. Codex appends to the rollout throughout a turn, so a live session keeps its file
+// fresh while it works.
+const ActiveWindow = 2 * time.Minute
+
+// IsActive reports whether a Codex process is probably still writing to sess, judged by the
+// modification time of its rollout files.
+func IsActive(sess Session) bool {
+	cutoff := time.Now().Add(-ActiveWindow)
+	for _, path := range sess.FilePaths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(cutoff) {
+			return true
+		}
+	}
+	return false
+}
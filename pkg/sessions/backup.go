@@ -0,0 +1,189 @@
+package sessions
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupPrefix     = "codex-sessions-"
+	backupSuffix     = ".tar.gz"
+	backupTimeLayout = "20060102-150405"
+)
+
+// Backup is a snapshot archive written by CreateBackup.
+type Backup struct {
+	Path string
+	Time time.Time
+}
+
+// CreateBackup writes a gzip-compressed tar snapshot of every file below the given sessions roots
+// (including the trash and annotations) to destDir, then removes the oldest snapshots so at most
+// keep remain; keep <= 0 disables rotation. With several roots each one is stored below
+// "root<N>/" in the archive. The sessions roots themselves are only read.
+func CreateBackup(destDir string, roots []string, keep int, opts FileOptions) (Backup, []string, error) {
+	now := time.Now()
+	backup := Backup{
+		Path: filepath.Join(destDir, backupPrefix+now.UTC().Format(backupTimeLayout)+backupSuffix),
+		Time: now,
+	}
+	opts.logOp("write %s", backup.Path)
+	if !opts.DryRun {
+		if err := writeBackup(backup.Path, roots); err != nil {
+			return Backup{}, nil, err
+		}
+	}
+	if keep <= 0 {
+		return backup, nil, nil
+	}
+	if opts.DryRun {
+		// The new snapshot was not written but counts towards keep.
+		keep--
+	}
+	removed, err := rotateBackups(destDir, keep, opts)
+	return backup, removed, err
+}
+
+func writeBackup(path string, roots []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create backup dir: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create backup: %w", err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for i, root := range roots {
+		prefix := ""
+		if len(roots) > 1 {
+			prefix = fmt.Sprintf("root%d/", i+1)
+		}
+		if err = archiveTree(tw, root, prefix); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write backup: %w", err)
+	}
+	return nil
+}
+
+// archiveTree adds the regular files and directories below root to tw, naming them relative to
+// root with prefix prepended.
+func archiveTree(tw *tar.Writer, root, prefix string) error {
+	return walkTree(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if path == root && errors.Is(walkErr, os.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if path == root || !(d.IsDir() || d.Type().IsRegular()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = prefix + filepath.ToSlash(rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+}
+
+// rotateBackups removes all but the keep newest snapshots in dir and returns their paths.
+func rotateBackups(dir string, keep int, opts FileOptions) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil || len(backups) <= keep {
+		return nil, err
+	}
+	var removed []string
+	var combined error
+	for _, backup := range backups[keep:] {
+		opts.logOp("remove %s", backup.Path)
+		if !opts.DryRun {
+			if err := os.Remove(backup.Path); err != nil {
+				combined = errors.Join(combined, err)
+				continue
+			}
+		}
+		removed = append(removed, backup.Path)
+	}
+	return removed, combined
+}
+
+// ListBackups returns the snapshots in dir, newest first. A missing dir yields no backups.
+func ListBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+		t, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), Time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
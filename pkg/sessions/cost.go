@@ -0,0 +1,59 @@
+package sessions
+
+import "strings"
+
+// Price is what a model charges in US dollars per million tokens. CachedInput applies to cached
+// input tokens and defaults to Input when zero.
+type Price struct {
+	Input       float64 `json:"input"`
+	CachedInput float64 `json:"cached_input,omitempty"`
+	Output      float64 `json:"output"`
+}
+
+// Cost returns the price of usage in US dollars. Reasoning tokens are billed as output and are
+// already part of OutputTokens.
+func (p Price) Cost(usage TokenUsage) float64 {
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	uncached := usage.InputTokens - usage.CachedInputTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.Input + float64(usage.CachedInputTokens)*cachedPrice + float64(usage.OutputTokens)*p.Output) / 1e6
+}
+
+// PriceTable maps model names to prices. A key also covers every model it is a prefix of, so
+// "gpt-5" prices "gpt-5-codex" unless that has an entry of its own.
+type PriceTable map[string]Price
+
+// Lookup returns the price of model: an exact entry, or else the one with the longest key that
+// is a prefix of model. Case is ignored.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	if model == "" {
+		return Price{}, false
+	}
+	var (
+		best    Price
+		bestLen = -1
+	)
+	for key, price := range t {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(model, key) && len(key) > bestLen {
+			best, bestLen = price, len(key)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// Cost estimates what sess cost from its token usage and the price of its model. It reports
+// false when the model has no price. Sessions that switched models are priced at the last one.
+func (t PriceTable) Cost(sess Session) (float64, bool) {
+	price, ok := t.Lookup(sess.Model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(sess.Tokens), true
+}
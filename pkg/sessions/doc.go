@@ -0,0 +1,21 @@
+// Package sessions discovers and manipulates the rollout logs the Codex CLI writes under
+// ~/.codex/sessions.
+//
+// A session is identified by the ID in its session_meta entry and may span several rollout
+// files when it was resumed. The main entry points are:
+//
+//   - Load and Stream walk a sessions directory and aggregate rollout files into Sessions;
+//     Parse reads a single file.
+//   - ReadTranscript returns the readable conversation of a session; Export and WriteMarkdown
+//     render it as Markdown.
+//   - DeleteFiles moves a session to the trash (see TrashFiles, RestoreTrash and EmptyTrash),
+//     PurgeFiles removes it permanently and ArchiveFiles moves it to the archive directory.
+//   - Watch follows a sessions directory and reports created, updated and deleted sessions.
+//
+// Functions reading sessions take an Options, which selects the files read and how they are
+// summarised, so concurrent loads with different settings do not interfere.
+//
+// Functions that modify the filesystem take a FileOptions, which enables read-only mode, a dry
+// run and logging of every file operation, and refuse to touch files outside the sessions root
+// (see CheckWithinRoot).
+package sessions
@@ -0,0 +1,101 @@
+package sessions
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// fileDigest is the content hash of a file as of its size and modification time.
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// digests caches the content hashes of rollout files by path, as Watch merges every session
+// again after each scan. Entries of files that are gone are dropped, so a long-running watch
+// does not keep every file it ever hashed.
+var (
+	digestsMu sync.Mutex
+	digests   = map[string]fileDigest{}
+)
+
+// contentDigest returns the hash of the file at path, reading it only when it changed since it
+// was last hashed.
+func contentDigest(path string) (fileDigest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		forgetDigest(path)
+		return fileDigest{}, err
+	}
+	digestsMu.Lock()
+	cached, ok := digests[path]
+	digestsMu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fileDigest{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fileDigest{}, err
+	}
+	digest := fileDigest{size: info.Size(), modTime: info.ModTime()}
+	h.Sum(digest.sum[:0])
+	digestsMu.Lock()
+	digests[path] = digest
+	digestsMu.Unlock()
+	return digest, nil
+}
+
+// forgetDigest drops the cached hash of the file at path.
+func forgetDigest(path string) {
+	digestsMu.Lock()
+	delete(digests, path)
+	digestsMu.Unlock()
+}
+
+// duplicateOf returns the file among paths whose content is identical to that of path, such as
+// the original of a backup copy, or "" when there is none. Only files of the same size are
+// hashed.
+func duplicateOf(paths []string, path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	var digest *fileDigest
+	for _, other := range paths {
+		if other == path {
+			continue
+		}
+		if otherInfo, err := os.Stat(other); err != nil || otherInfo.Size() != info.Size() {
+			continue
+		}
+		if digest == nil {
+			d, err := contentDigest(path)
+			if err != nil {
+				return ""
+			}
+			digest = &d
+		}
+		if d, err := contentDigest(other); err == nil && d.sum == digest.sum {
+			return other
+		}
+	}
+	return ""
+}
+
+// TrashDuplicates moves the DuplicateFiles of sess into a new trash entry, keeping the files the
+// session was loaded from.
+func TrashDuplicates(sess Session, sessionsRoot string, opts FileOptions) (TrashEntry, error) {
+	dup := sess.Snapshot()
+	dup.FilePaths, dup.DuplicateFiles = slices.Clone(sess.DuplicateFiles), nil
+	return TrashFiles(dup, sessionsRoot, opts)
+}
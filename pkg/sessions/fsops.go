@@ -0,0 +1,163 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileOptions controls how operations that modify the sessions tree (delete, archive, trash,
+// maintenance) touch the filesystem. Each such function takes the options of its call; the zero
+// value performs every operation silently.
+type FileOptions struct {
+	// ReadOnly refuses every operation that would modify the sessions tree with ErrReadOnly.
+	ReadOnly bool
+	// DryRun reports operations through Log without performing them.
+	DryRun bool
+	// Log, when set, receives a line for every file operation performed or planned.
+	Log func(msg string)
+}
+
+// ErrReadOnly is returned by operations that would modify the sessions tree in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// ErrOutsideRoot is returned by operations that would move or remove a session file that does
+// not lie inside the sessions root.
+var ErrOutsideRoot = errors.New("outside the sessions root")
+
+// CheckWithinRoot returns an error wrapping ErrOutsideRoot unless every file of sess, duplicates
+// included, lies inside sessionsRoot once symbolic links are resolved. It guards against
+// removing files elsewhere when a sessions root is given that is not one, and refuses files in
+// directories linked into the root from outside, which belong to another tree.
+func CheckWithinRoot(sess Session, sessionsRoot string) error {
+	if strings.TrimSpace(sessionsRoot) == "" {
+		return fmt.Errorf("no sessions root: %w", ErrOutsideRoot)
+	}
+	root, err := filepath.EvalSymlinks(sessionsRoot)
+	if err != nil {
+		return fmt.Errorf("resolve sessions root: %w", err)
+	}
+	for _, path := range slices.Concat(sess.FilePaths, sess.DuplicateFiles) {
+		// The file itself may be a link; moving or removing it leaves its target alone.
+		dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("resolve %s: %w", path, err)
+		}
+		if !below(root, filepath.Join(dir, filepath.Base(path))) {
+			return fmt.Errorf("%s: %w %s", path, ErrOutsideRoot, sessionsRoot)
+		}
+	}
+	return nil
+}
+
+// below reports whether path lies strictly inside dir. Both must be resolved already.
+func below(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolvePath returns the absolute form of path with symbolic links resolved, for a path that
+// need not exist yet: links are resolved in its longest existing ancestor.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			slices.Reverse(rest)
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || filepath.Dir(dir) == dir {
+			return "", err
+		}
+		rest = append(rest, filepath.Base(dir))
+	}
+}
+
+func (f FileOptions) writable() error {
+	if f.ReadOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+func (f FileOptions) logOp(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Info("file operation", "op", msg, "dry_run", f.DryRun)
+	if f.Log == nil {
+		return
+	}
+	if f.DryRun {
+		msg = "dry-run: " + msg
+	}
+	f.Log(msg)
+}
+
+func (f FileOptions) removeFile(path string) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	f.logOp("remove %s", path)
+	if f.DryRun {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+func (f FileOptions) removeAll(path string) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	f.logOp("remove -r %s", path)
+	if f.DryRun {
+		return nil
+	}
+	return os.RemoveAll(path)
+}
+
+func (f FileOptions) renameFile(src, dst string) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	f.logOp("move %s -> %s", src, dst)
+	if f.DryRun {
+		return nil
+	}
+	return os.Rename(src, dst)
+}
+
+func (f FileOptions) makeDirs(path string) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	f.logOp("mkdir -p %s", path)
+	if f.DryRun {
+		return nil
+	}
+	return os.MkdirAll(path, 0o755)
+}
+
+func (f FileOptions) writeFile(path string, data []byte) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	f.logOp("write %s", path)
+	if f.DryRun {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,100 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// grepWorkers is the number of sessions Grep reads at the same time.
+const grepWorkers = 8
+
+// GrepMatch is a line of a transcript entry matched by Grep.
+type GrepMatch struct {
+	Session Session
+	// Index is the position of the entry in the session transcript.
+	Index int
+	Entry TranscriptEntry
+	// Line is the matching line of the entry text and LineNumber its 1-based position in the
+	// text. Before and After hold up to the requested number of neighbouring lines of the text.
+	Line          string
+	LineNumber    int
+	Before, After []string
+}
+
+// Grep searches the transcripts returned by read for lines matching re, reading several
+// sessions in parallel. Matches are returned in the order of list and, within a session, of its
+// transcript, each with up to contextLines lines of the entry text around it. Sessions that
+// cannot be read are reported in the returned error along with the matches found elsewhere.
+func Grep(ctx context.Context, list []Session, re *regexp.Regexp, contextLines int, read func(Session) ([]TranscriptEntry, error)) ([]GrepMatch, error) {
+	results := make([][]GrepMatch, len(list))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		combined error
+	)
+	for i := 0; i < grepWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entries, err := read(list[i])
+				if err != nil {
+					errMu.Lock()
+					combined = errors.Join(combined, err)
+					errMu.Unlock()
+					continue
+				}
+				results[i] = grepEntries(list[i], entries, re, contextLines)
+			}
+		}()
+	}
+
+feed:
+	for i := range list {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var matches []GrepMatch
+	for _, found := range results {
+		matches = append(matches, found...)
+	}
+	if ctx.Err() != nil {
+		return matches, ctx.Err()
+	}
+	return matches, combined
+}
+
+func grepEntries(sess Session, entries []TranscriptEntry, re *regexp.Regexp, contextLines int) []GrepMatch {
+	var matches []GrepMatch
+	for i, entry := range entries {
+		if !re.MatchString(entry.Text) {
+			continue
+		}
+		lines := strings.Split(entry.Text, "\n")
+		for n, line := range lines {
+			if !re.MatchString(line) {
+				continue
+			}
+			matches = append(matches, GrepMatch{
+				Session:    sess,
+				Index:      i,
+				Entry:      entry,
+				Line:       line,
+				LineNumber: n + 1,
+				Before:     lines[max(0, n-contextLines):n],
+				After:      lines[n+1 : min(len(lines), n+1+contextLines)],
+			})
+		}
+	}
+	return matches
+}
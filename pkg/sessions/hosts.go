@@ -0,0 +1,12 @@
+package sessions
+
+import "path/filepath"
+
+// setRoot records root as the sessions root s was loaded from, naming its host after the root
+// in the normalized options o when the rollout did not.
+func (s *Session) setRoot(root string, o *Options) {
+	s.Root = root
+	if s.Host == "" {
+		s.Host = o.RootHosts[filepath.Clean(root)]
+	}
+}
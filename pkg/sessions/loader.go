@@ -0,0 +1,1067 @@
+package sessions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	defaultRelativeSessionsDir = ".codex/sessions"
+	maxLineSize                = 16 * 1024 * 1024 // 16 MiB, to safely fit large encrypted payloads
+	// DefaultSnippetLimit is the number of display columns snippets such as LastAction are
+	// truncated to unless the options say otherwise.
+	DefaultSnippetLimit = 160
+)
+
+// LastActionMode chooses what the LastAction of a session describes.
+type LastActionMode string
+
+const (
+	// LastActionEntry describes the latest entry of the session, whatever it is.
+	LastActionEntry LastActionMode = "entry"
+	// LastActionPrompt describes the latest user prompt, falling back to the latest entry for
+	// sessions without one, so token counts and tool chatter do not hide what was asked.
+	LastActionPrompt LastActionMode = "prompt"
+)
+
+// ignored reports whether entry is of a type in o.IgnoredEntries.
+func (o *Options) ignored(entry decodedEntry) bool {
+	return len(o.IgnoredEntries) > 0 && slices.Contains(o.IgnoredEntries, entry.payloadType())
+}
+
+// datedDir returns the time span covered by a dated directory: the rel path of a year, a month
+// or a day below a sessions root, such as "2025/01" for January 2025, in local time.
+func datedDir(rel string) (start, end time.Time, ok bool) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	layouts := []string{"2006", "2006/01", "2006/01/02"}
+	if len(parts) > len(layouts) {
+		return time.Time{}, time.Time{}, false
+	}
+	for i, part := range parts {
+		width := 2
+		if i == 0 {
+			width = 4
+		}
+		if len(part) != width || strings.Trim(part, "0123456789") != "" {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	start, err := time.ParseInLocation(layouts[len(parts)-1], strings.Join(parts, "/"), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	switch len(parts) {
+	case 1:
+		end = start.AddDate(1, 0, 0)
+	case 2:
+		end = start.AddDate(0, 1, 0)
+	default:
+		end = start.AddDate(0, 0, 1)
+	}
+	return start, end, true
+}
+
+// skipDatedDir reports whether the directory at path below root only holds rollouts started
+// after o.ScanRange, which cannot have been updated within it.
+func (o *Options) skipDatedDir(root, path string) bool {
+	if o.ScanRange.Until.IsZero() {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	start, _, ok := datedDir(rel)
+	return ok && !start.Before(o.ScanRange.Until)
+}
+
+// skipDatedFile reports whether the rollout file at path below root was started before
+// o.ScanRange and not modified since its beginning.
+func (o *Options) skipDatedFile(root, path string, d os.DirEntry) bool {
+	if o.ScanRange.Since.IsZero() {
+		return false
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	if _, end, ok := datedDir(rel); !ok || end.After(o.ScanRange.Since) {
+		return false
+	}
+	info, err := d.Info()
+	return err == nil && info.ModTime().Before(o.ScanRange.Since)
+}
+
+// applyLastAction replaces the last action of session by its latest prompt when o asks for
+// LastActionPrompt.
+func (o *Options) applyLastAction(session *Session) {
+	if o.LastAction == LastActionPrompt && session.LastUserMessage != "" {
+		session.LastAction = "user: " + session.LastUserMessage
+	}
+}
+
+// Load discovers and parses Codex CLI sessions located under sessionsDir, read with opts. When
+// sessionsDir is empty, the default path of "~/.codex/sessions" is used.
+func Load(sessionsDir string, opts Options) ([]Session, error) {
+	return LoadRootsContext(context.Background(), []string{sessionsDir}, opts)
+}
+
+// LoadContext is like Load but stops scanning when ctx is cancelled, returning ctx.Err() and
+// no sessions.
+func LoadContext(ctx context.Context, sessionsDir string, opts Options) ([]Session, error) {
+	return LoadRootsContext(ctx, []string{sessionsDir}, opts)
+}
+
+// LoadRoots loads the sessions of several sessions roots into a single list. Each session is
+// tagged with the root it was first found in; a session present in more than one root is merged.
+func LoadRoots(sessionsDirs []string, opts Options) ([]Session, error) {
+	return LoadRootsContext(context.Background(), sessionsDirs, opts)
+}
+
+// LoadRootsContext is like LoadRoots but stops scanning when ctx is cancelled.
+func LoadRootsContext(ctx context.Context, sessionsDirs []string, opts Options) ([]Session, error) {
+	if err := opts.Check(); err != nil {
+		return nil, err
+	}
+	list, _, err := loadRoots(ctx, sessionsDirs, opts.Normalized())
+	return list, err
+}
+
+// Parse reads a single rollout file with opts; its Excludes, ScanRange and FileLimit do not
+// apply. Sessions resumed into several files are only complete when all of them are merged,
+// which Load and Stream do.
+func Parse(path string, opts Options) (Session, error) {
+	opts = opts.Normalized()
+	session, err := parseSessionFile(path, &opts)
+	if err != nil {
+		return Session{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return *session, nil
+}
+
+// Stream parses sessions like Load but calls fn as soon as each rollout file has been parsed,
+// instead of after the whole tree has been read. A session split across several rollout files is
+// reported again, merged with what was seen so far, every time another of its files is parsed;
+// the last report for an ID is the complete session. Files are visited in lexical order, so
+// reports are not sorted by update time.
+func Stream(sessionsDir string, opts Options, fn func(Session)) error {
+	return StreamRootsContext(context.Background(), []string{sessionsDir}, opts, fn)
+}
+
+// StreamRoots streams the sessions of several sessions roots, one root after the other.
+func StreamRoots(sessionsDirs []string, opts Options, fn func(Session)) error {
+	return StreamRootsContext(context.Background(), sessionsDirs, opts, fn)
+}
+
+// StreamRootsContext is like StreamRoots but stops after the current file when ctx is cancelled
+// and returns ctx.Err().
+func StreamRootsContext(ctx context.Context, sessionsDirs []string, opts Options, fn func(Session)) error {
+	if err := opts.Check(); err != nil {
+		return err
+	}
+	opts = opts.Normalized()
+	byID := make(map[string]*Session)
+	var combined error
+	for _, dir := range sessionsDirs {
+		err := walkSessions(ctx, dir, &opts, byID, func(sess *Session) {
+			fn(sess.Snapshot())
+		}, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			combined = errors.Join(combined, err)
+		}
+	}
+	return combined
+}
+
+// walkSessions parses every rollout file under sessionsDir with the normalized options o into
+// byID, calling merged (when not nil) with the updated session after each file and recording
+// parse costs in metrics (when not nil). Parse and walk errors of individual files are joined
+// into the returned error without stopping the walk; cancelling ctx stops it.
+func walkSessions(ctx context.Context, sessionsDir string, o *Options, byID map[string]*Session, merged func(*Session), metrics *Metrics) error {
+	root, err := ResolveDir(sessionsDir)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("stat sessions dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("sessions path %q is not a directory", root)
+	}
+
+	var combinedErr error
+	// pending holds unparsable files not yet matched to a session by name.
+	var pending []string
+	logger.Debug("scanning sessions root", "root", root)
+	parse := func(path string, d os.DirEntry) {
+		start := time.Now()
+		session, err := parseSessionFile(path, o)
+		if err != nil {
+			logger.Warn("skipped rollout file", "path", path, "err", err)
+			combinedErr = errors.Join(combinedErr, fmt.Errorf("parse %s: %w", path, err))
+			for id, sess := range byID {
+				if fileOfSession(path, id) {
+					sess.SkippedFiles = append(sess.SkippedFiles, path)
+					if merged != nil {
+						merged(sess)
+					}
+					return
+				}
+			}
+			pending = append(pending, path)
+			return
+		}
+		elapsed := time.Since(start)
+		logger.Debug("parsed rollout file", "path", path, "id", session.ID, "duration", elapsed)
+		if metrics != nil {
+			var size int64
+			if info, err := d.Info(); err == nil {
+				size = info.Size()
+			}
+			metrics.record(FileMetric{Path: path, Bytes: size, Duration: elapsed})
+		}
+		session.setRoot(root, o)
+
+		mergeInto(byID, session, o)
+		pending = slices.DeleteFunc(pending, func(skipped string) bool {
+			if fileOfSession(skipped, session.ID) {
+				byID[session.ID].SkippedFiles = append(byID[session.ID].SkippedFiles, skipped)
+				return true
+			}
+			return false
+		})
+		if merged != nil {
+			merged(byID[session.ID])
+		}
+	}
+	// With a file limit the walk only lists the files; the most recent are parsed afterwards.
+	var listed []listedFile
+	err = walkTree(root, func(path string, d os.DirEntry, walkErr error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walkErr != nil {
+			combinedErr = errors.Join(combinedErr, fmt.Errorf("walk %s: %w", path, walkErr))
+			return nil
+		}
+		if d.IsDir() {
+			if isTrashDir(root, path) || o.skipDatedDir(root, path) || o.excluded(root, path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".jsonl" || o.excluded(root, path, false) || o.skipDatedFile(root, path, d) {
+			return nil
+		}
+		if o.FileLimit > 0 {
+			file := listedFile{path: path, entry: d}
+			if info, err := d.Info(); err == nil {
+				file.modified = info.ModTime()
+			}
+			listed = append(listed, file)
+			return nil
+		}
+		parse(path, d)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if o.FileLimit > 0 {
+		slices.SortStableFunc(listed, func(a, b listedFile) int {
+			return b.modified.Compare(a.modified)
+		})
+		if len(listed) > o.FileLimit {
+			logger.Debug("limited rollout files", "root", root, "files", len(listed), "limit", o.FileLimit)
+			listed = listed[:o.FileLimit]
+		}
+		for _, file := range listed {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			parse(file.path, file.entry)
+		}
+	}
+	return combinedErr
+}
+
+// listedFile is a rollout file found by a walk with a file limit.
+type listedFile struct {
+	path     string
+	entry    os.DirEntry
+	modified time.Time
+}
+
+// fileOfSession reports whether the rollout file name at path ends with id, as Codex names them
+// "rollout-<timestamp>-<id>.jsonl".
+func fileOfSession(path, id string) bool {
+	if id == "" {
+		return false
+	}
+	base := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	return strings.HasSuffix(base, "-"+id)
+}
+
+// mergeInto adds session to byID, merging it with a previously seen rollout of the same ID read
+// with the options o.
+func mergeInto(byID map[string]*Session, session *Session, o *Options) {
+	existing := byID[session.ID]
+	if existing == nil {
+		copySession := session.Snapshot()
+		byID[session.ID] = &copySession
+		return
+	}
+	// A copy of a file merged already would count everything twice.
+	if len(session.FilePaths) == 1 && !contains(existing.FilePaths, session.FilePaths[0]) {
+		if path := session.FilePaths[0]; duplicateOf(existing.FilePaths, path) != "" {
+			if !contains(existing.DuplicateFiles, path) {
+				existing.DuplicateFiles = append(existing.DuplicateFiles, path)
+			}
+			return
+		}
+	}
+
+	// Merge data favouring the latest metadata.
+	earlier := session.CreatedAt.Before(existing.CreatedAt)
+	if earlier || existing.CreatedAt.IsZero() {
+		existing.CreatedAt = session.CreatedAt
+	}
+	if session.UpdatedAt.After(existing.UpdatedAt) {
+		existing.UpdatedAt = session.UpdatedAt
+		existing.LastAction = session.LastAction
+		existing.EndState = session.EndState
+		if session.LastUserMessage != "" {
+			existing.LastUserMessage = session.LastUserMessage
+		}
+		if session.LastAssistantMessage != "" {
+			existing.LastAssistantMessage = session.LastAssistantMessage
+		}
+		if session.WorkingDir != "" {
+			existing.WorkingDir = session.WorkingDir
+		}
+		if session.Model != "" {
+			existing.Model = session.Model
+		}
+		if session.Host != "" {
+			existing.Host = session.Host
+		}
+		if session.SandboxMode != "" {
+			existing.SandboxMode = session.SandboxMode
+		}
+		if session.ApprovalPolicy != "" {
+			existing.ApprovalPolicy = session.ApprovalPolicy
+		}
+	} else {
+		if existing.WorkingDir == "" && session.WorkingDir != "" {
+			existing.WorkingDir = session.WorkingDir
+		}
+		if existing.Model == "" {
+			existing.Model = session.Model
+		}
+		if existing.Host == "" {
+			existing.Host = session.Host
+		}
+		if existing.SandboxMode == "" {
+			existing.SandboxMode = session.SandboxMode
+		}
+		if existing.ApprovalPolicy == "" {
+			existing.ApprovalPolicy = session.ApprovalPolicy
+		}
+		if existing.LastUserMessage == "" {
+			existing.LastUserMessage = session.LastUserMessage
+		}
+		if existing.LastAssistantMessage == "" {
+			existing.LastAssistantMessage = session.LastAssistantMessage
+		}
+	}
+	// The newest rollout may not have a prompt of its own.
+	o.applyLastAction(existing)
+
+	if existing.ParentID == "" {
+		existing.ParentID = session.ParentID
+	}
+	// The first prompt is the one of the earliest rollout.
+	if session.PromptHash != "" && (existing.PromptHash == "" || earlier) {
+		existing.PromptHash = session.PromptHash
+	}
+	for _, fp := range session.SkippedFiles {
+		if !contains(existing.SkippedFiles, fp) {
+			existing.SkippedFiles = append(existing.SkippedFiles, fp)
+		}
+	}
+
+	for _, fp := range session.FilePaths {
+		if !contains(existing.FilePaths, fp) {
+			existing.FilePaths = append(existing.FilePaths, fp)
+		}
+	}
+	existing.UserMessages += session.UserMessages
+	existing.AssistantMessages += session.AssistantMessages
+	existing.Tokens.add(session.Tokens)
+	existing.Patches.merge(session.Patches)
+	existing.Counts.add(session.Counts)
+}
+
+// collect flattens byID into a slice ordered by most recent update.
+func collect(byID map[string]*Session) []Session {
+	sessions := make([]Session, 0, len(byID))
+	for _, s := range byID {
+		// Ensure FilePaths sorted for determinism.
+		sort.Strings(s.FilePaths)
+		sessions = append(sessions, *s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions
+}
+
+// CreateRoot creates an empty sessions directory at dir, honouring read-only and dry-run mode.
+func CreateRoot(dir string, opts FileOptions) error {
+	if err := opts.makeDirs(dir); err != nil {
+		return fmt.Errorf("create sessions dir: %w", err)
+	}
+	return nil
+}
+
+// ResolveDir returns the absolute directory where Codex session logs are stored. When dir is empty,
+// the first of these is used: $CODEX_SESSIONS_DIR, "$CODEX_HOME/sessions",
+// "$XDG_DATA_HOME/codex/sessions" if that directory exists, and "~/.codex/sessions".
+func ResolveDir(dir string) (string, error) {
+	if dir != "" {
+		return filepath.Clean(dir), nil
+	}
+	if env := os.Getenv("CODEX_SESSIONS_DIR"); env != "" {
+		return filepath.Clean(env), nil
+	}
+	if env := os.Getenv("CODEX_HOME"); env != "" {
+		return filepath.Join(env, "sessions"), nil
+	}
+	if env := os.Getenv("XDG_DATA_HOME"); env != "" && filepath.IsAbs(env) {
+		candidate := filepath.Join(env, "codex", "sessions")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("detect user home: %w", err)
+	}
+	return filepath.Join(home, filepath.FromSlash(defaultRelativeSessionsDir)), nil
+}
+
+// isTrashDir reports whether path is the trash directory of root, which Load and Watch skip.
+func isTrashDir(root, path string) bool {
+	return path == TrashDir(root)
+}
+
+// parseSessionFile reads the rollout file at path with the normalized options o.
+func parseSessionFile(path string, o *Options) (*Session, error) {
+	session := &Session{
+		FilePaths: []string{path},
+	}
+
+	var (
+		createdSet bool
+		lastTS     time.Time
+	)
+
+	err := forEachEntry(path, func(entry logEntry) error {
+		ts, tsErr := parseTimestamp(entry.Timestamp)
+		if tsErr != nil {
+			ts = time.Time{}
+		}
+
+		switch entry.Type {
+		case "session_meta":
+			var payload sessionMetaPayload
+			if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+				return fmt.Errorf("decode session_meta payload: %w", err)
+			}
+			session.ID = payload.ID
+			session.WorkingDir = payload.CWD
+			if payload.Hostname != "" {
+				session.Host = payload.Hostname
+			}
+			if session.ParentID == "" {
+				session.ParentID = payload.parentID()
+			}
+			if payload.Model != "" {
+				session.Model = payload.Model
+			}
+			if pTs, pErr := parseTimestamp(payload.Timestamp); pErr == nil {
+				session.CreatedAt = pTs
+				createdSet = true
+			}
+		case "turn_context":
+			var payload turnContextPayload
+			if err := json.Unmarshal(entry.Payload, &payload); err == nil {
+				if payload.Model != "" {
+					session.Model = payload.Model
+				}
+				if mode := payload.sandboxMode(); mode != "" {
+					session.SandboxMode = mode
+				}
+				if payload.ApprovalPolicy != "" {
+					session.ApprovalPolicy = payload.ApprovalPolicy
+				}
+			}
+		}
+
+		decoded := decodeEntry(entry)
+		switch {
+		case decoded.item != nil:
+			countMessage(session, decoded.item, o.snippetLimit())
+			countPatch(session, decoded.item)
+			countEntry(session, decoded)
+		case decoded.event != nil:
+			// The counters are cumulative, so the last report of the file is its total.
+			if usage, ok := decoded.event.tokenUsage(); ok {
+				session.Tokens = usage
+			}
+			countEntry(session, decoded)
+		}
+
+		if o.ignored(decoded) {
+			return nil
+		}
+		if ts.After(lastTS) || lastTS.IsZero() {
+			lastTS = ts
+			if desc := describeEntry(decoded, o.snippetLimit()); desc != "" {
+				session.LastAction = desc
+			} else if entry.Type == "session_meta" && session.LastAction == "" {
+				session.LastAction = "session started"
+			}
+			if state, meaningful := entryOutcome(decoded); meaningful {
+				session.EndState = state
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ID == "" {
+		return nil, errors.New("missing session id")
+	}
+
+	// Without a usable timestamp the last write of the file stands in for the update time, and
+	// the dated directories Codex files rollouts under for the creation time.
+	undated := lastTS.IsZero()
+	if undated {
+		if info, err := os.Stat(path); err == nil {
+			lastTS = info.ModTime()
+		}
+	}
+	session.UpdatedAt = lastTS
+	if !createdSet || session.CreatedAt.IsZero() {
+		session.CreatedAt = session.UpdatedAt
+		if day, ok := dateOfPath(path); ok && undated {
+			session.CreatedAt = day
+		}
+	}
+	o.applyLastAction(session)
+
+	return session, nil
+}
+
+// dateOfPath returns the local midnight of the YYYY/MM/DD directories a rollout file sits in.
+func dateOfPath(path string) (time.Time, bool) {
+	dir := filepath.Dir(path)
+	day := filepath.Base(dir)
+	month := filepath.Base(filepath.Dir(dir))
+	year := filepath.Base(filepath.Dir(filepath.Dir(dir)))
+	if len(year) != 4 || len(month) != 2 || len(day) != 2 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006/01/02", year+"/"+month+"/"+day, time.Local)
+	return t, err == nil
+}
+
+// countMessage increments the message counters of session for user and assistant messages,
+// records the hash of the first user prompt and keeps snippets of the latest messages.
+func countMessage(session *Session, payload *responseItemPayload, limit int) {
+	if payload.Type != "message" {
+		return
+	}
+	switch payload.Role {
+	case "user":
+		session.UserMessages++
+		session.Counts.Messages++
+		text := joinTexts(payload.Content)
+		if session.PromptHash == "" {
+			session.PromptHash = promptHash(text)
+		}
+		if prompt := compactSnippet(PromptText(text), limit); prompt != "" {
+			session.LastUserMessage = prompt
+		}
+	case "assistant":
+		session.AssistantMessages++
+		session.Counts.Messages++
+		if reply := compactSnippet(joinTexts(payload.Content), limit); reply != "" {
+			session.LastAssistantMessage = reply
+		}
+	}
+}
+
+// countEntry adds a response item or event to the entry counts of session other than messages,
+// which countMessage counts.
+func countEntry(session *Session, entry decodedEntry) {
+	switch entry.payloadType() {
+	case "reasoning":
+		session.Counts.Reasoning++
+	case "function_call", "custom_tool_call", "local_shell_call", "web_search_call":
+		session.Counts.FunctionCalls++
+	case "function_call_output", "custom_tool_call_output", "error", "stream_error":
+		if state, _ := entryOutcome(entry); state.Failed() {
+			session.Counts.Errors++
+		}
+	}
+}
+
+// forEachEntry decodes every non-empty line of a rollout file and passes it to fn, stopping at the
+// first error.
+func forEachEntry(path string, fn func(entry logEntry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, maxLineSize)
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return fmt.Errorf("line exceeds %d bytes", maxLineSize)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		line = bytesTrimRightNewline(line)
+		if len(line) == 0 {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			continue
+		}
+
+		var entry logEntry
+		if unmarshalErr := json.Unmarshal(line, &entry); unmarshalErr != nil {
+			return fmt.Errorf("decode log entry: %w", unmarshalErr)
+		}
+		entry.line = number
+		if fnErr := fn(entry); fnErr != nil {
+			return fnErr
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("timestamp empty")
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: %q", value)
+}
+
+type logEntry struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	// line is the 1-based line number of the entry in its rollout file.
+	line int
+}
+
+type sessionMetaPayload struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	CWD       string `json:"cwd"`
+	Model     string `json:"model"`
+	// ForkedFromID is set by Codex on sessions forked from another; ParentID is accepted as
+	// an older spelling.
+	ForkedFromID string `json:"forked_from_id"`
+	ParentID     string `json:"parent_id"`
+	// Hostname is not written by Codex itself, but by wrappers that record where a session ran.
+	Hostname string `json:"hostname"`
+}
+
+func (p sessionMetaPayload) parentID() string {
+	if p.ForkedFromID != "" {
+		return p.ForkedFromID
+	}
+	return p.ParentID
+}
+
+// turnContextPayload holds the settings recorded at the start of every turn.
+type turnContextPayload struct {
+	Model          string          `json:"model"`
+	ApprovalPolicy string          `json:"approval_policy"`
+	SandboxPolicy  json.RawMessage `json:"sandbox_policy"`
+}
+
+// sandboxMode extracts the mode from the sandbox policy, which Codex writes either as a bare
+// string or as an object tagged with "mode".
+func (p turnContextPayload) sandboxMode() string {
+	var mode string
+	if json.Unmarshal(p.SandboxPolicy, &mode) == nil {
+		return mode
+	}
+	var tagged struct {
+		Mode string `json:"mode"`
+	}
+	if json.Unmarshal(p.SandboxPolicy, &tagged) == nil {
+		return tagged.Mode
+	}
+	return ""
+}
+
+// decodedEntry is a rollout entry with the payload of a response item or event decoded once,
+// for every summary parseSessionFile takes from it.
+type decodedEntry struct {
+	logEntry
+	// item is the payload of a response_item entry and event that of an event_msg entry; both
+	// are nil for other entries and payloads that do not decode.
+	item  *responseItemPayload
+	event *eventMsgPayload
+}
+
+// decodeEntry decodes the payload of entry when it is a response item or an event.
+func decodeEntry(entry logEntry) decodedEntry {
+	decoded := decodedEntry{logEntry: entry}
+	switch entry.Type {
+	case "response_item":
+		var payload responseItemPayload
+		if json.Unmarshal(entry.Payload, &payload) == nil {
+			decoded.item = &payload
+		}
+	case "event_msg":
+		var payload eventMsgPayload
+		if json.Unmarshal(entry.Payload, &payload) == nil {
+			decoded.event = &payload
+		}
+	}
+	return decoded
+}
+
+// payloadType returns the payload type of a response item or event, or the type of any other
+// entry.
+func (e decodedEntry) payloadType() string {
+	switch {
+	case e.item != nil && e.item.Type != "":
+		return e.item.Type
+	case e.event != nil && e.event.Type != "":
+		return e.event.Type
+	}
+	return e.Type
+}
+
+func describeEntry(entry decodedEntry, limit int) string {
+	switch {
+	case entry.item != nil:
+		return describeResponseItem(entry.item, limit)
+	case entry.event != nil:
+		return describeEventMessage(entry.event, limit)
+	default:
+		return ""
+	}
+}
+
+type responseItemPayload struct {
+	Type      string           `json:"type"`
+	Role      string           `json:"role,omitempty"`
+	Content   []messageContent `json:"content,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Arguments string           `json:"arguments,omitempty"`
+	// Input is the raw input of a custom (freeform) tool call such as apply_patch.
+	Input     string             `json:"input,omitempty"`
+	Output    string             `json:"output,omitempty"`
+	Summary   []messageContent   `json:"summary,omitempty"`
+	CallID    string             `json:"call_id,omitempty"`
+	Metadata  json.RawMessage    `json:"metadata,omitempty"`
+	Result    json.RawMessage    `json:"result,omitempty"`
+	Error     *responseItemError `json:"error,omitempty"`
+	Status    string             `json:"status,omitempty"`
+	Title     string             `json:"title,omitempty"`
+	AltText   string             `json:"alt_text,omitempty"`
+	Media     []json.RawMessage  `json:"media,omitempty"`
+	Parts     []json.RawMessage  `json:"parts,omitempty"`
+	Kind      string             `json:"kind,omitempty"`
+	Data      json.RawMessage    `json:"data,omitempty"`
+	Encrypted json.RawMessage    `json:"encrypted_content,omitempty"`
+}
+
+type responseItemError struct {
+	Message string `json:"message"`
+}
+
+type messageContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+func describeResponseItem(payload *responseItemPayload, limit int) string {
+	switch payload.Type {
+	case "message":
+		text := firstNonEmptyText(payload.Content)
+		if text == "" {
+			text = firstNonEmptyText(payload.Summary)
+		}
+		if text == "" && payload.Title != "" {
+			text = payload.Title
+		}
+		if text == "" {
+			return ""
+		}
+
+		prefix := strings.TrimSpace(payload.Role)
+		if prefix != "" {
+			return fmt.Sprintf("%s: %s", prefix, compactSnippet(text, limit))
+		}
+		return compactSnippet(text, limit)
+	case "reasoning":
+		text := firstNonEmptyText(payload.Summary)
+		if text == "" {
+			text = firstNonEmptyText(payload.Content)
+		}
+		if text == "" {
+			return ""
+		}
+		return fmt.Sprintf("reasoning: %s", compactSnippet(text, limit))
+	case "function_call", "custom_tool_call":
+		if patch, ok := patchOf(*payload); ok {
+			if diffs := ParsePatch(patch); len(diffs) > 0 {
+				return describePatch(diffs, limit)
+			}
+		}
+		desc := fmt.Sprintf("call %s", payload.Name)
+		if args := describeFunctionArguments(payload.Name, payload.Arguments, limit); args != "" {
+			desc = fmt.Sprintf("%s %s", desc, args)
+		}
+		return desc
+	case "function_call_output", "custom_tool_call_output":
+		return describeFunctionOutput(*payload, limit)
+	default:
+		if payload.Title != "" {
+			return compactSnippet(payload.Title, limit)
+		}
+		return ""
+	}
+}
+
+func describeFunctionArguments(name, argsJSON string, limit int) string {
+	if argsJSON == "" {
+		return ""
+	}
+	switch name {
+	case "shell":
+		var call struct {
+			Command []string `json:"command"`
+			Workdir string   `json:"workdir"`
+		}
+		if err := json.Unmarshal([]byte(argsJSON), &call); err != nil {
+			return ""
+		}
+		if len(call.Command) == 0 {
+			return ""
+		}
+		cmd := strings.Join(call.Command, " ")
+		return compactSnippet(cmd, limit)
+	default:
+		return ""
+	}
+}
+
+func describeFunctionOutput(payload responseItemPayload, limit int) string {
+	if payload.Output == "" {
+		if payload.Error != nil && payload.Error.Message != "" {
+			return fmt.Sprintf("call %s error: %s", payload.Name, compactSnippet(payload.Error.Message, limit))
+		}
+		return fmt.Sprintf("call %s completed", payload.Name)
+	}
+
+	var out toolOutput
+	if err := json.Unmarshal([]byte(payload.Output), &out); err != nil {
+		return fmt.Sprintf("call %s output", payload.Name)
+	}
+
+	switch {
+	case out.Error != "":
+		return fmt.Sprintf("call %s error: %s", payload.Name, compactSnippet(out.Error, limit))
+	case out.Metadata.ExitCode != nil:
+		snippet := compactSnippet(out.Output, limit)
+		if snippet != "" {
+			return fmt.Sprintf("call %s exit %d: %s", payload.Name, *out.Metadata.ExitCode, snippet)
+		}
+		return fmt.Sprintf("call %s exit %d", payload.Name, *out.Metadata.ExitCode)
+	default:
+		if out.Output == "" {
+			return fmt.Sprintf("call %s completed", payload.Name)
+		}
+		return fmt.Sprintf("call %s: %s", payload.Name, compactSnippet(out.Output, limit))
+	}
+}
+
+// rateLimitHints mark error messages caused by rate or usage limits, lower-cased.
+var rateLimitHints = []string{"rate limit", "rate_limit", "usage limit", "too many requests", "429"}
+
+// toolOutput is the JSON document the output of a function or custom tool call holds.
+type toolOutput struct {
+	Output   string `json:"output"`
+	Metadata struct {
+		ExitCode *int `json:"exit_code"`
+	} `json:"metadata"`
+	Error string `json:"error"`
+}
+
+// failed reports whether the call reported an error or its command exited with a non-zero code.
+func (o toolOutput) failed() bool {
+	return o.Error != "" || o.Metadata.ExitCode != nil && *o.Metadata.ExitCode != 0
+}
+
+// entryOutcome returns the end state entry would leave the session in, and whether it counts
+// towards how the session ended at all; bookkeeping such as token counts and turn context does
+// not.
+func entryOutcome(entry decodedEntry) (EndState, bool) {
+	switch {
+	case entry.item != nil:
+		payload := entry.item
+		if payload.Type != "function_call_output" && payload.Type != "custom_tool_call_output" {
+			return EndOK, true
+		}
+		if payload.Error != nil && payload.Error.Message != "" {
+			return EndToolFailure, true
+		}
+		var out toolOutput
+		if json.Unmarshal([]byte(payload.Output), &out) == nil && out.failed() {
+			return EndToolFailure, true
+		}
+		return EndOK, true
+	case entry.event != nil:
+		payload := entry.event
+		if payload.Type == "token_count" {
+			return EndOK, false
+		}
+		switch payload.Type {
+		case "turn_aborted":
+			return EndAborted, true
+		case "error", "stream_error":
+			message := strings.ToLower(payload.Message)
+			for _, hint := range rateLimitHints {
+				if strings.Contains(message, hint) {
+					return EndRateLimit, true
+				}
+			}
+			return EndError, true
+		}
+		return EndOK, true
+	}
+	return EndOK, false
+}
+
+type eventMsgPayload struct {
+	Type    string          `json:"type"`
+	Message string          `json:"message,omitempty"`
+	Text    string          `json:"text,omitempty"`
+	Kind    string          `json:"kind,omitempty"`
+	Status  string          `json:"status,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Detail  json.RawMessage `json:"detail,omitempty"`
+	// Info holds the counters of a token_count event.
+	Info *tokenCountInfo `json:"info,omitempty"`
+}
+
+func describeEventMessage(payload *eventMsgPayload, limit int) string {
+	switch payload.Type {
+	case "user_message", "assistant_message", "system_message":
+		text := payload.Message
+		if text == "" {
+			text = payload.Text
+		}
+		if text == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s: %s", payload.Type, compactSnippet(text, limit))
+	case "tool_progress":
+		if payload.Message != "" {
+			return fmt.Sprintf("tool progress: %s", compactSnippet(payload.Message, limit))
+		}
+	case "token_count":
+		return "token usage updated"
+	case "command_output":
+		if payload.Message != "" {
+			return fmt.Sprintf("command output: %s", compactSnippet(payload.Message, limit))
+		}
+	}
+	if payload.Message != "" {
+		return fmt.Sprintf("%s: %s", payload.Type, compactSnippet(payload.Message, limit))
+	}
+	return payload.Type
+}
+
+func firstNonEmptyText(items []messageContent) string {
+	for _, item := range items {
+		if strings.TrimSpace(item.Text) != "" {
+			return item.Text
+		}
+	}
+	return ""
+}
+
+// compactSnippet collapses the whitespace of text and truncates it to limit display columns.
+func compactSnippet(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	// Collapse whitespace similar to fzf preview.
+	text = strings.Join(strings.Fields(text), " ")
+	return runewidth.Truncate(text, limit, "...")
+}
+
+func bytesTrimRightNewline(b []byte) []byte {
+	return bytes.TrimRight(b, "\r\n")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package sessions
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEntryOutcome(t *testing.T) {
+	// output encodes a tool call output document the way Codex nests it in a JSON string.
+	output := func(doc string) string {
+		data, _ := json.Marshal(doc)
+		return string(data)
+	}
+	tests := []struct {
+		name    string
+		typ     string
+		payload string
+		want    EndState
+		counts  bool
+	}{
+		{"message", "response_item", `{"type":"message","role":"assistant"}`, EndOK, true},
+		{"function ok", "response_item", `{"type":"function_call_output","output":` + output(`{"output":"done","metadata":{"exit_code":0}}`) + `}`, EndOK, true},
+		{"function exit code", "response_item", `{"type":"function_call_output","output":` + output(`{"output":"boom","metadata":{"exit_code":2}}`) + `}`, EndToolFailure, true},
+		{"function error field", "response_item", `{"type":"function_call_output","output":` + output(`{"error":"sandbox denied"}`) + `}`, EndToolFailure, true},
+		{"function error object", "response_item", `{"type":"function_call_output","error":{"message":"timeout"}}`, EndToolFailure, true},
+		{"function plain text", "response_item", `{"type":"function_call_output","output":"exit 1"}`, EndOK, true},
+		{"custom ok", "response_item", `{"type":"custom_tool_call_output","output":` + output(`{"output":"Success","metadata":{"exit_code":0}}`) + `}`, EndOK, true},
+		{"custom exit code", "response_item", `{"type":"custom_tool_call_output","output":` + output(`{"output":"patch rejected","metadata":{"exit_code":1}}`) + `}`, EndToolFailure, true},
+		{"custom error field", "response_item", `{"type":"custom_tool_call_output","output":` + output(`{"error":"invalid patch"}`) + `}`, EndToolFailure, true},
+		{"aborted", "event_msg", `{"type":"turn_aborted"}`, EndAborted, true},
+		{"error", "event_msg", `{"type":"error","message":"stream disconnected"}`, EndError, true},
+		{"rate limit", "event_msg", `{"type":"error","message":"Rate limit reached"}`, EndRateLimit, true},
+		{"usage limit", "event_msg", `{"type":"stream_error","message":"You've hit your usage limit"}`, EndRateLimit, true},
+		{"agent message", "event_msg", `{"type":"agent_message","message":"hi"}`, EndOK, true},
+		{"token count", "event_msg", `{"type":"token_count"}`, EndOK, false},
+		{"turn context", "turn_context", `{"cwd":"/tmp"}`, EndOK, false},
+		{"invalid payload", "response_item", `[`, EndOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, counts := entryOutcome(decodeEntry(logEntry{Type: tt.typ, Payload: json.RawMessage(tt.payload)}))
+			if got != tt.want || counts != tt.counts {
+				t.Errorf("entryOutcome() = %q, %v; want %q, %v", got, counts, tt.want, tt.counts)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package sessions
+
+import (
+	"log/slog"
+)
+
+var logger = slog.New(slog.DiscardHandler)
+
+// SetLogger routes diagnostics of the package, such as every rollout file parsed and how long it
+// took, to l. By default nothing is logged.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	logger = l
+}
@@ -0,0 +1,69 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Maintain runs a quick housekeeping pass over the sessions root. It is cheap enough to run after
+// every resume and only touches artifacts that no longer carry session data.
+func Maintain(sessionsRoot string, opts FileOptions) error {
+	if err := opts.writable(); err != nil {
+		return err
+	}
+	root, err := ResolveDir(sessionsRoot)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(root); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("stat sessions dir: %w", err)
+	}
+
+	var combined error
+	if _, err := EmptyTrash(root, TrashRetention, opts); err != nil {
+		combined = errors.Join(combined, fmt.Errorf("expire trash: %w", err))
+	}
+	if err := pruneEmptyDirs(root, opts); err != nil {
+		combined = errors.Join(combined, fmt.Errorf("prune empty dirs: %w", err))
+	}
+	return combined
+}
+
+// pruneEmptyDirs removes empty directories below root, deepest first, keeping root itself, the
+// trash directory and symbolic links to directories.
+func pruneEmptyDirs(root string, opts FileOptions) error {
+	var dirs []string
+	err := walkTree(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() && path != root && !isTrashDir(root, path) {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, dir := range dirs {
+		// A link to an empty directory is left alone; removing it would only remove the link.
+		if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		_ = opts.removeFile(dir)
+	}
+	return nil
+}
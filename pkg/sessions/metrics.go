@@ -0,0 +1,90 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// slowestKept is the number of slowest files Metrics remembers.
+const slowestKept = 5
+
+// FileMetric is the parse cost of one rollout file.
+type FileMetric struct {
+	Path     string
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Metrics aggregates the cost of loading sessions, to diagnose slow startups.
+type Metrics struct {
+	Files int
+	Bytes int64
+	// Parse is the time spent parsing files; Wall also includes walking the directories.
+	Parse time.Duration
+	Wall  time.Duration
+	// Slowest lists the files that took longest to parse, slowest first.
+	Slowest []FileMetric
+}
+
+// FilesPerSecond returns the number of files loaded per second of wall time.
+func (m Metrics) FilesPerSecond() float64 {
+	if m.Wall <= 0 {
+		return 0
+	}
+	return float64(m.Files) / m.Wall.Seconds()
+}
+
+// BytesPerSecond returns the number of bytes loaded per second of wall time.
+func (m Metrics) BytesPerSecond() float64 {
+	if m.Wall <= 0 {
+		return 0
+	}
+	return float64(m.Bytes) / m.Wall.Seconds()
+}
+
+func (m *Metrics) record(file FileMetric) {
+	if m == nil {
+		return
+	}
+	m.Files++
+	m.Bytes += file.Bytes
+	m.Parse += file.Duration
+	if len(m.Slowest) == slowestKept && file.Duration <= m.Slowest[slowestKept-1].Duration {
+		return
+	}
+	m.Slowest = append(m.Slowest, file)
+	sort.Slice(m.Slowest, func(i, j int) bool {
+		return m.Slowest[i].Duration > m.Slowest[j].Duration
+	})
+	if len(m.Slowest) > slowestKept {
+		m.Slowest = m.Slowest[:slowestKept]
+	}
+}
+
+// LoadRootsMetrics is like LoadRootsContext and additionally reports how long parsing took.
+func LoadRootsMetrics(ctx context.Context, sessionsDirs []string, opts Options) ([]Session, Metrics, error) {
+	if err := opts.Check(); err != nil {
+		return nil, Metrics{}, err
+	}
+	return loadRoots(ctx, sessionsDirs, opts.Normalized())
+}
+
+// loadRoots loads sessionsDirs with the normalized options o, measuring how long parsing took.
+func loadRoots(ctx context.Context, sessionsDirs []string, o Options) ([]Session, Metrics, error) {
+	var metrics Metrics
+	start := time.Now()
+	byID := make(map[string]*Session)
+	var combined error
+	for _, dir := range sessionsDirs {
+		if err := walkSessions(ctx, dir, &o, byID, nil, &metrics); err != nil {
+			if ctx.Err() != nil {
+				return nil, metrics, ctx.Err()
+			}
+			combined = errors.Join(combined, err)
+		}
+	}
+	metrics.Wall = time.Since(start)
+	return collect(byID), metrics, combined
+}
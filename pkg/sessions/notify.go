@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"errors"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// errNotifierClosed stops the use of a notifier whose notifications ended unexpectedly.
+var errNotifierClosed = errors.New("file change notifications stopped")
+
+// notifier reports changes below the sessions roots through the file change notifications of
+// the operating system, so Watch only rescans after something was written. Notifications are not
+// recursive: every directory a scan visits is watched on its own.
+type notifier struct {
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+	// err is the first directory that could not be watched, after which changes can be missed
+	// and Watch goes back to polling.
+	err error
+}
+
+// newNotifier watches roots, or fails when the platform or its limits do not allow it.
+func newNotifier(roots []string) (*notifier, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	n := &notifier{watcher: watcher, watched: make(map[string]bool)}
+	for _, root := range roots {
+		if n.watch(root); n.err != nil {
+			watcher.Close()
+			return nil, n.err
+		}
+	}
+	return n, nil
+}
+
+// watch adds dir to the watched directories. It does nothing on a nil notifier, which polls.
+func (n *notifier) watch(dir string) {
+	if n == nil || n.err != nil || n.watched[dir] {
+		return
+	}
+	if err := n.watcher.Add(dir); err != nil {
+		n.err = err
+		return
+	}
+	n.watched[dir] = true
+}
+
+// changed reports whether ev may have changed a session, forgetting watched directories that
+// were removed.
+func (n *notifier) changed(ev fsnotify.Event) bool {
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		delete(n.watched, ev.Name)
+	}
+	// Permission and access time changes leave the contents alone.
+	return ev.Op != fsnotify.Chmod
+}
+
+func (n *notifier) close() {
+	n.watcher.Close()
+}
@@ -0,0 +1,104 @@
+package sessions
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Options control how rollout files are read into sessions. Every function reading sessions, such
+// as Load, Stream and Watch, takes the options of its call, so concurrent loads with different
+// settings do not interfere. The zero value reads every rollout file with DefaultSnippetLimit and
+// LastActionEntry.
+type Options struct {
+	// Excludes makes walks of the sessions roots skip the files and directories matching any of
+	// the patterns, such as an archive subtree or duplicates left by a sync tool, without moving
+	// them. A pattern is a path.Match glob matched against the path relative to the root, with
+	// forward slashes, and against the base name, so "archive" skips every directory of that
+	// name and "2024/*" the months of 2024. A pattern ending in "/" only matches directories.
+	Excludes []string `json:"excludes,omitempty"`
+	// IgnoredEntries are entry types, such as "token_count" or "tool_progress", skipped when
+	// computing LastAction, EndState and UpdatedAt, so housekeeping events do not make a dormant
+	// session look recently active. A type names the payload type of event_msg and
+	// response_item entries, or the type of any other entry.
+	IgnoredEntries []string `json:"ignored_entries,omitempty"`
+	// ScanRange restricts walks of the sessions roots to rollout files that can hold sessions
+	// updated within it, using the YYYY/MM/DD directories Codex files rollouts under by start
+	// date: directories of days after the range are skipped whole, and files in directories of
+	// days before it are read only when they were modified within it. A session resumed from a
+	// rollout started before the range then misses that rollout. The zero range reads
+	// everything.
+	ScanRange TimeRange `json:"scan_range"`
+	// FileLimit is the number of most recently modified rollout files read per root, so startup
+	// stays fast with enormous histories; 0 reads every file. A session with older rollouts
+	// beyond the limit misses them.
+	FileLimit int `json:"file_limit,omitempty"`
+	// SnippetLimit is the number of display columns snippets such as LastAction are truncated
+	// to; 0 means DefaultSnippetLimit.
+	SnippetLimit int `json:"snippet_limit,omitempty"`
+	// LastAction chooses what LastAction describes; "" means LastActionEntry.
+	LastAction LastActionMode `json:"last_action,omitempty"`
+	// RootHosts names the machine whose sessions each root holds, such as a directory synced
+	// from a laptop, so sessions merged from several machines can be told apart. Sessions loaded
+	// from one of these roots get the name in Host unless their rollout records a hostname of
+	// their own.
+	RootHosts map[string]string `json:"root_hosts,omitempty"`
+}
+
+// clone returns a copy of o that shares no slices or maps with it.
+func (o Options) clone() Options {
+	o.Excludes = slices.Clone(o.Excludes)
+	o.IgnoredEntries = slices.Clone(o.IgnoredEntries)
+	o.RootHosts = maps.Clone(o.RootHosts)
+	return o
+}
+
+// Check reports the first exclude pattern of o that is not a valid path.Match glob.
+func (o Options) Check() error {
+	for _, pattern := range o.Excludes {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return fmt.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Normalized returns o with the defaults its zero fields stand for filled in, so two options
+// reading the same sessions compare equal field by field.
+func (o Options) Normalized() Options {
+	o = o.clone()
+	if o.SnippetLimit < 1 {
+		o.SnippetLimit = DefaultSnippetLimit
+	}
+	if o.LastAction == "" {
+		o.LastAction = LastActionEntry
+	}
+	o.FileLimit = max(o.FileLimit, 0)
+	if len(o.Excludes) == 0 {
+		o.Excludes = nil
+	}
+	if len(o.IgnoredEntries) == 0 {
+		o.IgnoredEntries = nil
+	}
+	if len(o.RootHosts) == 0 {
+		o.RootHosts = nil
+	} else {
+		hosts := make(map[string]string, len(o.RootHosts))
+		for root, host := range o.RootHosts {
+			hosts[filepath.Clean(root)] = host
+		}
+		o.RootHosts = hosts
+	}
+	return o
+}
+
+// snippetLimit returns the number of display columns snippets are truncated to.
+func (o *Options) snippetLimit() int {
+	if o.SnippetLimit < 1 {
+		return DefaultSnippetLimit
+	}
+	return o.SnippetLimit
+}
@@ -0,0 +1,249 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Markers of the patch format Codex sends to its apply_patch tool.
+const (
+	patchBegin  = "*** Begin Patch"
+	patchEnd    = "*** End Patch"
+	patchAdd    = "*** Add File: "
+	patchUpdate = "*** Update File: "
+	patchDelete = "*** Delete File: "
+	patchMove   = "*** Move to: "
+	patchEOF    = "*** End of File"
+)
+
+// PatchOp is what a patch does to a file.
+type PatchOp string
+
+const (
+	PatchAdd    PatchOp = "add"
+	PatchUpdate PatchOp = "update"
+	PatchDelete PatchOp = "delete"
+)
+
+// FileDiff is the change an apply_patch call makes to one file.
+type FileDiff struct {
+	Path string
+	Op   PatchOp
+	// MoveTo is the new path of a renamed file, or "".
+	MoveTo string
+	// Lines holds the body of the change as in a unified diff: "@@" hunk headers, and lines
+	// starting with ' ' for context, '-' for removed and '+' for added lines.
+	Lines          []string
+	Added, Removed int
+}
+
+// Patch is an apply_patch call recorded in a session.
+type Patch struct {
+	Timestamp time.Time
+	Files     []FileDiff
+	// Path and Line locate the call in its rollout file (Line is 1-based).
+	Path string
+	Line int
+}
+
+// PatchStats sums the apply_patch calls of a session.
+type PatchStats struct {
+	// Files lists the changed paths, each once, in the order they were first patched.
+	Files          []string
+	Added, Removed int
+}
+
+// add counts diffs in s.
+func (s *PatchStats) add(diffs []FileDiff) {
+	for _, diff := range diffs {
+		if !slices.Contains(s.Files, diff.Path) {
+			s.Files = append(s.Files, diff.Path)
+		}
+		s.Added += diff.Added
+		s.Removed += diff.Removed
+	}
+}
+
+// merge adds the stats of another rollout of the same session.
+func (s *PatchStats) merge(other PatchStats) {
+	for _, path := range other.Files {
+		if !slices.Contains(s.Files, path) {
+			s.Files = append(s.Files, path)
+		}
+	}
+	s.Added += other.Added
+	s.Removed += other.Removed
+}
+
+// String describes the stats compactly, e.g. "2 files +10 -3", or returns "" without patches.
+func (s PatchStats) String() string {
+	switch len(s.Files) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("1 file +%d -%d", s.Added, s.Removed)
+	default:
+		return fmt.Sprintf("%d files +%d -%d", len(s.Files), s.Added, s.Removed)
+	}
+}
+
+// ParsePatch splits an apply_patch patch into the changes it makes to each file. Text outside
+// the "*** Begin Patch" and "*** End Patch" lines is ignored.
+func ParsePatch(patch string) []FileDiff {
+	var (
+		diffs   []FileDiff
+		current *FileDiff
+	)
+	patch = strings.TrimRight(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for _, line := range strings.Split(patch, "\n") {
+		var op PatchOp
+		var path string
+		switch {
+		case strings.HasPrefix(line, patchAdd):
+			op, path = PatchAdd, strings.TrimPrefix(line, patchAdd)
+		case strings.HasPrefix(line, patchUpdate):
+			op, path = PatchUpdate, strings.TrimPrefix(line, patchUpdate)
+		case strings.HasPrefix(line, patchDelete):
+			op, path = PatchDelete, strings.TrimPrefix(line, patchDelete)
+		case strings.HasPrefix(line, patchMove):
+			if current != nil {
+				current.MoveTo = strings.TrimSpace(strings.TrimPrefix(line, patchMove))
+			}
+			continue
+		case line == patchBegin, line == patchEOF:
+			continue
+		case line == patchEnd:
+			current = nil
+			continue
+		}
+		if op != "" {
+			diffs = append(diffs, FileDiff{Path: strings.TrimSpace(path), Op: op})
+			current = &diffs[len(diffs)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			current.Added++
+		case strings.HasPrefix(line, "-"):
+			current.Removed++
+		case strings.HasPrefix(line, "@@"), strings.HasPrefix(line, " "):
+		case line == "":
+			// Blank context lines are sometimes sent without their leading space.
+			line = " "
+		default:
+			continue
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	return diffs
+}
+
+// findPatch returns the patch embedded in text, from its "*** Begin Patch" line through its
+// "*** End Patch" line.
+func findPatch(text string) (string, bool) {
+	start := strings.Index(text, patchBegin)
+	if start < 0 {
+		return "", false
+	}
+	end := strings.Index(text[start:], patchEnd)
+	if end < 0 {
+		return text[start:], true
+	}
+	return text[start : start+end+len(patchEnd)], true
+}
+
+// patchOf returns the patch a tool call sends to apply_patch: as the input of an apply_patch
+// call, or as the argument of an apply_patch command run through the shell tool.
+func patchOf(payload responseItemPayload) (string, bool) {
+	switch payload.Type {
+	case "custom_tool_call":
+		if payload.Name == "apply_patch" {
+			return findPatch(payload.Input)
+		}
+	case "function_call":
+		if !strings.Contains(payload.Arguments, patchBegin) {
+			return "", false
+		}
+		var args struct {
+			Input   string          `json:"input"`
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(payload.Arguments), &args); err != nil {
+			return "", false
+		}
+		if payload.Name == "apply_patch" {
+			return findPatch(args.Input)
+		}
+		var command string
+		var argv []string
+		if err := json.Unmarshal(args.Command, &argv); err == nil {
+			command = strings.Join(argv, "\n")
+		} else if err := json.Unmarshal(args.Command, &command); err != nil {
+			return "", false
+		}
+		if strings.Contains(command, "apply_patch") {
+			return findPatch(command)
+		}
+	}
+	return "", false
+}
+
+// countPatch adds the patch of a tool call, if the response item is one, to the stats of session.
+func countPatch(session *Session, payload *responseItemPayload) {
+	if patch, ok := patchOf(*payload); ok {
+		session.Patches.add(ParsePatch(patch))
+	}
+}
+
+// describePatch summarises the files a patch changes for the last action of a session, e.g.
+// "patch main.go, ui.go (+10 -3)".
+func describePatch(diffs []FileDiff, limit int) string {
+	var stats PatchStats
+	stats.add(diffs)
+	names := make([]string, len(stats.Files))
+	for i, path := range stats.Files {
+		names[i] = path[strings.LastIndexAny(path, `/\`)+1:]
+	}
+	return compactSnippet(fmt.Sprintf("patch %s (+%d -%d)", strings.Join(names, ", "), stats.Added, stats.Removed), limit)
+}
+
+// ReadPatches returns the apply_patch calls recorded in the rollout files of sess, ordered by
+// timestamp. Every call Codex made is included, whether or not the patch applied cleanly.
+func ReadPatches(sess Session) ([]Patch, error) {
+	var patches []Patch
+	for _, path := range sess.FilePaths {
+		err := forEachEntry(path, func(entry logEntry) error {
+			if entry.Type != "response_item" {
+				return nil
+			}
+			var payload responseItemPayload
+			if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+				return nil
+			}
+			text, ok := patchOf(payload)
+			if !ok {
+				return nil
+			}
+			diffs := ParsePatch(text)
+			if len(diffs) == 0 {
+				return nil
+			}
+			ts, _ := parseTimestamp(entry.Timestamp)
+			patches = append(patches, Patch{Timestamp: ts, Files: diffs, Path: path, Line: entry.line})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	slices.SortStableFunc(patches, func(a, b Patch) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return patches, nil
+}
@@ -0,0 +1,94 @@
+package sessions
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces secrets masked by a Redactor.
+const Redacted = "[REDACTED]"
+
+// DefaultSecretPatterns match common credentials: OpenAI, Anthropic, GitHub, Slack and Google
+// API keys, AWS access keys and secrets, bearer tokens, private key blocks and "password=..."
+// style assignments.
+var DefaultSecretPatterns = []string{
+	`sk-[A-Za-z0-9_-]{20,}`,
+	`gh[pousr]_[A-Za-z0-9]{36,}`,
+	`github_pat_[A-Za-z0-9_]{22,}`,
+	`xox[abposr]-[A-Za-z0-9-]{10,}`,
+	`AIza[0-9A-Za-z_-]{35}`,
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`,
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{20,}=*)`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`(?i)\b(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["']?([^\s"',;]{8,})`,
+}
+
+// Redactor masks secrets in transcript text before it is shown or shared. A pattern with a
+// capture group masks only the first group, so "password=hunter22" keeps its key name.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor compiles patterns, preceded by DefaultSecretPatterns when withDefaults is set.
+func NewRedactor(patterns []string, withDefaults bool) (*Redactor, error) {
+	var all []string
+	if withDefaults {
+		all = append(all, DefaultSecretPatterns...)
+	}
+	r := &Redactor{}
+	for _, pattern := range append(all, patterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns text with every secret masked. A nil Redactor returns text unchanged.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, re := range r.patterns {
+		text = replaceSecrets(re, text)
+	}
+	return text
+}
+
+// Entries returns a copy of entries with their text redacted.
+func (r *Redactor) Entries(entries []TranscriptEntry) []TranscriptEntry {
+	if r == nil {
+		return entries
+	}
+	out := make([]TranscriptEntry, len(entries))
+	for i, entry := range entries {
+		entry.Text = r.Redact(entry.Text)
+		out[i] = entry
+	}
+	return out
+}
+
+func replaceSecrets(re *regexp.Regexp, text string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(text, Redacted)
+	}
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	out := make([]byte, 0, len(text))
+	last := 0
+	for _, m := range matches {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[0], m[1]
+		}
+		out = append(out, text[last:start]...)
+		out = append(out, Redacted...)
+		last = end
+	}
+	return string(append(out, text[last:]...))
+}
@@ -0,0 +1,208 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoBackupDir is returned by ScrubFiles without a backup directory: scrubbing cannot be
+// undone, so the originals are always kept.
+var ErrNoBackupDir = errors.New("no backup directory for the scrubbed originals")
+
+// ErrBackupInRoot is returned by ScrubFiles for a backup directory inside the sessions root,
+// where backups, sync tools and bundles would pick up the secrets being scrubbed.
+var ErrBackupInRoot = errors.New("backup directory is inside the sessions root")
+
+// ScrubFiles permanently masks the secrets r matches in the rollout files of sess. Each changed
+// file is replaced atomically, keeping its modification time, after its original was copied to
+// backupDir (see ScrubBackupPath), which is required and must lie outside sessionsRoot. It
+// returns the paths of the files that changed.
+func ScrubFiles(sess Session, r *Redactor, sessionsRoot, backupDir string, opts FileOptions) ([]string, error) {
+	if err := opts.writable(); err != nil {
+		return nil, err
+	}
+	if backupDir == "" {
+		return nil, ErrNoBackupDir
+	}
+	{
+		dir, err := resolvePath(backupDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolve backup directory: %w", err)
+		}
+		root, err := resolvePath(sessionsRoot)
+		if err != nil {
+			return nil, fmt.Errorf("resolve sessions root: %w", err)
+		}
+		if dir == root || below(root, dir) {
+			return nil, fmt.Errorf("%s: %w", backupDir, ErrBackupInRoot)
+		}
+	}
+	var changed []string
+	for _, path := range sess.FilePaths {
+		ok, err := scrubFile(path, r, backupDir, opts)
+		if err != nil {
+			return changed, fmt.Errorf("scrub %s: %w", path, err)
+		}
+		if ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed, nil
+}
+
+// ScrubBackupPath returns where ScrubFiles copies the rollout file at path before scrubbing it
+// with backupDir. The copy still holds the secrets.
+func ScrubBackupPath(backupDir, path string) string {
+	return filepath.Join(backupDir, filepath.Base(path))
+}
+
+func scrubFile(path string, r *Redactor, backupDir string, opts FileOptions) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		if scrubbed := scrubLine(line, r); !bytes.Equal(scrubbed, line) {
+			lines[i] = scrubbed
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	backup := ScrubBackupPath(backupDir, path)
+	opts.logOp("copy %s -> %s", path, backup)
+	opts.logOp("rewrite %s", path)
+	if opts.DryRun {
+		return true, nil
+	}
+	if err := os.MkdirAll(backupDir, 0o700); err != nil {
+		return false, err
+	}
+	if err := keepOriginal(backup, data); err != nil {
+		return false, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bytes.Join(lines, nil), info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	// Keep the modification time so the session does not look freshly active.
+	_ = os.Chtimes(path, info.ModTime(), info.ModTime())
+	return true, nil
+}
+
+// keepOriginal saves data to path, readable by the user only as it holds secrets, unless a backup
+// from an earlier scrub is already there, so the backup always holds the file as Codex wrote it.
+func keepOriginal(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// scrubLine masks the secrets in one JSONL line. Every string in the line is decoded, so secrets
+// written with JSON escapes such as \/ or \u0041 are found too, and masked. A line with nothing
+// to mask is kept as written; otherwise it is written out again token by token, keeping the
+// order of its keys, with the masked strings encoded afresh.
+func scrubLine(line []byte, r *Redactor) []byte {
+	body := bytes.TrimRight(line, "\r\n")
+	eol := line[len(body):]
+	out, changed, err := redactTokens(body, r)
+	if err != nil {
+		// Not JSON: mask the text as it is.
+		return append([]byte(r.Redact(string(body))), eol...)
+	}
+	if !changed {
+		return line
+	}
+	return append(out, eol...)
+}
+
+// redactTokens re-encodes the JSON value in data with every string masked by r, and reports
+// whether any string changed.
+func redactTokens(data []byte, r *Redactor) ([]byte, bool, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	// Each open container counts the tokens written in it so far, to place the separators.
+	type container struct {
+		object bool
+		n      int
+	}
+	var stack []container
+	separate := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := &stack[len(stack)-1]
+		if top.n > 0 {
+			if top.object && top.n%2 == 1 {
+				out.WriteByte(':')
+			} else {
+				out.WriteByte(',')
+			}
+		}
+		top.n++
+	}
+	changed := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' || t == '[' {
+				separate()
+				stack = append(stack, container{object: t == '{'})
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+			out.WriteByte(byte(t))
+			continue
+		case string:
+			if masked := r.Redact(t); masked != t {
+				tok, changed = masked, true
+			}
+		}
+		separate()
+		if err := enc.Encode(tok); err != nil {
+			return nil, false, err
+		}
+		out.Truncate(out.Len() - 1) // the newline Encode adds
+	}
+	if len(stack) != 0 {
+		return nil, false, io.ErrUnexpectedEOF
+	}
+	return out.Bytes(), changed, nil
+}
@@ -0,0 +1,137 @@
+package sessions
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScrubLine(t *testing.T) {
+	r, err := NewRedactor([]string{`sk-[A-Za-z0-9]{10,}`, `token/[a-z]{8}`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "plain secret",
+			line: `{"a":"key sk-abcdefghijkl here","b":1}` + "\n",
+			want: `{"a":"key [REDACTED] here","b":1}` + "\n",
+		},
+		{
+			name: "unicode escape",
+			line: `{"a":"sk-abc\u0064efghijkl"}` + "\n",
+			want: `{"a":"[REDACTED]"}` + "\n",
+		},
+		{
+			name: "slash escapes",
+			line: `{"u":"https:\/\/host\/token\/abcdefgh"}` + "\n",
+			want: `{"u":"https://host/[REDACTED]"}` + "\n",
+		},
+		{
+			name: "key order and numbers kept",
+			line: `{"z":"sk-abcdefghijkl","a":{"y":[1.50,"sk-abcdefghijkl",true],"b":null},"m":"<x>"}` + "\n",
+			want: `{"z":"[REDACTED]","a":{"y":[1.50,"[REDACTED]",true],"b":null},"m":"<x>"}` + "\n",
+		},
+		{
+			name: "nothing to mask keeps the line as written",
+			line: `{"a": "x\/y", "b": [ 1 ]}` + "\n",
+			want: `{"a": "x\/y", "b": [ 1 ]}` + "\n",
+		},
+		{
+			name: "crlf",
+			line: `{"a":"sk-abcdefghijkl"}` + "\r\n",
+			want: `{"a":"[REDACTED]"}` + "\r\n",
+		},
+		{
+			name: "not json",
+			line: "plain sk-abcdefghijkl text\n",
+			want: "plain [REDACTED] text\n",
+		},
+		{
+			name: "truncated json",
+			line: `{"a":"sk-abcdefghijkl"`,
+			want: `{"a":"[REDACTED]"`,
+		},
+		{
+			name: "no newline",
+			line: `["sk-abcdefghijkl"]`,
+			want: `["[REDACTED]"]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(scrubLine([]byte(tt.line), r)); got != tt.want {
+				t.Errorf("scrubLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactTokensReportsChanges(t *testing.T) {
+	r, err := NewRedactor([]string{`secret[0-9]+`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		data    string
+		want    string
+		changed bool
+		invalid bool
+	}{
+		{data: `{"a":"secret123"}`, want: `{"a":"[REDACTED]"}`, changed: true},
+		{data: `{"secret1":"b"}`, want: `{"[REDACTED]":"b"}`, changed: true},
+		{data: `{"a":"public"}`, want: `{"a":"public"}`},
+		{data: `[]`, want: `[]`},
+		{data: `{"a":`, invalid: true},
+		{data: `not json`, invalid: true},
+	}
+	for _, tt := range tests {
+		out, changed, err := redactTokens([]byte(tt.data), r)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("redactTokens(%q) succeeded, want an error", tt.data)
+			}
+			continue
+		}
+		if err != nil || string(out) != tt.want || changed != tt.changed {
+			t.Errorf("redactTokens(%q) = %q, %v, %v; want %q, %v", tt.data, out, changed, err, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestScrubFilesKeepsOriginal(t *testing.T) {
+	root, backups := t.TempDir(), t.TempDir()
+	path := filepath.Join(root, "rollout.jsonl")
+	original := `{"type":"event_msg","payload":{"message":"sk-abcdefghijkl"}}` + "\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRedactor([]string{`sk-[a-z]{10,}`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := Session{ID: "s", FilePaths: []string{path}}
+
+	if _, err := ScrubFiles(sess, r, root, "", FileOptions{}); !errors.Is(err, ErrNoBackupDir) {
+		t.Errorf("without a backup directory: err = %v, want ErrNoBackupDir", err)
+	}
+	if _, err := ScrubFiles(sess, r, root, filepath.Join(root, "kept"), FileOptions{}); !errors.Is(err, ErrBackupInRoot) {
+		t.Errorf("with a backup directory in the root: err = %v, want ErrBackupInRoot", err)
+	}
+	changed, err := ScrubFiles(sess, r, root, backups, FileOptions{})
+	if err != nil || len(changed) != 1 {
+		t.Fatalf("ScrubFiles = %v, %v; want the rollout changed", changed, err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "sk-") {
+		t.Errorf("scrubbed file still holds the secret: %s", data)
+	}
+	if data, _ := os.ReadFile(ScrubBackupPath(backups, path)); string(data) != original {
+		t.Errorf("kept original = %q, want %q", data, original)
+	}
+}
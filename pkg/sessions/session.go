@@ -0,0 +1,365 @@
+package sessions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Session holds aggregated information for a single Codex CLI session.
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	WorkingDir string
+	LastAction string
+	// LastUserMessage and LastAssistantMessage are snippets of the latest user prompt, without
+	// the context Codex injects, and of the latest assistant reply.
+	LastUserMessage      string
+	LastAssistantMessage string
+	FilePaths            []string
+	// Root is the sessions root the session was loaded from.
+	Root string
+	// Host is the machine the session was recorded on: the hostname in its session_meta entry
+	// when there is one, otherwise the name Options.RootHosts gave its root, or "" when unknown.
+	Host string
+	// Model is the most recently used model, e.g. "gpt-5" or "o4-mini", when the log records it.
+	Model string
+	// SandboxMode and ApprovalPolicy are the sandbox mode (e.g. "workspace-write") and approval
+	// policy (e.g. "on-request") of the latest turn, when the log records them.
+	SandboxMode    string
+	ApprovalPolicy string
+	// UserMessages and AssistantMessages count the conversation messages across all rollout files.
+	UserMessages      int
+	AssistantMessages int
+	// ParentID is the session this one was forked from, when the log records it. Sessions linked
+	// this way form a thread; see Threads.
+	ParentID string
+	// SkippedFiles lists rollout files of this session (judged by the ID at the end of the file
+	// name) that could not be parsed and were left out.
+	SkippedFiles []string
+	// DuplicateFiles lists rollout files with the same content as one of FilePaths, such as
+	// backup copies, which were left out rather than merged; see TrashDuplicates.
+	DuplicateFiles []string
+	// Tokens is the token usage reported by Codex, summed across the rollout files.
+	Tokens TokenUsage
+	// PromptHash identifies the first user prompt, ignoring the context Codex injects before it.
+	// Sessions sharing it and the working directory are continuations; see Continuations.
+	PromptHash string
+	// EndState records how the last meaningful entry of the latest rollout ended.
+	EndState EndState
+	// Patches sums the file changes Codex sent to apply_patch, across the rollout files.
+	Patches PatchStats
+	// Counts counts the entries of the session by kind, across the rollout files.
+	Counts EntryCounts
+}
+
+// EntryCounts counts the entries of a session by kind.
+type EntryCounts struct {
+	// Messages counts user and assistant messages.
+	Messages int
+	// Reasoning counts the reasoning items of the model.
+	Reasoning int
+	// FunctionCalls counts tool calls of every kind: functions, custom tools, local shell calls
+	// and web searches.
+	FunctionCalls int
+	// Errors counts error events and tool calls that failed to run.
+	Errors int
+}
+
+// add sums the counts of another rollout of the same session into c.
+func (c *EntryCounts) add(other EntryCounts) {
+	c.Messages += other.Messages
+	c.Reasoning += other.Reasoning
+	c.FunctionCalls += other.FunctionCalls
+	c.Errors += other.Errors
+}
+
+// String summarises the counts compactly, e.g. "42✉ 17⚙ 2✗" for messages, tool calls and
+// errors, leaving out the kinds that are zero.
+func (c EntryCounts) String() string {
+	var parts []string
+	for _, part := range []struct {
+		n    int
+		mark string
+	}{{c.Messages, "✉"}, {c.FunctionCalls, "⚙"}, {c.Errors, "✗"}} {
+		if part.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", part.n, part.mark))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// EndState classifies the last meaningful entry of a session: EndOK, or the kind of failure
+// that ended it.
+type EndState string
+
+const (
+	// EndOK means the session did not end with a failure.
+	EndOK EndState = ""
+	// EndError is an error event reported by Codex, e.g. a lost connection.
+	EndError EndState = "error"
+	// EndAborted is a turn the user interrupted.
+	EndAborted EndState = "aborted"
+	// EndToolFailure is a tool call that reported an error or whose command exited non-zero.
+	EndToolFailure EndState = "tool-failure"
+	// EndRateLimit is an error caused by a rate or usage limit.
+	EndRateLimit EndState = "rate-limit"
+)
+
+// Failed reports whether the session ended with a failure of any kind.
+func (s EndState) Failed() bool {
+	return s != EndOK
+}
+
+// String returns the name of the state as used by the ended: search filter, "ok" for EndOK.
+func (s EndState) String() string {
+	if s == EndOK {
+		return "ok"
+	}
+	return string(s)
+}
+
+// RootOr returns the sessions root the session was loaded from, or fallback when it is unknown.
+func (s Session) RootOr(fallback string) string {
+	if s.Root != "" {
+		return s.Root
+	}
+	return fallback
+}
+
+// Messages returns the number of user and assistant messages in the session.
+func (s Session) Messages() int {
+	return s.UserMessages + s.AssistantMessages
+}
+
+// Duration returns the time between the first and the last entry of the session.
+func (s Session) Duration() time.Duration {
+	if s.CreatedAt.IsZero() || s.UpdatedAt.Before(s.CreatedAt) {
+		return 0
+	}
+	return s.UpdatedAt.Sub(s.CreatedAt)
+}
+
+// Snapshot returns a shallow copy of the session. Useful when storing a copy for
+// presentation logic without exposing the underlying slice for modification.
+func (s Session) Snapshot() Session {
+	paths := make([]string, len(s.FilePaths))
+	copy(paths, s.FilePaths)
+	s.FilePaths = paths
+	s.SkippedFiles = slices.Clone(s.SkippedFiles)
+	s.DuplicateFiles = slices.Clone(s.DuplicateFiles)
+	s.Patches.Files = slices.Clone(s.Patches.Files)
+	return s
+}
+
+// MatchPrefix returns the sessions whose ID starts with prefix (case-insensitively). An exact ID
+// match is returned on its own even if it is also a prefix of other IDs.
+func MatchPrefix(list []Session, prefix string) []Session {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return nil
+	}
+	var matches []Session
+	for _, sess := range list {
+		id := strings.ToLower(sess.ID)
+		if id == prefix {
+			return []Session{sess}
+		}
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, sess)
+		}
+	}
+	return matches
+}
+
+// DeleteFiles moves all files associated with the session into the trash (see TrashFiles), so the
+// deletion can be undone until the trash is emptied.
+func DeleteFiles(sess Session, sessionsRoot string, opts FileOptions) error {
+	_, err := TrashFiles(sess, sessionsRoot, opts)
+	return err
+}
+
+// PurgeFiles permanently removes all files associated with the session, including its
+// DuplicateFiles. It makes a best-effort attempt to prune empty directories created for the
+// session, walking upwards until the sessions root or an occupied directory is encountered.
+func PurgeFiles(sess Session, sessionsRoot string, opts FileOptions) error {
+	if err := opts.writable(); err != nil {
+		return err
+	}
+	if err := CheckWithinRoot(sess, sessionsRoot); err != nil {
+		return err
+	}
+	if sessionsRoot != "" {
+		sessionsRoot = filepath.Clean(sessionsRoot)
+	}
+
+	var combined error
+	for _, path := range slices.Concat(sess.FilePaths, sess.DuplicateFiles) {
+		if err := opts.removeFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			combined = errors.Join(combined, fmt.Errorf("remove %s: %w", path, err))
+			continue
+		}
+		cleanupParentDirectories(filepath.Dir(path), sessionsRoot, opts)
+	}
+	return combined
+}
+
+// ArchiveDir returns the directory archived sessions are moved to: "archived_sessions" next to
+// the sessions root, mirroring the layout used by the Codex CLI.
+func ArchiveDir(sessionsRoot string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(sessionsRoot)), "archived_sessions")
+}
+
+// ArchiveFiles moves all files of the session, including its DuplicateFiles, into ArchiveDir,
+// preserving their path relative to the sessions root, so they no longer show up in Load without
+// being deleted.
+func ArchiveFiles(sess Session, sessionsRoot string, opts FileOptions) error {
+	if err := opts.writable(); err != nil {
+		return err
+	}
+	if err := CheckWithinRoot(sess, sessionsRoot); err != nil {
+		return err
+	}
+	sessionsRoot = filepath.Clean(sessionsRoot)
+	archive := ArchiveDir(sessionsRoot)
+
+	var combined error
+	for _, path := range slices.Concat(sess.FilePaths, sess.DuplicateFiles) {
+		rel, err := filepath.Rel(sessionsRoot, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(path)
+		}
+		dest := filepath.Join(archive, rel)
+		if err := opts.makeDirs(filepath.Dir(dest)); err != nil {
+			combined = errors.Join(combined, fmt.Errorf("archive %s: %w", path, err))
+			continue
+		}
+		if err := opts.renameFile(path, dest); err != nil {
+			combined = errors.Join(combined, fmt.Errorf("archive %s: %w", path, err))
+			continue
+		}
+		cleanupParentDirectories(filepath.Dir(path), sessionsRoot, opts)
+	}
+	return combined
+}
+
+func cleanupParentDirectories(start, stop string, opts FileOptions) {
+	if opts.DryRun {
+		// Nothing was removed, so no directory became empty.
+		return
+	}
+	stop = filepath.Clean(stop)
+
+	for dir := filepath.Clean(start); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if stop != "" {
+			rel, err := filepath.Rel(stop, dir)
+			if err != nil {
+				break
+			}
+			if strings.HasPrefix(rel, "..") {
+				break
+			}
+		}
+		if err := opts.removeFile(dir); err != nil {
+			break
+		}
+		if dir == stop {
+			break
+		}
+	}
+}
+
+// Threads groups the sessions of list that are linked by ParentID, forks and their forks alike.
+// It maps the ID of every session in a group of two or more to the ID of the group's root, the
+// earliest ancestor present in list.
+func Threads(list []Session) map[string]string {
+	parent := make(map[string]string, len(list))
+	for _, sess := range list {
+		parent[sess.ID] = sess.ParentID
+	}
+	rootOf := func(id string) string {
+		// The step limit guards against cycles in malformed logs.
+		for steps := 0; steps < len(parent); steps++ {
+			p, ok := parent[id]
+			if !ok || p == "" {
+				break
+			}
+			if _, known := parent[p]; !known {
+				break
+			}
+			id = p
+		}
+		return id
+	}
+	roots := make(map[string]string, len(list))
+	size := make(map[string]int)
+	for _, sess := range list {
+		root := rootOf(sess.ID)
+		roots[sess.ID] = root
+		size[root]++
+	}
+	for id, root := range roots {
+		if size[root] < 2 {
+			delete(roots, id)
+		}
+	}
+	return roots
+}
+
+// Continuations groups sessions that continue the same conversation in separate rollouts, as
+// happens when Codex starts a new session on resume: they share the working directory and the
+// first user prompt (PromptHash). It maps the ID of every session in a group of two or more to
+// the ID of the group's most recently updated session.
+func Continuations(list []Session) map[string]string {
+	type key struct{ dir, hash string }
+	latest := make(map[key]Session)
+	size := make(map[key]int)
+	for _, sess := range list {
+		if sess.PromptHash == "" {
+			continue
+		}
+		k := key{filepath.Clean(sess.WorkingDir), sess.PromptHash}
+		size[k]++
+		if cur, ok := latest[k]; !ok || sess.UpdatedAt.After(cur.UpdatedAt) ||
+			(sess.UpdatedAt.Equal(cur.UpdatedAt) && sess.ID > cur.ID) {
+			latest[k] = sess
+		}
+	}
+	groups := make(map[string]string)
+	for _, sess := range list {
+		if sess.PromptHash == "" {
+			continue
+		}
+		if k := (key{filepath.Clean(sess.WorkingDir), sess.PromptHash}); size[k] > 1 {
+			groups[sess.ID] = latest[k].ID
+		}
+	}
+	return groups
+}
+
+// contextBlock matches the context blocks Codex sends along with the user's first message.
+var contextBlock = regexp.MustCompile(`(?s)<(environment_context|user_instructions)>.*?</(environment_context|user_instructions)>`)
+
+// PromptText returns the text of a user message on one line, without the context blocks Codex
+// sends along with it. It is "" for a message holding nothing but context.
+func PromptText(text string) string {
+	return strings.Join(strings.Fields(contextBlock.ReplaceAllString(text, " ")), " ")
+}
+
+// promptHash returns a short hash of PromptText(text), or "" when that is empty.
+func promptHash(text string) string {
+	text = PromptText(text)
+	if text == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
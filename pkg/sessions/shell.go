@@ -0,0 +1,173 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ShellCommand is a command Codex ran through its shell tool.
+type ShellCommand struct {
+	Timestamp time.Time
+	// Command is the command line, unwrapped from `bash -lc` and the like when Codex used them.
+	Command string
+	// Workdir is the directory the command ran in, or "" when the call did not name one.
+	Workdir string
+	// ExitCode is the exit status recorded for the command, or -1 when there is none.
+	ExitCode int
+}
+
+// shellCall decodes the arguments of the shell tools Codex has used: "shell" and
+// "container.exec" take an argv, "shell_command" a command line and "exec_command" a cmd.
+type shellCall struct {
+	Command json.RawMessage `json:"command"`
+	Cmd     string          `json:"cmd"`
+	Workdir string          `json:"workdir"`
+}
+
+// commandLine returns the command line of the call. An argv of the form `bash -lc <script>` is
+// unwrapped to the script; other arguments are quoted for sh where needed.
+func (c shellCall) commandLine() string {
+	if c.Cmd != "" {
+		return c.Cmd
+	}
+	var line string
+	if err := json.Unmarshal(c.Command, &line); err == nil {
+		return line
+	}
+	var argv []string
+	if err := json.Unmarshal(c.Command, &argv); err != nil || len(argv) == 0 {
+		return ""
+	}
+	switch filepath.Base(argv[0]) {
+	case "bash", "sh", "zsh", "dash":
+		if len(argv) == 3 && (argv[1] == "-c" || argv[1] == "-lc") {
+			return argv[2]
+		}
+	}
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote single-quotes s for sh unless it consists only of characters that need no quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ReadShellCommands returns the shell commands recorded in the rollout files of sess, ordered
+// by timestamp. Patches sent through the shell to apply_patch are left out; see ReadPatches.
+func ReadShellCommands(sess Session) ([]ShellCommand, error) {
+	var commands []ShellCommand
+	byCall := map[string]int{}
+	for _, path := range sess.FilePaths {
+		err := forEachEntry(path, func(entry logEntry) error {
+			if entry.Type != "response_item" {
+				return nil
+			}
+			var payload responseItemPayload
+			if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+				return nil
+			}
+			switch payload.Type {
+			case "function_call":
+				switch payload.Name {
+				case "shell", "container.exec", "shell_command", "exec_command":
+				default:
+					return nil
+				}
+				if _, ok := patchOf(payload); ok {
+					return nil
+				}
+				var call shellCall
+				if err := json.Unmarshal([]byte(payload.Arguments), &call); err != nil {
+					return nil
+				}
+				line := strings.TrimSpace(call.commandLine())
+				if line == "" {
+					return nil
+				}
+				ts, _ := parseTimestamp(entry.Timestamp)
+				if payload.CallID != "" {
+					byCall[payload.CallID] = len(commands)
+				}
+				commands = append(commands, ShellCommand{Timestamp: ts, Command: line, Workdir: call.Workdir, ExitCode: -1})
+			case "function_call_output":
+				i, ok := byCall[payload.CallID]
+				if !ok {
+					return nil
+				}
+				var out struct {
+					Metadata struct {
+						ExitCode *int `json:"exit_code"`
+					} `json:"metadata"`
+				}
+				if json.Unmarshal([]byte(payload.Output), &out) == nil && out.Metadata.ExitCode != nil {
+					commands[i].ExitCode = *out.Metadata.ExitCode
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	slices.SortStableFunc(commands, func(a, b ShellCommand) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return commands, nil
+}
+
+// WriteShellScript renders commands as a sh script that runs them again in order. Each command
+// runs in a subshell in the directory it ran in (the working directory of sess unless the call
+// named another), as Codex runs every command in a fresh process, and is preceded by a comment
+// with its time and exit status. Commands that failed are commented out, so the script replays
+// what worked.
+func WriteShellScript(w io.Writer, sess Session, commands []ShellCommand) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Shell commands run by Codex in session %s.\n", sess.ID)
+	b.WriteString("set -e\n")
+	for _, cmd := range commands {
+		b.WriteString("\n")
+		status := "exit status unknown"
+		if cmd.ExitCode >= 0 {
+			status = fmt.Sprintf("exit %d", cmd.ExitCode)
+		}
+		if cmd.Timestamp.IsZero() {
+			fmt.Fprintf(&b, "# %s\n", status)
+		} else {
+			fmt.Fprintf(&b, "# %s, %s\n", cmd.Timestamp.Local().Format("2006-01-02 15:04:05"), status)
+		}
+		dir := cmd.Workdir
+		if dir == "" {
+			dir = sess.WorkingDir
+		}
+		line := "(" + cmd.Command
+		if dir != "" {
+			line = fmt.Sprintf("(cd %s && %s", shellQuote(dir), cmd.Command)
+		}
+		// A trailing comment or here-document would swallow the closing parenthesis.
+		if strings.ContainsAny(cmd.Command, "#\n") {
+			line += "\n"
+		}
+		line += ")"
+		if cmd.ExitCode > 0 {
+			line = "# " + strings.ReplaceAll(line, "\n", "\n# ")
+		}
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,114 @@
+package sessions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange limits sessions by when they were last updated. A zero Since or Until leaves that
+// side open.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether the range is unbounded.
+func (r TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Contains reports whether t lies within the range; Until is exclusive.
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && t.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && !t.Before(r.Until) {
+		return false
+	}
+	return true
+}
+
+// Covers reports whether every time within other also lies within r.
+func (r TimeRange) Covers(other TimeRange) bool {
+	if !r.Since.IsZero() && (other.Since.IsZero() || other.Since.Before(r.Since)) {
+		return false
+	}
+	if !r.Until.IsZero() && (other.Until.IsZero() || other.Until.After(r.Until)) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the sessions of list updated within the range.
+func (r TimeRange) Filter(list []Session) []Session {
+	if r.IsZero() {
+		return list
+	}
+	out := make([]Session, 0, len(list))
+	for _, sess := range list {
+		if r.Contains(sess.UpdatedAt) {
+			out = append(out, sess)
+		}
+	}
+	return out
+}
+
+// String describes the range, e.g. "since 2025-01-02 00:00".
+func (r TimeRange) String() string {
+	const layout = "2006-01-02 15:04"
+	switch {
+	case r.IsZero():
+		return "any time"
+	case r.Until.IsZero():
+		return "since " + r.Since.Local().Format(layout)
+	case r.Since.IsZero():
+		return "until " + r.Until.Local().Format(layout)
+	default:
+		return r.Since.Local().Format(layout) + " – " + r.Until.Local().Format(layout)
+	}
+}
+
+// ParseTimeBound parses one side of a time range relative to now. It accepts an age such as
+// "36h", "7d" or "2w", a date "2006-01-02" or "2006-01-02 15:04" in local time, or RFC 3339.
+// With end set, a bare date means the end of that day, so "--until 2025-01-31" includes the
+// 31st.
+func ParseTimeBound(s string, now time.Time, end bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if age, ok := parseAge(s); ok {
+		return now.Add(-age), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want an age like 7d or 36h, or a date like 2006-01-02)", s)
+}
+
+// parseAge parses a Go duration or a whole number of days ("d") or weeks ("w").
+func parseAge(s string) (time.Duration, bool) {
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, true
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,263 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TranscriptEntry is a single readable item of a session transcript.
+type TranscriptEntry struct {
+	Timestamp time.Time
+	// Role is "user", "assistant", "reasoning" or "tool".
+	Role string
+	// Name holds the tool name for function calls and their outputs.
+	Name string
+	Text string
+	// Path and Line locate the entry in its rollout file (Line is 1-based).
+	Path string
+	Line int
+}
+
+// Heading returns a short label describing the entry, e.g. "assistant" or "tool shell".
+func (e TranscriptEntry) Heading() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s %s", e.Role, e.Name)
+	}
+	return e.Role
+}
+
+// ReadTranscript parses every rollout file of the session and returns its transcript ordered by
+// timestamp.
+func ReadTranscript(sess Session) ([]TranscriptEntry, error) {
+	var entries []TranscriptEntry
+	for _, path := range sess.FilePaths {
+		err := forEachEntry(path, func(entry logEntry) error {
+			if entry.Type != "response_item" {
+				return nil
+			}
+			if item, ok := transcriptEntry(entry); ok {
+				item.Path, item.Line = path, entry.line
+				entries = append(entries, item)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+func transcriptEntry(entry logEntry) (TranscriptEntry, bool) {
+	var payload responseItemPayload
+	if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+		return TranscriptEntry{}, false
+	}
+	ts, _ := parseTimestamp(entry.Timestamp)
+	item := TranscriptEntry{Timestamp: ts}
+
+	switch payload.Type {
+	case "message":
+		item.Role = strings.TrimSpace(payload.Role)
+		if item.Role == "" {
+			item.Role = "assistant"
+		}
+		item.Text = joinTexts(payload.Content)
+	case "reasoning":
+		item.Role = "reasoning"
+		item.Text = joinTexts(payload.Summary)
+	case "function_call", "custom_tool_call":
+		item.Role = "tool"
+		item.Name = payload.Name
+		item.Text = payload.Arguments + payload.Input
+		if patch, ok := patchOf(payload); ok {
+			// The patch itself, so the entry reads as a diff and can be opened as one.
+			item.Text = patch
+		} else if args := describeFunctionArguments(payload.Name, payload.Arguments, DefaultSnippetLimit); args != "" {
+			item.Text = args
+		}
+	case "function_call_output", "custom_tool_call_output":
+		item.Role = "tool"
+		item.Name = "output"
+		item.Text = functionOutputText(payload.Output)
+	default:
+		return TranscriptEntry{}, false
+	}
+
+	if strings.TrimSpace(item.Text) == "" {
+		return TranscriptEntry{}, false
+	}
+	return item, true
+}
+
+func joinTexts(items []messageContent) string {
+	var parts []string
+	for _, item := range items {
+		if text := strings.TrimSpace(item.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func functionOutputText(raw string) string {
+	var out struct {
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err == nil && out.Output != "" {
+		return out.Output
+	}
+	return raw
+}
+
+// Export writes the full transcript of sess to w as Markdown.
+func Export(w io.Writer, sess Session) error {
+	entries, err := ReadTranscript(sess)
+	if err != nil {
+		return err
+	}
+	return WriteMarkdown(w, sess, entries)
+}
+
+// WriteMarkdown renders the given transcript entries of sess as a Markdown document.
+func WriteMarkdown(w io.Writer, sess Session, entries []TranscriptEntry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Codex session %s\n\n", sess.ID)
+	if sess.WorkingDir != "" {
+		fmt.Fprintf(&b, "- Directory: `%s`\n", sess.WorkingDir)
+	}
+	if !sess.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "- Started: %s\n", sess.CreatedAt.Format(time.RFC3339))
+	}
+	b.WriteString("\n")
+
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "## %s", entry.Heading())
+		if !entry.Timestamp.IsZero() {
+			fmt.Fprintf(&b, " — %s", entry.Timestamp.Format(time.RFC3339))
+		}
+		b.WriteString("\n\n")
+		if entry.Role == "tool" {
+			fmt.Fprintf(&b, "```\n%s\n```\n\n", strings.TrimRight(entry.Text, "\n"))
+		} else {
+			fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(entry.Text))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTranscript = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Codex session {{.Session.ID}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+h2 { font-size: 1rem; margin-top: 2rem; color: #555; }
+pre { background: #f4f4f4; padding: .75rem; overflow-x: auto; }
+.text { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Codex session {{.Session.ID}}</h1>
+<ul>
+{{- if .Session.WorkingDir}}
+<li>Directory: <code>{{.Session.WorkingDir}}</code></li>
+{{- end}}
+{{- if not .Session.CreatedAt.IsZero}}
+<li>Started: {{.Session.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}</li>
+{{- end}}
+</ul>
+{{- range .Entries}}
+<h2>{{.Heading}}{{if not .Timestamp.IsZero}} — {{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}}{{end}}</h2>
+{{- if eq .Role "tool"}}
+<pre>{{.Text}}</pre>
+{{- else}}
+<div class="text">{{.Text}}</div>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the given transcript entries of sess as a standalone HTML page.
+func WriteHTML(w io.Writer, sess Session, entries []TranscriptEntry) error {
+	return htmlTranscript.Execute(w, struct {
+		Session Session
+		Entries []TranscriptEntry
+	}{sess, entries})
+}
+
+// ANSI escape sequences used by WriteText.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+)
+
+// roleANSI colors the heading of an entry by role in WriteText.
+var roleANSI = map[string]string{
+	"user":      "\x1b[1;36m",
+	"assistant": "\x1b[1;32m",
+	"tool":      "\x1b[1;33m",
+	"reasoning": "\x1b[1;35m",
+}
+
+// WriteText renders the given transcript entries of sess as plain text for reading in a
+// terminal or pager. With color set, headings are colored by role with ANSI escape sequences
+// and tool output is dimmed.
+func WriteText(w io.Writer, sess Session, entries []TranscriptEntry, color bool) error {
+	style := func(seq, text string) string {
+		if !color || text == "" {
+			return text
+		}
+		return seq + text + ansiReset
+	}
+	var b strings.Builder
+	b.WriteString(style(ansiBold, "Codex session "+sess.ID))
+	b.WriteString("\n")
+	if sess.WorkingDir != "" {
+		fmt.Fprintf(&b, "Directory: %s\n", sess.WorkingDir)
+	}
+	if !sess.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "Started:   %s\n", sess.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if sess.Model != "" {
+		fmt.Fprintf(&b, "Model:     %s\n", sess.Model)
+	}
+	b.WriteString("\n")
+
+	for _, entry := range entries {
+		heading := entry.Heading()
+		if !entry.Timestamp.IsZero() {
+			heading = entry.Timestamp.Local().Format("15:04:05") + "  " + heading
+		}
+		b.WriteString(style(roleANSI[entry.Role], heading))
+		b.WriteString("\n")
+		if entry.Role == "tool" {
+			// Each line is styled on its own, so pagers showing part of the output keep it dim.
+			for i, line := range strings.Split(strings.TrimRight(entry.Text, "\n"), "\n") {
+				if i > 0 {
+					b.WriteString("\n")
+				}
+				b.WriteString(style(ansiDim, line))
+			}
+		} else {
+			b.WriteString(strings.TrimSpace(entry.Text))
+		}
+		b.WriteString("\n\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
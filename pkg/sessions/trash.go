@@ -0,0 +1,175 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	trashDirName      = ".trash"
+	trashManifestName = "manifest.json"
+	// TrashRetention is how long trashed sessions are kept before Maintain expires them.
+	TrashRetention = 30 * 24 * time.Hour
+)
+
+// TrashEntry records a session moved to the trash so it can be restored.
+type TrashEntry struct {
+	// Dir is the directory holding the trashed files and manifest.
+	Dir       string        `json:"-"`
+	Session   Session       `json:"session"`
+	DeletedAt time.Time     `json:"deleted_at"`
+	Files     []TrashedFile `json:"files"`
+}
+
+// TrashedFile maps an original rollout path to its name inside the trash entry directory.
+type TrashedFile struct {
+	Original string `json:"original"`
+	Name     string `json:"name"`
+}
+
+// TrashDir returns the trash directory inside the sessions root.
+func TrashDir(sessionsRoot string) string {
+	return filepath.Join(filepath.Clean(sessionsRoot), trashDirName)
+}
+
+// TrashFiles moves all files of the session, duplicates included, into a new trash entry and
+// prunes directories left empty. The returned entry can be passed to RestoreTrash.
+func TrashFiles(sess Session, sessionsRoot string, opts FileOptions) (TrashEntry, error) {
+	if err := opts.writable(); err != nil {
+		return TrashEntry{}, err
+	}
+	if err := CheckWithinRoot(sess, sessionsRoot); err != nil {
+		return TrashEntry{}, err
+	}
+	sessionsRoot = filepath.Clean(sessionsRoot)
+	now := time.Now().UTC()
+	entry := TrashEntry{
+		Dir:       filepath.Join(TrashDir(sessionsRoot), fmt.Sprintf("%s-%s", now.Format("20060102T150405.000000000"), sess.ID)),
+		Session:   sess.Snapshot(),
+		DeletedAt: now,
+	}
+	if err := opts.makeDirs(entry.Dir); err != nil {
+		return TrashEntry{}, fmt.Errorf("create trash entry: %w", err)
+	}
+
+	var combined error
+	for i, path := range slices.Concat(sess.FilePaths, sess.DuplicateFiles) {
+		name := fmt.Sprintf("%d-%s", i, filepath.Base(path))
+		if err := opts.renameFile(path, filepath.Join(entry.Dir, name)); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				combined = errors.Join(combined, fmt.Errorf("trash %s: %w", path, err))
+			}
+			continue
+		}
+		entry.Files = append(entry.Files, TrashedFile{Original: path, Name: name})
+		cleanupParentDirectories(filepath.Dir(path), sessionsRoot, opts)
+	}
+
+	if err := writeTrashManifest(entry, opts); err != nil {
+		combined = errors.Join(combined, err)
+	}
+	return entry, combined
+}
+
+// RestoreTrash moves the files of a trash entry back to their original locations and removes the
+// entry. Files whose original path is occupied are left in the trash.
+func RestoreTrash(entry TrashEntry, opts FileOptions) error {
+	if err := opts.writable(); err != nil {
+		return err
+	}
+	var combined error
+	for _, f := range entry.Files {
+		if _, err := os.Stat(f.Original); err == nil {
+			combined = errors.Join(combined, fmt.Errorf("restore %s: file already exists", f.Original))
+			continue
+		}
+		if err := opts.makeDirs(filepath.Dir(f.Original)); err != nil {
+			combined = errors.Join(combined, fmt.Errorf("restore %s: %w", f.Original, err))
+			continue
+		}
+		if err := opts.renameFile(filepath.Join(entry.Dir, f.Name), f.Original); err != nil {
+			combined = errors.Join(combined, fmt.Errorf("restore %s: %w", f.Original, err))
+		}
+	}
+	if combined != nil {
+		return combined
+	}
+	return opts.removeAll(entry.Dir)
+}
+
+// ListTrash returns the trash entries below sessionsRoot, oldest first.
+func ListTrash(sessionsRoot string) ([]TrashEntry, error) {
+	dirs, err := os.ReadDir(TrashDir(sessionsRoot))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read trash: %w", err)
+	}
+
+	var (
+		entries  []TrashEntry
+		combined error
+	)
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(TrashDir(sessionsRoot), d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, trashManifestName))
+		if err != nil {
+			combined = errors.Join(combined, fmt.Errorf("read trash manifest %s: %w", dir, err))
+			continue
+		}
+		var entry TrashEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			combined = errors.Join(combined, fmt.Errorf("decode trash manifest %s: %w", dir, err))
+			continue
+		}
+		entry.Dir = dir
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.Before(entries[j].DeletedAt)
+	})
+	return entries, combined
+}
+
+// EmptyTrash permanently removes trash entries deleted more than olderThan ago; zero removes all
+// entries. It returns the number of entries removed.
+func EmptyTrash(sessionsRoot string, olderThan time.Duration, opts FileOptions) (int, error) {
+	if err := opts.writable(); err != nil {
+		return 0, err
+	}
+	entries, err := ListTrash(sessionsRoot)
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, entry := range entries {
+		if olderThan > 0 && entry.DeletedAt.After(cutoff) {
+			continue
+		}
+		if rmErr := opts.removeAll(entry.Dir); rmErr != nil {
+			err = errors.Join(err, fmt.Errorf("remove %s: %w", entry.Dir, rmErr))
+			continue
+		}
+		removed++
+	}
+	return removed, err
+}
+
+func writeTrashManifest(entry TrashEntry, opts FileOptions) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode trash manifest: %w", err)
+	}
+	if err := opts.writeFile(filepath.Join(entry.Dir, trashManifestName), data); err != nil {
+		return fmt.Errorf("write trash manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TokenUsage holds the cumulative token counters reported by the Codex CLI.
+type TokenUsage struct {
+	InputTokens       int64 `json:"input_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens"`
+	OutputTokens      int64 `json:"output_tokens"`
+	ReasoningTokens   int64 `json:"reasoning_output_tokens"`
+	TotalTokens       int64 `json:"total_tokens"`
+}
+
+// tokenCountInfo is the info of a token_count event.
+type tokenCountInfo struct {
+	TotalTokenUsage TokenUsage `json:"total_token_usage"`
+}
+
+// ReadTokenUsage returns the latest cumulative token usage recorded in the session's rollouts.
+// Sessions without token_count events report zero usage.
+func ReadTokenUsage(sess Session) (TokenUsage, error) {
+	var usage TokenUsage
+	for _, path := range sess.FilePaths {
+		var fileUsage TokenUsage
+		err := forEachEntry(path, func(entry logEntry) error {
+			if u, ok := tokenUsageOf(entry); ok {
+				fileUsage = u
+			}
+			return nil
+		})
+		if err != nil {
+			return TokenUsage{}, fmt.Errorf("read %s: %w", path, err)
+		}
+		usage.add(fileUsage)
+	}
+	return usage, nil
+}
+
+func tokenUsageOf(entry logEntry) (TokenUsage, bool) {
+	if entry.Type != "event_msg" {
+		return TokenUsage{}, false
+	}
+	var payload eventMsgPayload
+	if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+		return TokenUsage{}, false
+	}
+	return payload.tokenUsage()
+}
+
+// tokenUsage returns the cumulative token usage reported by a token_count event.
+func (p *eventMsgPayload) tokenUsage() (TokenUsage, bool) {
+	if p.Type != "token_count" || p.Info == nil {
+		return TokenUsage{}, false
+	}
+	usage := p.Info.TotalTokenUsage
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	return usage, true
+}
+
+func (u *TokenUsage) add(other TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.CachedInputTokens += other.CachedInputTokens
+	u.OutputTokens += other.OutputTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.TotalTokens += other.TotalTokens
+}
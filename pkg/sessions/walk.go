@@ -0,0 +1,110 @@
+package sessions
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// excluded reports whether the file or directory at name below root matches a pattern of
+// o.Excludes.
+func (o *Options) excluded(root, name string, dir bool) bool {
+	if len(o.Excludes) == 0 || name == root {
+		return false
+	}
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range o.Excludes {
+		pattern, dirOnly := strings.CutSuffix(pattern, "/")
+		if dirOnly && !dir {
+			continue
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// walkTree walks the tree rooted at root like filepath.WalkDir, but follows symbolic links to
+// directories, including root itself, as sessions directories are often links to a synced
+// drive. Paths stay below root as given. A directory reached a second time, through a link
+// loop or a link to a directory walked already, is skipped. Links to files are reported with
+// the entry of their target, so Info returns its size and modification time.
+func walkTree(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		real, realErr := filepath.EvalSymlinks(root)
+		if realErr != nil {
+			real = root
+		}
+		w := treeWalker{fn: fn, visited: map[string]bool{}}
+		err = w.walk(root, real, fs.FileInfoToDirEntry(info))
+	}
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// treeWalker holds the state of a walkTree: the resolved paths of the directories visited.
+type treeWalker struct {
+	fn      fs.WalkDirFunc
+	visited map[string]bool
+}
+
+// walk visits path, whose symbolic links resolve to real, and the tree below it.
+func (w *treeWalker) walk(path, real string, d fs.DirEntry) error {
+	if !d.IsDir() {
+		return w.fn(path, d, nil)
+	}
+	if w.visited[real] {
+		logger.Debug("skipped directory visited before", "path", path, "target", real)
+		return nil
+	}
+	w.visited[real] = true
+	if err := w.fn(path, d, nil); err != nil {
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, d, err); err != nil && !errors.Is(err, filepath.SkipDir) {
+			return err
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		child, childReal := filepath.Join(path, entry.Name()), filepath.Join(real, entry.Name())
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// A dangling link is reported as it is.
+			if info, err := os.Stat(child); err == nil {
+				entry = fs.FileInfoToDirEntry(info)
+				if target, err := filepath.EvalSymlinks(child); err == nil {
+					childReal = target
+				}
+			}
+		}
+		if err := w.walk(child, childReal, entry); err != nil {
+			if errors.Is(err, filepath.SkipDir) && !entry.IsDir() {
+				// As with filepath.WalkDir, skipping from a file skips the rest of its directory.
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
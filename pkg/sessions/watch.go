@@ -0,0 +1,227 @@
+package sessions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Event kinds reported by Watch.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// Event describes a change to a session observed by Watch.
+type Event struct {
+	Kind    string
+	Session Session
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	session *Session
+}
+
+const (
+	// notifySettle is how long Watch waits after a change notification before rescanning, so a
+	// burst of writes is handled by one scan.
+	notifySettle = 100 * time.Millisecond
+	// notifyResync is how often Watch rescans without notifications, for changes the operating
+	// system does not report, such as those made to a network file system by another machine.
+	notifyResync = time.Minute
+)
+
+// Watch calls emit for every session below sessionsDir that was created, updated or deleted since
+// the previous scan. A scan walks the whole tree but only re-parses rollout files whose size or
+// modification time changed. Where the operating system reports file changes, a scan follows each
+// change, at most once every interval, and otherwise once a minute; without notifications, or
+// when a directory cannot be watched, the tree is scanned every interval. The first scan
+// establishes the baseline and emits nothing. Sessions are read with opts, but every rollout
+// file is watched, whatever opts.ScanRange and opts.FileLimit say. Watch blocks until ctx is
+// cancelled.
+func Watch(ctx context.Context, sessionsDir string, interval time.Duration, opts Options, emit func(Event)) error {
+	return WatchRoots(ctx, []string{sessionsDir}, interval, opts, emit)
+}
+
+// WatchRoots is like Watch for several sessions roots at once.
+func WatchRoots(ctx context.Context, sessionsDirs []string, interval time.Duration, opts Options, emit func(Event)) error {
+	if err := opts.Check(); err != nil {
+		return err
+	}
+	opts = opts.Normalized()
+	roots := make([]string, len(sessionsDirs))
+	for i, dir := range sessionsDirs {
+		root, err := ResolveDir(dir)
+		if err != nil {
+			return err
+		}
+		roots[i] = root
+	}
+
+	notes, err := newNotifier(roots)
+	if err != nil {
+		logger.Warn("file change notifications unavailable, polling", "err", err)
+	}
+	defer func() {
+		if notes != nil {
+			notes.close()
+		}
+	}()
+
+	files := make(map[string]*fileState)
+	previous := scanChanges(roots, &opts, files, notes)
+	lastScan := time.Now()
+	var scan, resync <-chan time.Time
+	for {
+		if notes != nil && notes.err != nil {
+			logger.Warn("cannot watch every directory, polling", "err", notes.err)
+			notes.close()
+			notes = nil
+		}
+		var (
+			events <-chan fsnotify.Event
+			errs   <-chan error
+		)
+		if notes != nil {
+			events, errs = notes.watcher.Events, notes.watcher.Errors
+			if resync == nil {
+				resync = time.After(max(interval, notifyResync))
+			}
+		} else if scan == nil {
+			scan = time.After(time.Until(lastScan.Add(interval)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				notes.err = errNotifierClosed
+			} else if notes.changed(ev) && scan == nil {
+				scan = time.After(max(time.Until(lastScan.Add(interval)), notifySettle))
+			}
+			continue
+		case err, ok := <-errs:
+			if !ok {
+				notes.err = errNotifierClosed
+				continue
+			}
+			// Dropped notifications leave only a rescan to find what changed.
+			logger.Warn("file change notifications", "err", err)
+			if scan == nil {
+				scan = time.After(notifySettle)
+			}
+			continue
+		case <-scan:
+		case <-resync:
+		}
+
+		scan, resync = nil, nil
+		current := scanChanges(roots, &opts, files, notes)
+		lastScan = time.Now()
+		for id, sess := range current {
+			old, ok := previous[id]
+			switch {
+			case !ok:
+				emit(Event{Kind: EventCreated, Session: sess})
+			case sessionChanged(old, sess):
+				emit(Event{Kind: EventUpdated, Session: sess})
+			}
+		}
+		for id, sess := range previous {
+			if _, ok := current[id]; !ok {
+				emit(Event{Kind: EventDeleted, Session: sess})
+			}
+		}
+		previous = current
+	}
+}
+
+// scanChanges refreshes files with the current state of roots, read with the options o, and
+// returns the aggregated sessions. The directories visited are added to notes.
+func scanChanges(roots []string, o *Options, files map[string]*fileState, notes *notifier) map[string]Session {
+	seen := make(map[string]bool, len(files))
+	for _, root := range roots {
+		scanRoot(root, o, files, seen, notes)
+	}
+
+	byID := make(map[string]*Session)
+	for _, root := range roots {
+		for path, state := range files {
+			if !seen[path] {
+				delete(files, path)
+				forgetDigest(path)
+				continue
+			}
+			if state.session.Root == root {
+				mergeInto(byID, state.session, o)
+			}
+		}
+	}
+
+	out := make(map[string]Session, len(byID))
+	for _, sess := range collect(byID) {
+		out[sess.ID] = sess
+	}
+	return out
+}
+
+// scanRoot re-parses the rollout files below root whose size or modification time changed,
+// records every file found in seen and watches every directory visited with notes.
+func scanRoot(root string, o *Options, files map[string]*fileState, seen map[string]bool, notes *notifier) {
+	_ = walkTree(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if isTrashDir(root, path) || o.excluded(root, path, true) {
+				return filepath.SkipDir
+			}
+			notes.watch(path)
+			return nil
+		}
+		if filepath.Ext(path) != ".jsonl" || o.excluded(root, path, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[path] = true
+		state := files[path]
+		if state != nil && state.modTime.Equal(info.ModTime()) && state.size == info.Size() {
+			return nil
+		}
+		session, err := parseSessionFile(path, o)
+		if err != nil {
+			// Likely a partially written line. The last good state stays, with its old size and
+			// time, so the session is not reported deleted and the file is parsed again on the
+			// next scan.
+			return nil
+		}
+		session.setRoot(root, o)
+		files[path] = &fileState{modTime: info.ModTime(), size: info.Size(), session: session}
+		return nil
+	})
+}
+
+func sessionChanged(a, b Session) bool {
+	if !a.UpdatedAt.Equal(b.UpdatedAt) || a.LastAction != b.LastAction || a.WorkingDir != b.WorkingDir {
+		return true
+	}
+	if len(a.FilePaths) != len(b.FilePaths) {
+		return true
+	}
+	for i := range a.FilePaths {
+		if a.FilePaths[i] != b.FilePaths[i] {
+			return true
+		}
+	}
+	return false
+}
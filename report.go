@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// aggregate sums the sessions of one day or project for `stats --format csv|json`.
+type aggregate struct {
+	Key      string `json:"key"`
+	Sessions int    `json:"sessions"`
+	Messages int    `json:"messages"`
+	sessions.TokenUsage
+	DurationSeconds float64 `json:"duration_seconds"`
+	// CostUSD is the estimated cost of the sessions whose model has a configured price.
+	CostUSD float64 `json:"cost_usd"`
+}
+
+func (a *aggregate) add(sess sessions.Session, prices sessions.PriceTable) {
+	a.Sessions++
+	a.Messages += sess.Messages()
+	a.InputTokens += sess.Tokens.InputTokens
+	a.CachedInputTokens += sess.Tokens.CachedInputTokens
+	a.OutputTokens += sess.Tokens.OutputTokens
+	a.ReasoningTokens += sess.Tokens.ReasoningTokens
+	a.TotalTokens += sess.Tokens.TotalTokens
+	a.DurationSeconds += sess.Duration().Seconds()
+	if cost, ok := prices.Cost(sess); ok {
+		a.CostUSD += cost
+	}
+}
+
+// statsReport holds the per-day and per-project aggregates. Days are keyed by the local date the
+// session started, projects by working directory.
+type statsReport struct {
+	Total    aggregate   `json:"total"`
+	Days     []aggregate `json:"days"`
+	Projects []aggregate `json:"projects"`
+}
+
+func buildReport(list []sessions.Session, prices sessions.PriceTable) statsReport {
+	days := make(map[string]*aggregate)
+	projects := make(map[string]*aggregate)
+	report := statsReport{Total: aggregate{Key: "total"}}
+	for _, sess := range list {
+		started := sess.CreatedAt
+		if started.IsZero() {
+			started = sess.UpdatedAt
+		}
+		addTo(days, started.Local().Format("2006-01-02"), sess, prices)
+		addTo(projects, sess.WorkingDir, sess, prices)
+		report.Total.add(sess, prices)
+	}
+	report.Days = sortedAggregates(days)
+	report.Projects = sortedAggregates(projects)
+	return report
+}
+
+func addTo(groups map[string]*aggregate, key string, sess sessions.Session, prices sessions.PriceTable) {
+	a := groups[key]
+	if a == nil {
+		a = &aggregate{Key: key}
+		groups[key] = a
+	}
+	a.add(sess, prices)
+}
+
+func sortedAggregates(groups map[string]*aggregate) []aggregate {
+	out := make([]aggregate, 0, len(groups))
+	for _, a := range groups {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func writeReportJSON(w io.Writer, report statsReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeReportCSV writes one row per aggregate; the scope column tells days, projects and the
+// total apart.
+func writeReportCSV(w io.Writer, report statsReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"scope", "key", "sessions", "messages", "input_tokens", "cached_input_tokens", "output_tokens", "reasoning_output_tokens", "total_tokens", "duration_seconds", "cost_usd"})
+	row := func(scope string, a aggregate) {
+		cw.Write([]string{
+			scope, a.Key,
+			strconv.Itoa(a.Sessions), strconv.Itoa(a.Messages),
+			strconv.FormatInt(a.InputTokens, 10), strconv.FormatInt(a.CachedInputTokens, 10),
+			strconv.FormatInt(a.OutputTokens, 10), strconv.FormatInt(a.ReasoningTokens, 10),
+			strconv.FormatInt(a.TotalTokens, 10),
+			strconv.FormatFloat(a.DurationSeconds, 'f', 0, 64),
+			strconv.FormatFloat(a.CostUSD, 'f', 4, 64),
+		})
+	}
+	for _, a := range report.Days {
+		row("day", a)
+	}
+	for _, a := range report.Projects {
+		row("project", a)
+	}
+	row("total", report.Total)
+	cw.Flush()
+	return cw.Error()
+}
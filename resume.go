@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// resumeCommand builds the argv that resumes sess. Without a template it runs
+// `<codexBin> resume <id>`. A template is split into words first, with single or double quotes
+// grouping words, and each word is then expanded with the session as data (e.g. {{.ID}},
+// {{.Model}}, {{.WorkingDir}}), so values containing spaces stay a single argument.
+func resumeCommand(tmpl, codexBin string, sess sessions.Session, extraArgs []string) ([]string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return append([]string{codexBin, "resume", sess.ID}, extraArgs...), nil
+	}
+	words, err := splitWords(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("resume command: %w", err)
+	}
+	var argv []string
+	for _, word := range words {
+		t, err := template.New("resume").Parse(word)
+		if err != nil {
+			return nil, fmt.Errorf("resume command: %w", err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, sess); err != nil {
+			return nil, fmt.Errorf("resume command: %w", err)
+		}
+		argv = append(argv, b.String())
+	}
+	return append(argv, extraArgs...), nil
+}
+
+// splitWords splits s at unquoted whitespace. Single and double quotes group text into one word
+// and are removed; there are no escapes, which keeps Windows paths intact.
+func splitWords(s string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+		quote  rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return words, nil
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Uri2001/codex-sessions/internal/sidecar"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+//go:embed web/index.html
+var webPage []byte
+
+// transcriptRecord is the JSON form of a transcript entry served by `serve`.
+type transcriptRecord struct {
+	Time time.Time `json:"time"`
+	Role string    `json:"role"`
+	Name string    `json:"name,omitempty"`
+	Text string    `json:"text"`
+}
+
+// searchResult is one session matched by /api/search together with the matching entries.
+type searchResult struct {
+	sessionRecord
+	Matches []searchMatch `json:"matches"`
+}
+
+type searchMatch struct {
+	Index   int    `json:"index"`
+	Role    string `json:"role"`
+	Snippet string `json:"snippet"`
+}
+
+// server answers the read-only HTTP API. Sessions are loaded per request (from the daemon when
+// one is running) so responses always reflect the directory.
+type server struct {
+	e   *env
+	web bool
+	// addrs are the Host headers besides the loopback names that requests may carry: the
+	// --listen address and the address actually listened on.
+	addrs []string
+}
+
+func runServe(e *env, args []string) error {
+	fs := newFlagSet("serve")
+	listen := fs.String("listen", "127.0.0.1:7788", "Address to listen on.")
+	web := fs.Bool("web", false, "Also serve a browser viewer for sessions and transcripts at /.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	s := &server{e: e, web: *web, addrs: []string{*listen, listener.Addr().String()}}
+	srv := &http.Server{Handler: s.guard(s.routes()), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	if *web {
+		fmt.Fprintf(os.Stderr, "open http://%s/ in a browser\n", listener.Addr())
+	} else {
+		fmt.Fprintf(os.Stderr, "listening on http://%s\n", listener.Addr())
+	}
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/sessions", s.handleList)
+	mux.HandleFunc("GET /api/sessions/{id}", s.handleSession)
+	mux.HandleFunc("GET /api/sessions/{id}/transcript", s.handleTranscript)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	if s.web {
+		mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(webPage)
+		})
+	}
+	return mux
+}
+
+// loopbackHosts are the host names a browser on this machine reaches the server by.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// guard rejects requests a web page may have made on the user's behalf. A Host other than a
+// loopback name or the listen address means DNS rebinding: a page whose domain was pointed at
+// 127.0.0.1 after it loaded, now reading the API as its own origin. Cross-origin requests to the
+// API are refused as well, as the server has no authentication.
+func (s *server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/api/") && crossOrigin(r) {
+			writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether host, the Host header of a request, names the server: a loopback
+// name on any port, or the address it listens on.
+func (s *server) allowedHost(host string) bool {
+	if slices.Contains(s.addrs, host) {
+		return true
+	}
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	return slices.Contains(loopbackHosts, strings.ToLower(name))
+}
+
+// crossOrigin reports whether r was sent by a page of another origin, judged by the Origin and
+// Sec-Fetch-Site headers browsers add. Requests from tools other than browsers carry neither.
+func crossOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+// visibleSessions loads the sessions (from the daemon when one is running) within --since and
+// --until, leaving out hidden ones unless --all is set.
+func visibleSessions(e *env) []sessions.Session {
+	list, err := loadFromDaemon(e)
+	if err != nil {
+		warnf("%v", err)
+	}
+	list = e.timeRange.Filter(list)
+	if *flagAll {
+		return list
+	}
+	meta, _ := sidecar.Open(e.root)
+	visible := list[:0]
+	for _, sess := range list {
+		if !meta.Get(sess.ID).Hidden {
+			visible = append(visible, sess)
+		}
+	}
+	return visible
+}
+
+// handleList serves GET /api/sessions. q filters by a case-insensitive substring of the ID,
+// directory or last action; limit caps the number of records.
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("q"))
+	limit, err := limitParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, filterRecords(s.e, visibleSessions(s.e), query, limit))
+}
+
+func (s *server) handleSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.lookup(w, r)
+	if ok {
+		writeJSON(w, newSessionRecord(s.e, sess))
+	}
+}
+
+// handleTranscript serves GET /api/sessions/{id}/transcript as JSON, or as Markdown with
+// format=markdown.
+func (s *server) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	entries, err := s.e.readTranscript(sess)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_ = sessions.WriteMarkdown(w, sess, entries)
+		return
+	}
+	records := make([]transcriptRecord, len(entries))
+	for i, entry := range entries {
+		records[i] = transcriptRecord{Time: entry.Timestamp, Role: entry.Role, Name: entry.Name, Text: entry.Text}
+	}
+	writeJSON(w, records)
+}
+
+// handleSearch serves GET /api/search, a case-insensitive full-text search over every transcript.
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing q parameter"))
+		return
+	}
+	limit, err := limitParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, _ := contentCandidates(s.e, visibleSessions(s.e), substringPattern(query))
+	writeJSON(w, searchTranscripts(s.e, list, query, limit))
+}
+
+// filterRecords returns up to limit (0 for all) sessions whose ID, directory or last action
+// contains the lower-case query.
+func filterRecords(e *env, list []sessions.Session, query string, limit int) []sessionRecord {
+	records := []sessionRecord{}
+	for _, sess := range list {
+		if limit > 0 && len(records) == limit {
+			break
+		}
+		text := strings.ToLower(sess.ID + "\n" + sess.WorkingDir + "\n" + sess.LastAction)
+		if query == "" || strings.Contains(text, query) {
+			records = append(records, newSessionRecord(e, sess))
+		}
+	}
+	return records
+}
+
+// searchTranscripts returns up to limit (0 for all) sessions whose transcript, with secrets
+// masked, contains the lower-case query, with every matching entry.
+func searchTranscripts(e *env, list []sessions.Session, query string, limit int) []searchResult {
+	results := []searchResult{}
+	// Lower-casing can change the length of the text, so matches are found in the original to
+	// get offsets the snippet can be cut at.
+	re := regexp.MustCompile(substringPattern(query))
+	for _, sess := range list {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		entries, err := e.readTranscript(sess)
+		if err != nil {
+			continue
+		}
+		var matches []searchMatch
+		for i, entry := range entries {
+			if loc := re.FindStringIndex(entry.Text); loc != nil {
+				matches = append(matches, searchMatch{Index: i, Role: entry.Heading(), Snippet: snippet(entry.Text, loc[0], loc[1]-loc[0])})
+			}
+		}
+		if len(matches) > 0 {
+			results = append(results, searchResult{sessionRecord: newSessionRecord(e, sess), Matches: matches})
+		}
+	}
+	return results
+}
+
+func (s *server) lookup(w http.ResponseWriter, r *http.Request) (sessions.Session, bool) {
+	sess, err := resolveSession(visibleSessions(s.e), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return sessions.Session{}, false
+	}
+	return sess, true
+}
+
+func limitParam(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	return limit, nil
+}
+
+// snippet returns the text around text[pos:pos+n] on a single line.
+func snippet(text string, pos, n int) string {
+	const radius = 60
+	start, end := max(0, pos-radius), min(len(text), pos+n+radius)
+	for start > 0 && !isRuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text[end]) {
+		end++
+	}
+	out := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
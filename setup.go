@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/Uri2001/codex-sessions/internal/config"
+	"github.com/Uri2001/codex-sessions/internal/ui"
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// firstRun shows the setup screen when none of the sessions roots exist. It reports whether the
+// UI should open afterwards and a status line describing what was set up.
+func firstRun(e *env) (bool, string, error) {
+	for _, root := range e.roots {
+		if _, err := os.Stat(root); !errors.Is(err, os.ErrNotExist) {
+			return true, "", nil
+		}
+	}
+
+	setup := ui.Setup{SessionsDir: e.root}
+	if bin, err := resolveCodexBin(*flagCodexBin); err == nil {
+		setup.CodexPath, _ = exec.LookPath(bin)
+	}
+	configPath := *flagConfig
+	if configPath == "" {
+		configPath, _ = config.DefaultPath()
+	}
+	if configPath != "" {
+		_, err := os.Stat(configPath)
+		setup.ConfigPath, setup.ConfigExists = configPath, err == nil
+	}
+
+	choice, err := ui.RunSetup(setup)
+	if err != nil {
+		return false, "", fmt.Errorf("run setup: %w", err)
+	}
+	if !choice.Continue {
+		return false, "", nil
+	}
+
+	var status string
+	if choice.CreateDir {
+		if err := sessions.CreateRoot(e.root, e.files); err != nil {
+			status = err.Error()
+		} else {
+			status = fmt.Sprintf("Created %s; sessions appear here once you run codex", e.root)
+		}
+	}
+	if choice.WriteConfig {
+		// Only directories given explicitly are recorded, so the default keeps following
+		// CODEX_HOME and friends.
+		cfg := config.Config{Keymap: config.KeymapDefault, SessionsDirs: flagSessionsDirs}
+		if err := config.Create(configPath, cfg); err != nil {
+			status = err.Error()
+		} else {
+			e.cfgLoaded = false
+		}
+	}
+	return true, status, nil
+}
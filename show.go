@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/Uri2001/codex-sessions/pkg/sessions"
+)
+
+// runShow prints a session transcript as colored text through $PAGER. Without a terminal on
+// stdout, or with --no-pager, the text is written to stdout directly and only colored on a
+// terminal.
+func runShow(e *env, args []string) error {
+	fs := newFlagSet("show")
+	noPager := fs.Bool("no-pager", false, "Write to stdout instead of $PAGER.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	sess, err := resolveSession(loadSessions(e), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := e.readTranscript(sess)
+	if err != nil {
+		return err
+	}
+
+	terminal := isTerminal(os.Stdout)
+	_, noColor := os.LookupEnv("NO_COLOR")
+	var text bytes.Buffer
+	if err := sessions.WriteText(&text, sess, entries, terminal && !noColor); err != nil {
+		return err
+	}
+	if *noPager || !terminal {
+		_, err := text.WriteTo(os.Stdout)
+		return err
+	}
+	return page(&text)
+}
+
+// page runs $PAGER (less, or more on Windows, when it is unset) with r on its stdin. Less is
+// told to pass colors through and to exit right away when the text fits on one screen, unless
+// $LESS says otherwise. When the pager cannot be started, r is written to stdout instead.
+func page(r io.Reader) error {
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		pager = "less"
+		if runtime.GOOS == "windows" {
+			pager = "more"
+		}
+	}
+	// $PAGER may carry arguments, e.g. "less -S".
+	argv := strings.Fields(pager)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = r, os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		warnf("cannot start pager %q: %v", argv[0], err)
+		_, err := io.Copy(os.Stdout, r)
+		return err
+	}
+	return cmd.Wait()
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}